@@ -32,53 +32,96 @@ import (
 
 const maxChunkSize = 8 * 1024 * 1024 // 8 MB chunk size
 
+// maxErrorMessageBytes is the maximum size of the message of an APIError built
+// from a response body that is not a JSON error.
+const maxErrorMessageBytes = 1024
+
 type apiClient struct {
 	clientConfig *ClientConfig
+	telemetry    *telemetry
 }
 
 // sendStreamRequest issues an server streaming API request and returns a map of the response contents.
-func sendStreamRequest[T responseStream[R], R any](ctx context.Context, ac *apiClient, path string, method string, body map[string]any, httpOptions *HTTPOptions, output *responseStream[R]) error {
+func sendStreamRequest[T responseStream[R], R any](ctx context.Context, ac *apiClient, path string, method string, body map[string]any, httpOptions *HTTPOptions, output *responseStream[R]) (err error) {
+	ctx = withCallerOperation(ctx)
+	ctx, span := ac.startSpan(ctx, modelFromPath(path), body, httpOptions)
+	defer func() {
+		// On success the span is ended by the iterator.
+		if err != nil {
+			span.end(err)
+		}
+	}()
 	req, err := buildRequest(ctx, ac, path, body, method, httpOptions)
 	if err != nil {
 		return err
 	}
 
-	resp, err := doRequest(ac, req)
+	call := &InterceptedCall{Operation: operationFromContext(ctx), Kind: CallKindStream, Body: body, Request: req}
+	resp, err := intercept(ctx, ac, call, func(ctx context.Context, call *InterceptedCall) (*InterceptedResponse, error) {
+		resp, err := doRequest(ac, call.Request, httpOptions)
+		if err != nil {
+			return nil, err
+		}
+		if !httpStatusOk(resp) {
+			defer resp.Body.Close()
+			return nil, newAPIError(resp)
+		}
+		return &InterceptedResponse{HTTPResponse: resp}, nil
+	})
 	if err != nil {
 		return err
 	}
 
 	// resp.Body will be closed by the iterator
-	return deserializeStreamResponse(resp, output)
+	if err := deserializeStreamResponse(resp.HTTPResponse, output); err != nil {
+		return err
+	}
+	output.span = span
+	return nil
 }
 
 // sendRequest issues an API request and returns a map of the response contents.
-func sendRequest(ctx context.Context, ac *apiClient, path string, method string, body map[string]any, httpOptions *HTTPOptions) (map[string]any, error) {
+func sendRequest(ctx context.Context, ac *apiClient, path string, method string, body map[string]any, httpOptions *HTTPOptions) (output map[string]any, err error) {
+	ctx = withCallerOperation(ctx)
+	ctx, span := ac.startSpan(ctx, modelFromPath(path), body, httpOptions)
+	defer func() {
+		span.observe(output)
+		span.end(err)
+	}()
 	req, err := buildRequest(ctx, ac, path, body, method, httpOptions)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := doRequest(ac, req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	return deserializeUnaryResponse(resp)
-}
+	call := &InterceptedCall{Operation: operationFromContext(ctx), Kind: CallKindUnary, Body: body, Request: req}
+	resp, err := intercept(ctx, ac, call, func(ctx context.Context, call *InterceptedCall) (*InterceptedResponse, error) {
+		resp, err := doRequest(ac, call.Request, httpOptions)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
 
-func downloadFile(ctx context.Context, ac *apiClient, path string, httpOptions *HTTPOptions) ([]byte, error) {
-	req, err := buildRequest(ctx, ac, path, nil, http.MethodGet, httpOptions)
+		output, err := deserializeUnaryResponse(resp)
+		if err != nil {
+			return nil, err
+		}
+		return &InterceptedResponse{HTTPResponse: resp, Body: output}, nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	if resp.Body == nil {
+		return make(map[string]any), nil
+	}
+	return resp.Body, nil
+}
 
-	resp, err := doRequest(ac, req)
-	if err != nil {
+func downloadFile(ctx context.Context, ac *apiClient, path string, httpOptions *HTTPOptions) (data []byte, err error) {
+	var b bytes.Buffer
+	if _, err := newDownloader(ac, path, httpOptions, nil).download(ctx, &b); err != nil {
 		return nil, err
 	}
-	return io.ReadAll(resp.Body)
+	return b.Bytes(), nil
 }
 
 func mapToStruct[R any](input map[string]any, output *R) error {
@@ -137,6 +180,7 @@ func buildRequest(ctx context.Context, ac *apiClient, path string, body map[stri
 	// Set headers
 	doMergeHeaders(httpOptions.Headers, &req.Header)
 	doMergeHeaders(sdkHeader(ctx, ac), &req.Header)
+	ac.injectTraceContext(ctx, req.Header)
 	return req, nil
 }
 
@@ -174,14 +218,10 @@ func inferTimeout(ctx context.Context, ac *apiClient) time.Duration {
 	return contextTimeout
 }
 
-func doRequest(ac *apiClient, req *http.Request) (*http.Response, error) {
-	// Create a new HTTP client and send the request
-	client := ac.clientConfig.HTTPClient
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("doRequest: error sending request: %w", err)
-	}
-	return resp, nil
+func doRequest(ac *apiClient, req *http.Request, httpOptions *HTTPOptions) (*http.Response, error) {
+	// Send the request with the client's HTTP client, retrying transient failures
+	// if retry options are configured.
+	return doRequestWithRetry(ac, req, resolveRetryOptions(ac, httpOptions))
 }
 
 func deserializeUnaryResponse(resp *http.Response) (map[string]any, error) {
@@ -205,17 +245,23 @@ func deserializeUnaryResponse(resp *http.Response) (map[string]any, error) {
 }
 
 type responseStream[R any] struct {
-	r  *bufio.Scanner
-	rc io.ReadCloser
+	r    *bufio.Scanner
+	rc   io.ReadCloser
+	span *callSpan
 }
 
 func iterateResponseStream[R any](rs *responseStream[R], responseConverter func(responseMap map[string]any) (*R, error)) iter.Seq2[*R, error] {
 	return func(yield func(*R, error) bool) {
+		var streamErr error
 		defer func() {
 			// Close the response body range over function is done.
 			if err := rs.rc.Close(); err != nil {
 				log.Printf("Error closing response body: %v", err)
 			}
+			if streamErr == nil {
+				streamErr = rs.r.Err()
+			}
+			rs.span.end(streamErr)
 		}()
 		for rs.r.Scan() {
 			line := rs.r.Bytes()
@@ -230,16 +276,25 @@ func iterateResponseStream[R any](rs *responseStream[R], responseConverter func(
 				respRaw := make(map[string]any)
 				if err := json.Unmarshal(data, &respRaw); err != nil {
 					err = fmt.Errorf("iterateResponseStream: error unmarshalling data %s:%s. error: %w", string(prefix), string(data), err)
+					streamErr = err
 					if !yield(nil, err) {
 						return
 					}
 				}
+				rs.span.chunk(respRaw)
+				// The server reports errors that occur after the stream started as a chunk.
+				if apiErr, ok := apiErrorFromMap(respRaw); ok {
+					streamErr = apiErr
+					yield(nil, apiErr)
+					return
+				}
 				// Step 2: The toStruct function calls fromConverter(handle Vertex and MLDev schema
 				// difference and get a unified response). Then toStruct function converts the unified
 				// response from map[string]any to struct type.
 				// var resp = new(R)
 				resp, err := responseConverter(respRaw)
 				if err != nil {
+					streamErr = err
 					if !yield(nil, err) {
 						return
 					}
@@ -251,7 +306,8 @@ func iterateResponseStream[R any](rs *responseStream[R], responseConverter func(
 				}
 			default:
 				// Stream chunk not started with "data" is treated as an error.
-				if !yield(nil, fmt.Errorf("iterateResponseStream: invalid stream chunk: %s:%s", string(prefix), string(data))) {
+				streamErr = fmt.Errorf("iterateResponseStream: invalid stream chunk: %s:%s", string(prefix), string(data))
+				if !yield(nil, streamErr) {
 					return
 				}
 			}
@@ -266,6 +322,9 @@ func iterateResponseStream[R any](rs *responseStream[R], responseConverter func(
 }
 
 // APIError contains an error response from the server.
+//
+// APIError matches the sentinel error of its failure class with errors.Is, such
+// as [ErrRateLimited] or [ErrNotFound].
 type APIError struct {
 	// Code is the HTTP response status code.
 	Code int `json:"code,omitempty"`
@@ -273,7 +332,8 @@ type APIError struct {
 	Message string `json:"message,omitempty"`
 	// Status is the server response status.
 	Status string `json:"status,omitempty"`
-	// Details field provides more context to an error.
+	// Details field provides more context to an error. See ErrorDetails for their
+	// typed form.
 	Details []map[string]any `json:"details,omitempty"`
 }
 
@@ -289,15 +349,29 @@ func newAPIError(resp *http.Response) error {
 	}
 
 	if len(body) > 0 {
-		if err := json.Unmarshal(body, respWithError); err != nil {
+		if err := json.Unmarshal(body, respWithError); err != nil || respWithError.ErrorInfo == nil {
 			// Handle plain text error message. File upload backend doesn't return json error message.
-			return APIError{Code: resp.StatusCode, Status: resp.Status, Message: string(body)}
+			// The body may be a whole HTML page.
+			return APIError{Code: resp.StatusCode, Status: resp.Status, Message: truncateErrorMessage(body)}
+		}
+		if respWithError.ErrorInfo.Code == 0 {
+			respWithError.ErrorInfo.Code = resp.StatusCode
 		}
 		return *respWithError.ErrorInfo
 	}
 	return APIError{Code: resp.StatusCode, Status: resp.Status}
 }
 
+// truncateErrorMessage returns the body of an error response as a message of at
+// most maxErrorMessageBytes bytes.
+func truncateErrorMessage(body []byte) string {
+	if len(body) <= maxErrorMessageBytes {
+		return string(body)
+	}
+	// Drop the rune cut by the truncation.
+	return strings.ToValidUTF8(string(body[:maxErrorMessageBytes]), "") + "..."
+}
+
 // Error returns a string representation of the APIError.
 func (e APIError) Error() string {
 	return fmt.Sprintf(
@@ -359,71 +433,9 @@ func scan(data []byte, atEOF bool) (advance int, token []byte, err error) {
 }
 
 func (ac *apiClient) uploadFile(ctx context.Context, r io.Reader, uploadURL string, httpOptions *HTTPOptions) (*File, error) {
-	var offset int64 = 0
-	var resp *http.Response
-	var respBody map[string]any
-	var uploadCommand = "upload"
-
-	buffer := make([]byte, maxChunkSize)
-	for {
-		bytesRead, err := io.ReadFull(r, buffer)
-		// Check both EOF and UnexpectedEOF errors.
-		// ErrUnexpectedEOF: Reading a file file_size%maxChunkSize<len(buffer).
-		// EOF: Reading a file file_size%maxChunkSize==0. The underlying reader return 0 bytes buffer and EOF at next call.
-		if err == io.EOF || err == io.ErrUnexpectedEOF {
-			uploadCommand += ", finalize"
-		} else if err != nil {
-			return nil, fmt.Errorf("Failed to read bytes from file at offset %d: %w. Bytes actually read: %d", offset, err, bytesRead)
-		}
-
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, bytes.NewReader(buffer[:bytesRead]))
-		if err != nil {
-			return nil, fmt.Errorf("Failed to create upload request for chunk at offset %d: %w", offset, err)
-		}
-		doMergeHeaders(httpOptions.Headers, &req.Header)
-		doMergeHeaders(sdkHeader(ctx, ac), &req.Header)
-
-		req.Header.Set("X-Goog-Upload-Command", uploadCommand)
-		req.Header.Set("X-Goog-Upload-Offset", strconv.FormatInt(offset, 10))
-		req.Header.Set("Content-Length", strconv.FormatInt(int64(bytesRead), 10))
-
-		resp, err = doRequest(ac, req)
-		if err != nil {
-			return nil, fmt.Errorf("upload request failed for chunk at offset %d: %w", offset, err)
-		}
-		defer resp.Body.Close()
-
-		respBody, err = deserializeUnaryResponse(resp)
-		if err != nil {
-			return nil, fmt.Errorf("response body is invalid for chunk at offset %d: %w", offset, err)
-		}
-
-		offset += int64(bytesRead)
-
-		uploadStatus := resp.Header.Get("X-Goog-Upload-Status")
-
-		if uploadStatus != "final" && strings.Contains(uploadCommand, "finalize") {
-			return nil, fmt.Errorf("send finalize command but doesn't receive final status. Offset %d, Bytes read: %d, Upload status: %s", offset, bytesRead, uploadStatus)
-		}
-		if uploadStatus != "active" {
-			// Upload is complete ('final') or interrupted ('cancelled', etc.)
-			break
-		}
-	}
-
-	if resp == nil {
-		return nil, fmt.Errorf("Upload request failed. No response received")
-	}
-
-	finalUploadStatus := resp.Header.Get("X-Goog-Upload-Status")
-	if finalUploadStatus != "final" {
-		return nil, fmt.Errorf("Failed to upload file: Upload status is not finalized")
-	}
-
-	var response = new(File)
-	err := mapToStruct(respBody["file"].(map[string]any), &response)
+	u, err := newUploader(ac, &UploadSession{URL: uploadURL}, httpOptions, nil)
 	if err != nil {
 		return nil, err
 	}
-	return response, nil
+	return u.upload(ctx, r)
 }
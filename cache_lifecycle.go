@@ -0,0 +1,352 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCacheKeeperTTL           = time.Hour
+	defaultCacheKeeperRefreshMargin = 5 * time.Minute
+	// cacheKeeperRetryDelay is the delay before a failed refresh is retried.
+	cacheKeeperRetryDelay = 10 * time.Second
+	// cacheReleaseTimeout bounds the deletion of the caches of a keeper whose
+	// context is done.
+	cacheReleaseTimeout = 30 * time.Second
+)
+
+// CacheKeeperConfig configures a [CacheKeeper]. Zero valued fields use the
+// documented defaults.
+type CacheKeeperConfig struct {
+	// TTL set on the caches when they are refreshed. Defaults to 1h.
+	TTL time.Duration
+	// The caches are refreshed when they expire within RefreshMargin. Defaults to
+	// 5m, and is at most half of TTL.
+	RefreshMargin time.Duration
+	// Optional. Called when a cache can't be refreshed or deleted. A cache that is
+	// not found is no longer kept; other refreshes are retried.
+	OnError func(name string, err error)
+}
+
+// CacheKeeper keeps a set of caches alive, by updating their TTL with
+// Caches.Update before they expire, and deletes them when they are released,
+// when the keeper is closed, or when the context of the keeper is done.
+//
+// A CacheKeeper is safe for concurrent use.
+type CacheKeeper struct {
+	caches *Caches
+	config CacheKeeperConfig
+	// wake interrupts the wait of the keeper loop, after a change of the caches.
+	wake chan struct{}
+	// stop stops the keeper loop, and done is closed when it returned.
+	stop func()
+	done chan struct{}
+
+	mu     sync.Mutex
+	kept   map[string]*keptCache
+	closed bool
+}
+
+type keptCache struct {
+	expireTime time.Time
+	// refreshTime is the time of the next refresh.
+	refreshTime time.Time
+}
+
+// NewCacheKeeper creates a CacheKeeper that uses the Caches of the client.
+// When ctx is done, the keeper deletes its caches and stops, as Close does.
+func NewCacheKeeper(ctx context.Context, client *Client, config *CacheKeeperConfig) *CacheKeeper {
+	k := &CacheKeeper{
+		caches: client.Caches,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		kept:   make(map[string]*keptCache),
+	}
+	if config != nil {
+		k.config = *config
+	}
+	if k.config.TTL <= 0 {
+		k.config.TTL = defaultCacheKeeperTTL
+	}
+	if k.config.RefreshMargin <= 0 {
+		k.config.RefreshMargin = defaultCacheKeeperRefreshMargin
+	}
+	// Otherwise a refreshed cache would be due for a refresh right away.
+	k.config.RefreshMargin = min(k.config.RefreshMargin, k.config.TTL/2)
+	loopCtx, stop := context.WithCancel(ctx)
+	k.stop = stop
+	go k.loop(ctx, loopCtx)
+	return k
+}
+
+// Keep adds the cache to the caches kept alive. If the cache has no expiration
+// time, or if it expires within the refresh margin, it is refreshed right away.
+func (k *CacheKeeper) Keep(cache *CachedContent) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.closed {
+		return fmt.Errorf("CacheKeeper.Keep: keeper is closed")
+	}
+	k.kept[cache.Name] = &keptCache{expireTime: cache.ExpireTime, refreshTime: k.refreshTime(cache.ExpireTime)}
+	k.notify()
+	return nil
+}
+
+// Forget stops keeping the cache alive, without deleting it.
+func (k *CacheKeeper) Forget(name string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	delete(k.kept, name)
+}
+
+// Release stops keeping the cache alive and deletes it.
+func (k *CacheKeeper) Release(ctx context.Context, name string) error {
+	k.Forget(name)
+	if _, err := k.caches.Delete(ctx, name, nil); err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("CacheKeeper.Release: %w", err)
+	}
+	return nil
+}
+
+// ExpireTime returns the expiration time of a kept cache, as of its last
+// refresh.
+func (k *CacheKeeper) ExpireTime(name string) (time.Time, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	kept, ok := k.kept[name]
+	if !ok {
+		return time.Time{}, false
+	}
+	return kept.expireTime, true
+}
+
+// Close stops the keeper and deletes the caches that it keeps.
+func (k *CacheKeeper) Close(ctx context.Context) error {
+	k.stop()
+	<-k.done
+	return k.releaseAll(ctx)
+}
+
+// loop refreshes the caches until loopCtx is done. If ctx is done, it deletes
+// the caches.
+func (k *CacheKeeper) loop(ctx, loopCtx context.Context) {
+	defer close(k.done)
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-loopCtx.Done():
+			if ctx.Err() != nil {
+				// The owning context is done, not just the keeper closed.
+				releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cacheReleaseTimeout)
+				k.releaseAll(releaseCtx)
+				cancel()
+			}
+			return
+		case <-k.wake:
+		case <-timer.C:
+		}
+		next := k.refreshDue(loopCtx)
+		timer.Reset(time.Until(next))
+	}
+}
+
+// refreshDue refreshes the caches whose refresh time passed, and returns the
+// time of the next refresh.
+func (k *CacheKeeper) refreshDue(ctx context.Context) time.Time {
+	now := time.Now()
+	var due []string
+	k.mu.Lock()
+	for name, kept := range k.kept {
+		if !kept.refreshTime.After(now) {
+			due = append(due, name)
+		}
+	}
+	k.mu.Unlock()
+
+	for _, name := range due {
+		cache, err := k.caches.Update(ctx, name, &UpdateCachedContentConfig{TTL: k.config.TTL})
+		if ctx.Err() != nil {
+			break
+		}
+		k.mu.Lock()
+		kept, ok := k.kept[name]
+		switch {
+		case !ok:
+			// The cache was forgotten during the refresh.
+		case err == nil:
+			kept.expireTime = cache.ExpireTime
+			if kept.expireTime.IsZero() {
+				kept.expireTime = time.Now().Add(k.config.TTL)
+			}
+			kept.refreshTime = k.refreshTime(kept.expireTime)
+		case errors.Is(err, ErrNotFound):
+			delete(k.kept, name)
+		default:
+			kept.refreshTime = time.Now().Add(cacheKeeperRetryDelay)
+		}
+		k.mu.Unlock()
+		if err != nil {
+			k.reportError(name, err)
+		}
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	next := now.Add(k.config.TTL)
+	for _, kept := range k.kept {
+		if kept.refreshTime.Before(next) {
+			next = kept.refreshTime
+		}
+	}
+	return next
+}
+
+// releaseAll deletes the kept caches, and closes the keeper.
+func (k *CacheKeeper) releaseAll(ctx context.Context) error {
+	k.mu.Lock()
+	k.closed = true
+	kept := k.kept
+	k.kept = make(map[string]*keptCache)
+	k.mu.Unlock()
+
+	var errs []error
+	for name := range kept {
+		if _, err := k.caches.Delete(ctx, name, nil); err != nil && !errors.Is(err, ErrNotFound) {
+			k.reportError(name, err)
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("CacheKeeper.Close: %w", err)
+	}
+	return nil
+}
+
+// refreshTime returns the time to refresh a cache expiring at expireTime.
+func (k *CacheKeeper) refreshTime(expireTime time.Time) time.Time {
+	return expireTime.Add(-k.config.RefreshMargin)
+}
+
+// notify wakes the keeper loop up. The caller must hold k.mu.
+func (k *CacheKeeper) notify() {
+	select {
+	case k.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (k *CacheKeeper) reportError(name string, err error) {
+	if k.config.OnError != nil {
+		k.config.OnError(name, err)
+	}
+}
+
+// CacheFilter selects cached contents. A cache matches the filter if it matches
+// all of its set fields.
+type CacheFilter struct {
+	// Optional. Prefix of the display name of the caches, such as the
+	// "genai-cache-manager" display name of the caches of a [CacheManager].
+	DisplayNamePrefix string
+	// Optional. Minimum age of the caches, from their creation time.
+	OlderThan time.Duration
+	// Optional. Reports whether a cache matches.
+	Match func(*CachedContent) bool
+}
+
+func (f *CacheFilter) matches(cache *CachedContent, now time.Time) bool {
+	if f == nil {
+		return true
+	}
+	if !strings.HasPrefix(cache.DisplayName, f.DisplayNamePrefix) {
+		return false
+	}
+	if f.OlderThan > 0 && (cache.CreateTime.IsZero() || now.Sub(cache.CreateTime) < f.OlderThan) {
+		return false
+	}
+	return f.Match == nil || f.Match(cache)
+}
+
+// CacheUsage is the storage used by a set of cached contents.
+type CacheUsage struct {
+	// Caches is the number of caches.
+	Caches int
+	// Usage is the sum of the usage metadata of the caches.
+	Usage CachedContentUsageMetadata
+}
+
+// Usage returns the storage used by the caches that match the filter, from
+// their usage metadata. A nil filter matches all caches.
+func (m Caches) Usage(ctx context.Context, filter *CacheFilter) (*CacheUsage, error) {
+	usage := &CacheUsage{}
+	now := time.Now()
+	for cache, err := range m.All(ctx) {
+		if err != nil {
+			return nil, fmt.Errorf("Usage: %w", err)
+		}
+		if !filter.matches(cache, now) {
+			continue
+		}
+		usage.Caches++
+		if u := cache.UsageMetadata; u != nil {
+			usage.Usage.AudioDurationSeconds += u.AudioDurationSeconds
+			usage.Usage.ImageCount += u.ImageCount
+			usage.Usage.TextCount += u.TextCount
+			usage.Usage.TotalTokenCount += u.TotalTokenCount
+			usage.Usage.VideoDurationSeconds += u.VideoDurationSeconds
+		}
+	}
+	return usage, nil
+}
+
+// GarbageCollect deletes the caches that match the filter, such as the orphaned
+// caches of a display name prefix that are older than a threshold, and returns
+// their names. At least one field of the filter must be set, so that the caches
+// of other applications are not deleted. It continues after a failed deletion,
+// and returns the deletion errors joined.
+func (m Caches) GarbageCollect(ctx context.Context, filter *CacheFilter) ([]string, error) {
+	if filter == nil || (filter.DisplayNamePrefix == "" && filter.OlderThan <= 0 && filter.Match == nil) {
+		return nil, fmt.Errorf("GarbageCollect: the filter must set DisplayNamePrefix, OlderThan or Match")
+	}
+	now := time.Now()
+	var matching []string
+	for cache, err := range m.All(ctx) {
+		if err != nil {
+			return nil, fmt.Errorf("GarbageCollect: %w", err)
+		}
+		if filter.matches(cache, now) {
+			matching = append(matching, cache.Name)
+		}
+	}
+	var deleted []string
+	var errs []error
+	for _, name := range matching {
+		if _, err := m.Delete(ctx, name, nil); err != nil && !errors.Is(err, ErrNotFound) {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		deleted = append(deleted, name)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return deleted, fmt.Errorf("GarbageCollect: %w", err)
+	}
+	return deleted, nil
+}
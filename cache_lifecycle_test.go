@@ -0,0 +1,227 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// cacheLifecycleServer lists, updates and deletes caches.
+type cacheLifecycleServer struct {
+	mu      sync.Mutex
+	caches  map[string]*CachedContent
+	updates map[string]int
+	deleted []string
+}
+
+func (s *cacheLifecycleServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := strings.TrimPrefix(r.URL.Path, "/v1beta/")
+	if r.Method == http.MethodGet && name == "cachedContents" {
+		var resp ListCachedContentsResponse
+		for _, cache := range s.caches {
+			resp.CachedContents = append(resp.CachedContents, cache)
+		}
+		slices.SortFunc(resp.CachedContents, func(a, b *CachedContent) int { return strings.Compare(a.Name, b.Name) })
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+	cache, ok := s.caches[name]
+	if !ok {
+		http.Error(w, `{"error": {"code": 404, "message": "not found", "status": "NOT_FOUND"}}`, http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodPatch:
+		var req struct {
+			TTL string `json:"ttl"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		ttl, err := time.ParseDuration(req.TTL)
+		if err != nil {
+			http.Error(w, "invalid ttl "+req.TTL, http.StatusBadRequest)
+			return
+		}
+		s.updates[name]++
+		cache.ExpireTime = time.Now().Add(ttl).UTC()
+		json.NewEncoder(w).Encode(cache)
+	case http.MethodDelete:
+		delete(s.caches, name)
+		s.deleted = append(s.deleted, name)
+		w.Write([]byte(`{}`))
+	default:
+		http.Error(w, "unexpected request "+r.Method+" "+r.URL.Path, http.StatusBadRequest)
+	}
+}
+
+func newCacheLifecycleTest(t *testing.T, caches ...*CachedContent) (*Client, *cacheLifecycleServer) {
+	server := &cacheLifecycleServer{caches: make(map[string]*CachedContent), updates: make(map[string]int)}
+	for _, cache := range caches {
+		server.caches[cache.Name] = cache
+	}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	return newTestClient(t, ts, nil), server
+}
+
+func TestCacheKeeper(t *testing.T) {
+	client, server := newCacheLifecycleTest(t,
+		&CachedContent{Name: "cachedContents/kept"},
+		&CachedContent{Name: "cachedContents/released"},
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	var mu sync.Mutex
+	var failed []string
+	keeper := NewCacheKeeper(ctx, client, &CacheKeeperConfig{
+		TTL: 2 * time.Hour,
+		OnError: func(name string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			failed = append(failed, name)
+		},
+	})
+
+	// The caches expire within the refresh margin: they are refreshed right away.
+	expireTime := time.Now().Add(time.Minute)
+	for _, name := range []string{"cachedContents/kept", "cachedContents/released", "cachedContents/missing"} {
+		if err := keeper.Keep(&CachedContent{Name: name, ExpireTime: expireTime}); err != nil {
+			t.Fatalf("Keep(%s) failed: %v", name, err)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, missing := keeper.ExpireTime("cachedContents/missing")
+		got, _ := keeper.ExpireTime("cachedContents/kept")
+		if !missing && got.After(time.Now().Add(time.Hour)) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("caches not refreshed: kept expires at %v, missing kept: %v", got, missing)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	if !slices.Equal(failed, []string{"cachedContents/missing"}) {
+		t.Errorf("OnError() called for %q, want cachedContents/missing", failed)
+	}
+	mu.Unlock()
+
+	if err := keeper.Release(context.Background(), "cachedContents/released"); err != nil {
+		t.Fatalf("Release() failed: %v", err)
+	}
+
+	// The owning context is cancelled: the remaining cache is deleted.
+	cancel()
+	<-keeper.done
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if want := []string{"cachedContents/released", "cachedContents/kept"}; !slices.Equal(server.deleted, want) {
+		t.Errorf("deleted caches = %q, want %q", server.deleted, want)
+	}
+	if err := keeper.Keep(&CachedContent{Name: "cachedContents/late"}); err == nil {
+		t.Errorf("Keep() after the context is done succeeded, want error")
+	}
+}
+
+func TestCacheKeeperClose(t *testing.T) {
+	client, server := newCacheLifecycleTest(t, &CachedContent{Name: "cachedContents/c1"})
+	keeper := NewCacheKeeper(context.Background(), client, nil)
+	if err := keeper.Keep(&CachedContent{Name: "cachedContents/c1", ExpireTime: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Keep() failed: %v", err)
+	}
+	if err := keeper.Close(context.Background()); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if !slices.Equal(server.deleted, []string{"cachedContents/c1"}) || server.updates["cachedContents/c1"] != 0 {
+		t.Errorf("deleted caches = %q with %d updates, want cachedContents/c1 deleted without update", server.deleted, server.updates["cachedContents/c1"])
+	}
+}
+
+func TestCacheKeeperShortTTL(t *testing.T) {
+	client, server := newCacheLifecycleTest(t, &CachedContent{Name: "cachedContents/c1"})
+	// With the default 5m margin, the refreshed cache would be due right away.
+	keeper := NewCacheKeeper(context.Background(), client, &CacheKeeperConfig{TTL: time.Minute})
+	if keeper.config.RefreshMargin != 30*time.Second {
+		t.Errorf("RefreshMargin = %v, want 30s", keeper.config.RefreshMargin)
+	}
+	if err := keeper.Keep(&CachedContent{Name: "cachedContents/c1"}); err != nil {
+		t.Fatalf("Keep() failed: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err := keeper.Close(context.Background()); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if got := server.updates["cachedContents/c1"]; got != 1 {
+		t.Errorf("%d updates, want 1", got)
+	}
+}
+
+func TestCachesGarbageCollect(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	client, server := newCacheLifecycleTest(t,
+		&CachedContent{Name: "cachedContents/old", DisplayName: "genai-cache-manager", CreateTime: now.Add(-2 * time.Hour), UsageMetadata: &CachedContentUsageMetadata{TotalTokenCount: 100, TextCount: 400}},
+		&CachedContent{Name: "cachedContents/new", DisplayName: "genai-cache-manager", CreateTime: now, UsageMetadata: &CachedContentUsageMetadata{TotalTokenCount: 50, ImageCount: 1}},
+		&CachedContent{Name: "cachedContents/other", DisplayName: "other", CreateTime: now.Add(-2 * time.Hour), UsageMetadata: &CachedContentUsageMetadata{TotalTokenCount: 10}},
+	)
+	filter := &CacheFilter{DisplayNamePrefix: "genai-", OlderThan: time.Hour}
+
+	usage, err := client.Caches.Usage(ctx, &CacheFilter{DisplayNamePrefix: "genai-"})
+	if err != nil {
+		t.Fatalf("Usage() failed: %v", err)
+	}
+	want := CacheUsage{Caches: 2, Usage: CachedContentUsageMetadata{TotalTokenCount: 150, TextCount: 400, ImageCount: 1}}
+	if *usage != want {
+		t.Errorf("Usage() = %+v, want %+v", *usage, want)
+	}
+	if usage, err := client.Caches.Usage(ctx, nil); err != nil || usage.Caches != 3 || usage.Usage.TotalTokenCount != 160 {
+		t.Errorf("Usage(nil) = %+v, %v, want 3 caches and 160 tokens", usage, err)
+	}
+
+	deleted, err := client.Caches.GarbageCollect(ctx, filter)
+	if err != nil {
+		t.Fatalf("GarbageCollect() failed: %v", err)
+	}
+	if !slices.Equal(deleted, []string{"cachedContents/old"}) || !slices.Equal(server.deleted, deleted) {
+		t.Errorf("GarbageCollect() = %q, deleted %q, want cachedContents/old", deleted, server.deleted)
+	}
+
+	matched := &CacheFilter{Match: func(c *CachedContent) bool { return c.Name == "cachedContents/other" }}
+	if deleted, err := client.Caches.GarbageCollect(ctx, matched); err != nil || !slices.Equal(deleted, []string{"cachedContents/other"}) {
+		t.Errorf("GarbageCollect() with Match = %q, %v, want cachedContents/other", deleted, err)
+	}
+	for _, filter := range []*CacheFilter{nil, {}} {
+		if _, err := client.Caches.GarbageCollect(ctx, filter); err == nil {
+			t.Errorf("GarbageCollect(%+v) succeeded, want error", filter)
+		}
+	}
+	if _, err := client.Caches.Get(ctx, "cachedContents/old", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of a collected cache error = %v, want ErrNotFound", err)
+	}
+}
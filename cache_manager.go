@@ -0,0 +1,432 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCacheMinTokens   = 4096
+	defaultCacheMinRequests = 2
+	defaultCacheTTL         = time.Hour
+	// cacheExpirationMargin is the minimum remaining lifetime of a cache for a
+	// request to use it.
+	cacheExpirationMargin = time.Minute
+	// maxSeenPrefixes bounds the number of prefixes whose requests are counted.
+	maxSeenPrefixes = 10000
+)
+
+// CacheManagerConfig configures a [CacheManager]. Zero valued fields use the
+// documented defaults.
+type CacheManagerConfig struct {
+	// Minimum number of tokens of a cached prefix. A prefix is also cached only if
+	// it has at least this number of tokens more than the cache that the request
+	// would use otherwise. Defaults to 4096.
+	MinTokens int32
+	// Number of requests that must start with a prefix before it is cached.
+	// Defaults to 2.
+	MinRequests int
+	// TTL of the created caches. Defaults to 1h.
+	TTL time.Duration
+}
+
+// CacheManager caches the stable prefixes of generate content requests with
+// [Caches.Create], and rewrites the requests that start with a cached prefix to
+// reference the cache. A prefix is made of the system instruction, the tools
+// and the tool config of the request, and of its leading contents, all but the
+// last one. It is stable once MinRequests requests for the same model started
+// with it, and it is cached if it has at least MinTokens tokens, as counted by
+// Models.CountTokens. The tools are not counted.
+//
+// If a request that references a cache fails because the cache expired or was
+// deleted, the cache is forgotten and the request is sent again without it.
+// Requests that set GenerateContentConfig.CachedContent, or that use automatic
+// function calling, are sent as is.
+//
+// A CacheManager is safe for concurrent use. Call Close to delete the caches
+// that it created.
+type CacheManager struct {
+	models *Models
+	caches *Caches
+	config CacheManagerConfig
+
+	// createMu serializes the creation of caches.
+	createMu sync.Mutex
+	// mu guards the fields below.
+	mu sync.Mutex
+	// seen is the number of requests that started with each prefix.
+	seen map[string]int
+	// tokens is the number of tokens of each content, by hash.
+	tokens map[string]int32
+	// entries are the caches of prefixes, by prefix hash. A nil entry records a
+	// prefix whose cache could not be created.
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	name       string
+	expireTime time.Time
+	// length is the number of contents of the prefix.
+	length int
+	tokens int32
+}
+
+// NewCacheManager creates a CacheManager that uses the Models and Caches of the
+// client.
+func NewCacheManager(client *Client, config *CacheManagerConfig) *CacheManager {
+	m := &CacheManager{
+		models:  client.Models,
+		caches:  client.Caches,
+		seen:    make(map[string]int),
+		tokens:  make(map[string]int32),
+		entries: make(map[string]*cacheEntry),
+	}
+	if config != nil {
+		m.config = *config
+	}
+	if m.config.MinTokens <= 0 {
+		m.config.MinTokens = defaultCacheMinTokens
+	}
+	if m.config.MinRequests <= 0 {
+		m.config.MinRequests = defaultCacheMinRequests
+	}
+	if m.config.TTL <= 0 {
+		m.config.TTL = defaultCacheTTL
+	}
+	return m
+}
+
+// GenerateContent calls Models.GenerateContent with the request rewritten to
+// use the cache of its longest cached prefix, if any.
+func (m *CacheManager) GenerateContent(ctx context.Context, model string, contents []*Content, config *GenerateContentConfig) (*GenerateContentResponse, error) {
+	cachedContents, cachedConfig, entry, err := m.prepare(ctx, model, contents, config)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.models.GenerateContent(ctx, model, cachedContents, cachedConfig)
+	if entry != nil && m.cacheFailed(entry, err) {
+		return m.models.GenerateContent(ctx, model, contents, config)
+	}
+	return resp, err
+}
+
+// GenerateContentStream calls Models.GenerateContentStream with the request
+// rewritten to use the cache of its longest cached prefix, if any. The request
+// is sent again without the cache only if the stream fails before its first
+// response.
+func (m *CacheManager) GenerateContentStream(ctx context.Context, model string, contents []*Content, config *GenerateContentConfig) iter.Seq2[*GenerateContentResponse, error] {
+	return func(yield func(*GenerateContentResponse, error) bool) {
+		cachedContents, cachedConfig, entry, err := m.prepare(ctx, model, contents, config)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		received := false
+		for resp, err := range m.models.GenerateContentStream(ctx, model, cachedContents, cachedConfig) {
+			if err != nil && !received && entry != nil && m.cacheFailed(entry, err) {
+				break
+			}
+			received = true
+			if !yield(resp, err) || err != nil {
+				return
+			}
+		}
+		if received {
+			return
+		}
+		for resp, err := range m.models.GenerateContentStream(ctx, model, contents, config) {
+			if !yield(resp, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// NewChat creates a chat session, as Chats.Create does, whose messages are sent
+// with the CacheManager. The cached prefix of a chat grows with its history.
+func (m *CacheManager) NewChat(ctx context.Context, model string, config *GenerateContentConfig, history []*Content, policies ...HistoryPolicy) (*Chat, error) {
+	chat, err := (&Chats{apiClient: m.models.apiClient}).Create(ctx, model, config, history, policies...)
+	if err != nil {
+		return nil, err
+	}
+	chat.cacheManager = m
+	return chat, nil
+}
+
+// Close deletes the caches created by the CacheManager. The CacheManager can
+// still be used afterwards, and creates new caches as needed.
+func (m *CacheManager) Close(ctx context.Context) error {
+	m.mu.Lock()
+	entries := m.entries
+	m.entries = make(map[string]*cacheEntry)
+	m.mu.Unlock()
+
+	var errs []error
+	for _, entry := range entries {
+		if entry == nil {
+			continue
+		}
+		if _, err := m.caches.Delete(ctx, entry.name, nil); err != nil && !errors.Is(err, ErrNotFound) {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("CacheManager.Close: %w", err)
+	}
+	return nil
+}
+
+// prepare returns the contents and the config of the request that uses the
+// cache of the longest cached prefix of the request, creating it if needed, and
+// the cache entry. If the request doesn't use a cache, the entry is nil.
+func (m *CacheManager) prepare(ctx context.Context, model string, contents []*Content, config *GenerateContentConfig) ([]*Content, *GenerateContentConfig, *cacheEntry, error) {
+	if len(contents) == 0 || (config != nil && config.CachedContent != "") || config.automaticFunctionCalling() != nil {
+		return contents, config, nil, nil
+	}
+	keys, err := prefixKeys(model, contents, config)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("CacheManager: %w", err)
+	}
+
+	best, stable := m.lookup(keys)
+	if stable >= 0 && (best == nil || stable > best.length) {
+		entry, err := m.create(ctx, model, contents[:stable], config, keys[stable], best)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if entry != nil {
+			best = entry
+		}
+	}
+	if best == nil {
+		return contents, config, nil, nil
+	}
+
+	var cachedConfig GenerateContentConfig
+	if config != nil {
+		cachedConfig = *config
+	}
+	// The system instruction, tools and tool config are part of the cache.
+	cachedConfig.SystemInstruction = nil
+	cachedConfig.Tools = nil
+	cachedConfig.ToolConfig = nil
+	cachedConfig.CachedContent = best.name
+	return contents[best.length:], &cachedConfig, best, nil
+}
+
+// lookup counts the request with the given prefix keys, and returns the cache
+// of its longest cached prefix, and the length of its longest stable prefix, or
+// -1 if no prefix is stable.
+func (m *CacheManager) lookup(keys []string) (*cacheEntry, int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.seen) > maxSeenPrefixes {
+		clear(m.seen)
+	}
+	var best *cacheEntry
+	stable := -1
+	for length, key := range keys {
+		if key == "" {
+			continue
+		}
+		m.seen[key]++
+		if m.seen[key] >= m.config.MinRequests {
+			stable = length
+		}
+		entry, ok := m.entries[key]
+		if !ok {
+			continue
+		}
+		if entry == nil {
+			// The prefix could not be cached; don't try again.
+			stable = -1
+			continue
+		}
+		if time.Until(entry.expireTime) < cacheExpirationMargin {
+			delete(m.entries, key)
+			continue
+		}
+		best = entry
+	}
+	if best != nil && stable <= best.length {
+		stable = -1
+	}
+	return best, stable
+}
+
+// create caches the prefix if it has enough tokens, and returns its entry, or
+// nil if it is not cached.
+func (m *CacheManager) create(ctx context.Context, model string, prefix []*Content, config *GenerateContentConfig, key string, current *cacheEntry) (*cacheEntry, error) {
+	m.createMu.Lock()
+	defer m.createMu.Unlock()
+	m.mu.Lock()
+	entry, ok := m.entries[key]
+	m.mu.Unlock()
+	if ok {
+		// Another request created the cache in the meantime.
+		return entry, nil
+	}
+
+	var system *Content
+	if config != nil && config.SystemInstruction != nil {
+		system = &Content{Role: RoleUser, Parts: config.SystemInstruction.Parts}
+	}
+	var tokens int32
+	for _, content := range append([]*Content{system}, prefix...) {
+		if content == nil {
+			continue
+		}
+		count, err := m.countTokens(ctx, model, content)
+		if err != nil {
+			return nil, err
+		}
+		tokens += count
+	}
+	if tokens < m.config.MinTokens || (current != nil && tokens-current.tokens < m.config.MinTokens) {
+		return nil, nil
+	}
+
+	cacheConfig := &CreateCachedContentConfig{
+		TTL:         m.config.TTL,
+		DisplayName: "genai-cache-manager",
+		Contents:    prefix,
+	}
+	if config != nil {
+		cacheConfig.SystemInstruction = config.SystemInstruction
+		cacheConfig.Tools = config.Tools
+		cacheConfig.ToolConfig = config.ToolConfig
+	}
+	cache, err := m.caches.Create(ctx, model, cacheConfig)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		// Send the request without the cache. If the cache is invalid, such as when
+		// the prefix is too short for the model, don't try to cache the prefix
+		// again; other errors, such as rate limits, may be transient.
+		if errors.Is(err, ErrInvalidArgument) {
+			m.entries[key] = nil
+		}
+		return nil, nil
+	}
+	entry = &cacheEntry{name: cache.Name, expireTime: cache.ExpireTime, length: len(prefix), tokens: tokens}
+	if entry.expireTime.IsZero() {
+		entry.expireTime = time.Now().Add(m.config.TTL)
+	}
+	m.entries[key] = entry
+	return entry, nil
+}
+
+// countTokens returns the number of tokens of the content, counted once by
+// content hash.
+func (m *CacheManager) countTokens(ctx context.Context, model string, content *Content) (int32, error) {
+	key, err := hashJSON(model, content)
+	if err != nil {
+		return 0, fmt.Errorf("CacheManager: %w", err)
+	}
+	m.mu.Lock()
+	count, ok := m.tokens[key]
+	m.mu.Unlock()
+	if ok {
+		return count, nil
+	}
+	resp, err := m.models.CountTokens(ctx, model, []*Content{content}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("CacheManager: %w", err)
+	}
+	m.mu.Lock()
+	m.tokens[key] = resp.TotalTokens
+	m.mu.Unlock()
+	return resp.TotalTokens, nil
+}
+
+// cacheFailed reports whether err is caused by the cache of entry, because it
+// expired or was deleted, and forgets the cache if so. The error must refer to
+// the name of the cache, in its message or details.
+func (m *CacheManager) cacheFailed(entry *cacheEntry, err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	// The Gemini Developer API answers PERMISSION_DENIED for unknown caches.
+	if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrPermissionDenied) && !strings.Contains(strings.ToLower(apiErr.Message), "cache") {
+		return false
+	}
+	details, _ := json.Marshal(apiErr.Details)
+	if !strings.Contains(apiErr.Message, entry.name) && !strings.Contains(string(details), entry.name) {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, e := range m.entries {
+		if e == entry {
+			delete(m.entries, key)
+		}
+	}
+	return true
+}
+
+// prefixKeys returns the hashes of the prefixes of the request: keys[i] is the
+// hash of the model, the system instruction, the tools, the tool config and the
+// first i contents. The last content is not part of any prefix. If the request
+// has no system instruction and tools, keys[0] is empty.
+func prefixKeys(model string, contents []*Content, config *GenerateContentConfig) ([]string, error) {
+	var system *Content
+	var tools []*Tool
+	var toolConfig *ToolConfig
+	if config != nil {
+		system, tools, toolConfig = config.SystemInstruction, config.Tools, config.ToolConfig
+	}
+	key, err := hashJSON(model, system, tools, toolConfig)
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{key}
+	for _, content := range contents[:len(contents)-1] {
+		if key, err = hashJSON(key, content); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if system == nil && len(tools) == 0 {
+		// The prefix without contents is empty.
+		keys[0] = ""
+	}
+	return keys, nil
+}
+
+// hashJSON returns the hex encoded SHA-256 hash of the JSON encoding of values.
+func hashJSON(values ...any) (string, error) {
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	for _, v := range values {
+		if err := enc.Encode(v); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
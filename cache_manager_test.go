@@ -0,0 +1,212 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// cacheServer counts one token per character of text, creates and deletes
+// caches, and answers generate content requests with "ok", recording them.
+type cacheServer struct {
+	mu       sync.Mutex
+	created  []cacheRequest
+	requests []cacheRequest
+	deleted  map[string]bool
+	// createErrors are the errors of the next cache creations.
+	createErrors []string
+}
+
+// cacheRequest records a request that creates a cache or generates content.
+type cacheRequest struct {
+	CachedContent     string     `json:"cachedContent"`
+	Contents          []*Content `json:"contents"`
+	SystemInstruction *Content   `json:"systemInstruction"`
+}
+
+func (s *cacheServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var req cacheRequest
+	json.NewDecoder(r.Body).Decode(&req)
+	switch {
+	case strings.HasSuffix(r.URL.Path, ":countTokens"):
+		tokens := 0
+		for _, content := range req.Contents {
+			for _, part := range content.Parts {
+				tokens += len(part.Text)
+			}
+		}
+		fmt.Fprintf(w, `{"totalTokens": %d}`, tokens)
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/cachedContents"):
+		if len(s.createErrors) > 0 {
+			http.Error(w, s.createErrors[0], http.StatusServiceUnavailable)
+			s.createErrors = s.createErrors[1:]
+			return
+		}
+		s.created = append(s.created, req)
+		expireTime := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		fmt.Fprintf(w, `{"name": "cachedContents/c%d", "expireTime": %q}`, len(s.created), expireTime)
+	case r.Method == http.MethodDelete:
+		s.deleted[strings.TrimPrefix(r.URL.Path, "/v1beta/")] = true
+		fmt.Fprint(w, `{}`)
+	case strings.HasSuffix(r.URL.Path, ":generateContent"):
+		s.requests = append(s.requests, req)
+		if s.deleted[req.CachedContent] {
+			message := fmt.Sprintf("CachedContent %s not found (or permission denied)", req.CachedContent)
+			http.Error(w, fmt.Sprintf(`{"error": {"code": 403, "message": %q, "status": "PERMISSION_DENIED"}}`, message), http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `{"candidates": [{"content": {"role": "model", "parts": [{"text": "ok"}]}}]}`)
+	default:
+		http.Error(w, "unexpected request "+r.URL.Path, http.StatusBadRequest)
+	}
+}
+
+// last returns the last generate content request.
+func (s *cacheServer) last() cacheRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[len(s.requests)-1]
+}
+
+func newCacheManagerTest(t *testing.T) (*CacheManager, *cacheServer) {
+	server := &cacheServer{deleted: make(map[string]bool)}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	client := newTestClient(t, ts, nil)
+	return NewCacheManager(client, &CacheManagerConfig{MinTokens: 10}), server
+}
+
+func TestCacheManagerGenerateContent(t *testing.T) {
+	ctx := context.Background()
+	manager, server := newCacheManagerTest(t)
+	config := &GenerateContentConfig{SystemInstruction: NewContentFromText("Answer about the document.", RoleUser)}
+	document := NewContentFromText(strings.Repeat("document ", 10), RoleUser)
+	ask := func(question string) cacheRequest {
+		t.Helper()
+		contents := []*Content{document, NewContentFromText(question, RoleUser)}
+		resp, err := manager.GenerateContent(ctx, "gemini-2.0-flash", contents, config)
+		if err != nil {
+			t.Fatalf("GenerateContent() failed: %v", err)
+		}
+		if resp.Text() != "ok" {
+			t.Errorf("GenerateContent() = %q, want ok", resp.Text())
+		}
+		return server.last()
+	}
+
+	if req := ask("first"); req.CachedContent != "" || len(req.Contents) != 2 {
+		t.Errorf("first request = %s with %d contents, want no cache and 2 contents", req.CachedContent, len(req.Contents))
+	}
+	// The prefix is stable from the second request on.
+	for _, question := range []string{"second", "third"} {
+		req := ask(question)
+		if req.CachedContent != "cachedContents/c1" || len(req.Contents) != 1 || req.SystemInstruction != nil {
+			t.Errorf("%s request = %q with %d contents, want cachedContents/c1 with the question only", question, req.CachedContent, len(req.Contents))
+		}
+	}
+	if len(server.created) != 1 || len(server.created[0].Contents) != 1 || server.created[0].SystemInstruction == nil {
+		t.Errorf("created caches = %+v, want one with the system instruction and the document", server.created)
+	}
+
+	// The cache was deleted: the request is sent again without it.
+	server.deleted["cachedContents/c1"] = true
+	if req := ask("fourth"); req.CachedContent != "" || len(req.Contents) != 2 {
+		t.Errorf("request after deletion = %q with %d contents, want no cache and 2 contents", req.CachedContent, len(req.Contents))
+	}
+	if req := ask("fifth"); req.CachedContent != "cachedContents/c2" {
+		t.Errorf("next request = %q, want a new cache cachedContents/c2", req.CachedContent)
+	}
+
+	if err := manager.Close(ctx); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	if !server.deleted["cachedContents/c2"] {
+		t.Errorf("Close() didn't delete cachedContents/c2")
+	}
+}
+
+func TestCacheManagerChat(t *testing.T) {
+	ctx := context.Background()
+	manager, server := newCacheManagerTest(t)
+	chat, err := manager.NewChat(ctx, "gemini-2.0-flash", &GenerateContentConfig{SystemInstruction: NewContentFromText("Be brief.", RoleUser)}, nil)
+	if err != nil {
+		t.Fatalf("NewChat() failed: %v", err)
+	}
+	for _, message := range []string{"a long first question", "a long second question", "third"} {
+		if _, err := chat.SendMessage(ctx, Part{Text: message}); err != nil {
+			t.Fatalf("SendMessage() failed: %v", err)
+		}
+	}
+	// The third request starts with the first turn, which was sent twice, and
+	// which has enough tokens.
+	req := server.last()
+	if req.CachedContent == "" || len(req.Contents) != 3 {
+		t.Errorf("third request = %q with %d contents, want a cache of the first turn and 3 contents", req.CachedContent, len(req.Contents))
+	}
+	if len(chat.History(false)) != 6 {
+		t.Errorf("History() has %d contents, want the 6 contents of the 3 turns", len(chat.History(false)))
+	}
+}
+
+func TestCacheManagerCreateErrors(t *testing.T) {
+	ctx := context.Background()
+	manager, server := newCacheManagerTest(t)
+	server.createErrors = []string{`{"error": {"code": 503, "message": "overloaded", "status": "UNAVAILABLE"}}`}
+	config := &GenerateContentConfig{SystemInstruction: NewContentFromText(strings.Repeat("instruction ", 10), RoleUser)}
+	var requests []cacheRequest
+	for _, question := range []string{"first", "second", "third"} {
+		if _, err := manager.GenerateContent(ctx, "gemini-2.0-flash", []*Content{NewContentFromText(question, RoleUser)}, config); err != nil {
+			t.Fatalf("GenerateContent() failed: %v", err)
+		}
+		requests = append(requests, server.last())
+	}
+	// The failed creation is transient: the cache is created for the next request.
+	if requests[1].CachedContent != "" || requests[2].CachedContent != "cachedContents/c1" {
+		t.Errorf("requests use caches %q and %q, want no cache then cachedContents/c1", requests[1].CachedContent, requests[2].CachedContent)
+	}
+}
+
+func TestCacheManagerCacheFailed(t *testing.T) {
+	manager, _ := newCacheManagerTest(t)
+	entry := &cacheEntry{name: "cachedContents/c1"}
+	tests := []struct {
+		desc string
+		err  error
+		want bool
+	}{
+		{"named in message", APIError{Code: 403, Status: "PERMISSION_DENIED", Message: "CachedContent cachedContents/c1 not found"}, true},
+		{"named in details", APIError{Code: 404, Status: "NOT_FOUND", Details: []map[string]any{{"resourceName": "cachedContents/c1"}}}, true},
+		{"other resource", APIError{Code: 403, Status: "PERMISSION_DENIED", Message: "permission denied on files/f1"}, false},
+		{"not an API error", errors.New("cachedContents/c1"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := manager.cacheFailed(entry, tt.err); got != tt.want {
+				t.Errorf("cacheFailed(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// ChatHistory is the conversation that a [HistoryPolicy] manages before a chat
+// sends a message.
+type ChatHistory struct {
+	// Contents are the previous turns of the conversation, oldest first.
+	Contents []*Content
+	// Message is the user message about to be sent. Policies must not remove it.
+	Message *Content
+	// Summary summarizes the turns that were removed from Contents. It is sent to
+	// the model at the end of the system instruction.
+	Summary string
+}
+
+// HistoryPolicy limits the history of a chat. Before sending a message, a chat
+// applies its policies in order to its curated history, and sends the result.
+// Once the message is sent, the chat drops the turns that the policies removed
+// from its history. See [Chats.Create].
+type HistoryPolicy interface {
+	// Apply modifies history. models and model are the ones of the chat, for
+	// policies that need to call the model.
+	Apply(ctx context.Context, models *Models, model string, history *ChatHistory) error
+}
+
+// HistoryPolicyFunc adapts a function to a [HistoryPolicy].
+type HistoryPolicyFunc func(ctx context.Context, models *Models, model string, history *ChatHistory) error
+
+// Apply calls f.
+func (f HistoryPolicyFunc) Apply(ctx context.Context, models *Models, model string, history *ChatHistory) error {
+	return f(ctx, models, model, history)
+}
+
+// KeepLastTurns returns a policy that keeps the last n turns of a chat, a turn
+// being a user message and all the contents that answer it, including the
+// function calls and responses exchanged in automatic function calling mode.
+func KeepLastTurns(n int) HistoryPolicy {
+	return HistoryPolicyFunc(func(ctx context.Context, models *Models, model string, history *ChatHistory) error {
+		if n <= 0 {
+			history.Contents = nil
+			return nil
+		}
+		if starts := turnStarts(history.Contents); len(starts) > n {
+			history.Contents = history.Contents[starts[len(starts)-n]:]
+		}
+		return nil
+	})
+}
+
+// KeepWithinTokenBudget returns a policy that removes the oldest turns of a chat
+// until its contents and the new message fit in maxTokens, as counted by
+// Models.CountTokens. The tokens of each content are counted once and cached by
+// the policy, so a policy must not be shared by chats of different models. The
+// new message is always sent, even if it alone exceeds the budget.
+func KeepWithinTokenBudget(maxTokens int32) HistoryPolicy {
+	return &tokenBudgetPolicy{maxTokens: maxTokens, counts: make(map[*Content]int32)}
+}
+
+type tokenBudgetPolicy struct {
+	maxTokens int32
+
+	mu     sync.Mutex
+	counts map[*Content]int32
+}
+
+func (p *tokenBudgetPolicy) Apply(ctx context.Context, models *Models, model string, history *ChatHistory) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	counts := make(map[*Content]int32, len(history.Contents)+1)
+	var total int32
+	for _, content := range append(slices.Clip(history.Contents), history.Message) {
+		count, ok := p.counts[content]
+		if !ok {
+			resp, err := models.CountTokens(ctx, model, []*Content{content}, nil)
+			if err != nil {
+				return fmt.Errorf("KeepWithinTokenBudget: %w", err)
+			}
+			count = resp.TotalTokens
+		}
+		counts[content] = count
+		total += count
+	}
+	// Forget the contents that are no longer in the history.
+	p.counts = counts
+
+	starts := append(turnStarts(history.Contents), len(history.Contents))
+	first := 0
+	for i := 0; total > p.maxTokens && i < len(starts)-1; i++ {
+		for _, content := range history.Contents[starts[i]:starts[i+1]] {
+			total -= counts[content]
+		}
+		first = starts[i+1]
+	}
+	history.Contents = history.Contents[first:]
+	return nil
+}
+
+// SummarizeOlderTurns returns a policy that asks the model of the chat to
+// summarize the older turns of a chat once it has more than maxTurns turns. The
+// summary replaces all but the last keepTurns turns, and is sent to the model in
+// the system instruction. Earlier summaries are included in the new one.
+func SummarizeOlderTurns(maxTurns, keepTurns int) HistoryPolicy {
+	return HistoryPolicyFunc(func(ctx context.Context, models *Models, model string, history *ChatHistory) error {
+		starts := turnStarts(history.Contents)
+		if len(starts) <= maxTurns || len(starts) <= keepTurns {
+			return nil
+		}
+		split := len(history.Contents)
+		if keepTurns > 0 {
+			split = starts[len(starts)-keepTurns]
+		}
+		prompt := "Summarize the conversation so far in a few sentences. Keep the facts, decisions and open questions that later turns may need."
+		if history.Summary != "" {
+			prompt += "\n\nIt continues an earlier conversation, summarized as follows:\n" + history.Summary
+		}
+		contents := append(slices.Clone(history.Contents[:split]), NewContentFromText(prompt, RoleUser))
+		resp, err := models.GenerateContent(ctx, model, contents, nil)
+		if err != nil {
+			return fmt.Errorf("SummarizeOlderTurns: %w", err)
+		}
+		summary := resp.Text()
+		if summary == "" {
+			return fmt.Errorf("SummarizeOlderTurns: the model returned an empty summary")
+		}
+		history.Summary = summary
+		history.Contents = history.Contents[split:]
+		return nil
+	})
+}
+
+// turnStarts returns the index in contents of the user message that starts each
+// turn. The function responses that users send back in automatic function
+// calling mode don't start a turn.
+func turnStarts(contents []*Content) []int {
+	var starts []int
+	for i, content := range contents {
+		if i == 0 || isUserMessage(content) {
+			starts = append(starts, i)
+		}
+	}
+	return starts
+}
+
+func isUserMessage(content *Content) bool {
+	if content == nil || (content.Role != RoleUser && content.Role != "") {
+		return false
+	}
+	return !slices.ContainsFunc(content.Parts, func(part *Part) bool {
+		return part != nil && part.FunctionResponse != nil
+	})
+}
+
+// curatedHistory returns the turns of history that are valid to send back to the
+// model. Model turns that are empty or have empty parts, as recorded for blocked
+// or failed responses, are dropped together with the rest of their turn, back
+// to the user input that started it, so that no function call of automatic
+// function calling is left without its response.
+func curatedHistory(history []*Content) []*Content {
+	var curated []*Content
+	for i := 0; i < len(history); {
+		if history[i] == nil {
+			i++
+			continue
+		}
+		if history[i].Role != RoleModel {
+			curated = append(curated, history[i])
+			i++
+			continue
+		}
+		valid := true
+		start := i
+		for ; i < len(history) && history[i] != nil && history[i].Role == RoleModel; i++ {
+			valid = valid && validModelContent(history[i])
+		}
+		if valid {
+			curated = append(curated, history[start:i]...)
+		} else if starts := turnStarts(curated); len(starts) > 0 {
+			curated = curated[:starts[len(starts)-1]]
+		}
+	}
+	return curated
+}
+
+func validModelContent(content *Content) bool {
+	if len(content.Parts) == 0 {
+		return false
+	}
+	return !slices.ContainsFunc(content.Parts, isEmptyPart)
+}
@@ -0,0 +1,249 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// chatHistoryServer answers generateContent requests with the text "reply to
+// <last user text>", or with "summary" for summarization requests, and
+// countTokens requests with one token per character of text. Streaming requests
+// get the reply in two chunks. It records the generateContent requests.
+func chatHistoryServer(t *testing.T) (*httptest.Server, *[]map[string]any) {
+	t.Helper()
+	var requests []map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := make(map[string]any)
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		contents := body["contents"].([]any)
+		var texts []string
+		for _, content := range contents {
+			for _, part := range content.(map[string]any)["parts"].([]any) {
+				text, _ := part.(map[string]any)["text"].(string)
+				texts = append(texts, text)
+			}
+		}
+		if strings.HasSuffix(r.URL.Path, ":countTokens") {
+			fmt.Fprintf(w, `{"totalTokens": %d}`, len(strings.Join(texts, "")))
+			return
+		}
+		last := texts[len(texts)-1]
+		reply := "reply to " + last
+		switch {
+		case strings.HasPrefix(last, "Summarize"):
+			reply = "summary"
+		case last == "blocked":
+			fmt.Fprint(w, `{"promptFeedback": {"blockReason": "SAFETY"}}`)
+			requests = append(requests, body)
+			return
+		}
+		requests = append(requests, body)
+		if strings.HasSuffix(r.URL.Path, ":streamGenerateContent") {
+			prefix, rest, _ := strings.Cut(reply, " ")
+			fmt.Fprintf(w, "data:%s\n\ndata:%s\n\n", objectTextResponse(t, prefix+" "), objectTextResponse(t, rest))
+			return
+		}
+		fmt.Fprint(w, objectTextResponse(t, reply))
+	}))
+	return ts, &requests
+}
+
+// requestTexts returns the texts of the contents of a recorded request.
+func requestTexts(request map[string]any) []string {
+	var texts []string
+	for _, content := range request["contents"].([]any) {
+		part := content.(map[string]any)["parts"].([]any)[0].(map[string]any)
+		texts = append(texts, part["text"].(string))
+	}
+	return texts
+}
+
+func TestChatCuratedHistory(t *testing.T) {
+	ctx := context.Background()
+	ts, requests := chatHistoryServer(t)
+	defer ts.Close()
+	client := newTestClient(t, ts, nil)
+
+	chat, err := client.Chats.Create(ctx, "gemini-2.0-flash", nil, nil)
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	for _, message := range []string{"one", "blocked", "two"} {
+		if _, err := chat.SendMessage(ctx, Part{Text: message}); err != nil {
+			t.Fatalf("SendMessage(%q) failed: %v", message, err)
+		}
+	}
+
+	want := []string{"one", "reply to one", "two"}
+	if diff := cmp.Diff(want, requestTexts((*requests)[2])); diff != "" {
+		t.Errorf("request contents mismatch (-want +got):\n%s", diff)
+	}
+	if got := len(chat.History(false)); got != 6 {
+		t.Errorf("len(History(false)) = %d, want 6", got)
+	}
+	if blocked := chat.History(false)[3]; blocked.Role != RoleModel || len(blocked.Parts) != 0 {
+		t.Errorf("History(false)[3] = %+v, want an empty model turn", blocked)
+	}
+	curated := chat.History(true)
+	var got []string
+	for _, content := range curated {
+		got = append(got, content.Parts[0].Text)
+	}
+	if diff := cmp.Diff([]string{"one", "reply to one", "two", "reply to two"}, got); diff != "" {
+		t.Errorf("History(true) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestChatHistoryPolicies(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		desc              string
+		policies          []HistoryPolicy
+		wantContents      []string
+		wantSystemSummary bool
+	}{
+		{
+			desc:         "keep last turns",
+			policies:     []HistoryPolicy{KeepLastTurns(1)},
+			wantContents: []string{"two", "reply to two", "three"},
+		},
+		{
+			desc: "token budget",
+			// "three" and the last turn are 5 + 3 + 12 characters.
+			policies:     []HistoryPolicy{KeepWithinTokenBudget(20)},
+			wantContents: []string{"two", "reply to two", "three"},
+		},
+		{
+			desc:              "summarize",
+			policies:          []HistoryPolicy{SummarizeOlderTurns(1, 1)},
+			wantContents:      []string{"two", "reply to two", "three"},
+			wantSystemSummary: true,
+		},
+		{
+			desc:         "no policies",
+			wantContents: []string{"one", "reply to one", "two", "reply to two", "three"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ts, requests := chatHistoryServer(t)
+			defer ts.Close()
+			client := newTestClient(t, ts, nil)
+
+			chat, err := client.Chats.Create(ctx, "gemini-2.0-flash", nil, nil, tt.policies...)
+			if err != nil {
+				t.Fatalf("Create() failed: %v", err)
+			}
+			for _, message := range []string{"one", "two", "three"} {
+				if _, err := chat.SendMessage(ctx, Part{Text: message}); err != nil {
+					t.Fatalf("SendMessage(%q) failed: %v", message, err)
+				}
+			}
+
+			last := (*requests)[len(*requests)-1]
+			if diff := cmp.Diff(tt.wantContents, requestTexts(last)); diff != "" {
+				t.Errorf("request contents mismatch (-want +got):\n%s", diff)
+			}
+			systemInstruction, _ := last["systemInstruction"].(map[string]any)
+			if gotSummary := strings.Contains(fmt.Sprint(systemInstruction), "summary"); gotSummary != tt.wantSystemSummary {
+				t.Errorf("system instruction = %v, want summary: %v", systemInstruction, tt.wantSystemSummary)
+			}
+			if got, want := len(chat.History(false)), len(tt.wantContents)+1; got != want {
+				t.Errorf("len(History(false)) = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestChatHistoryPolicyKeepsComprehensiveHistory(t *testing.T) {
+	ctx := context.Background()
+	ts, _ := chatHistoryServer(t)
+	defer ts.Close()
+	client := newTestClient(t, ts, nil)
+
+	chat, err := client.Chats.Create(ctx, "gemini-2.0-flash", nil, nil, KeepLastTurns(1))
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	for _, message := range []string{"one", "blocked", "two"} {
+		if _, err := chat.SendMessage(ctx, Part{Text: message}); err != nil {
+			t.Fatalf("SendMessage(%q) failed: %v", message, err)
+		}
+	}
+	// The policy kept the only valid turn, and the blocked turn is still recorded.
+	if got := len(chat.History(false)); got != 6 {
+		t.Errorf("len(History(false)) = %d, want 6", got)
+	}
+
+	// A message that fails doesn't change the history.
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := chat.SendMessage(cancelled, Part{Text: "three"}); err == nil {
+		t.Fatalf("SendMessage() with a cancelled context succeeded, want error")
+	}
+	if got := len(chat.History(false)); got != 6 {
+		t.Errorf("len(History(false)) after a failed message = %d, want 6", got)
+	}
+
+	if _, err := chat.SendMessage(ctx, Part{Text: "three"}); err != nil {
+		t.Fatalf("SendMessage(%q) failed: %v", "three", err)
+	}
+	var got []string
+	for _, content := range chat.History(false) {
+		got = append(got, content.Parts[0].Text)
+	}
+	if diff := cmp.Diff([]string{"two", "reply to two", "three", "reply to three"}, got); diff != "" {
+		t.Errorf("History(false) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTurnStarts(t *testing.T) {
+	contents := []*Content{
+		NewContentFromText("weather?", RoleUser),
+		NewContentFromFunctionCall("get_weather", nil, RoleModel),
+		NewContentFromFunctionResponse("get_weather", nil, RoleUser),
+		NewContentFromText("sunny", RoleModel),
+		NewContentFromText("thanks", RoleUser),
+	}
+	if diff := cmp.Diff([]int{0, 4}, turnStarts(contents)); diff != "" {
+		t.Errorf("turnStarts() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCuratedHistoryBlockedFunctionCallingTurn(t *testing.T) {
+	history := []*Content{
+		NewContentFromText("hello", RoleUser),
+		NewContentFromText("hi", RoleModel),
+		NewContentFromText("weather?", RoleUser),
+		NewContentFromFunctionCall("get_weather", nil, RoleModel),
+		NewContentFromFunctionResponse("get_weather", nil, RoleUser),
+		{Role: RoleModel},
+	}
+	// The whole turn is dropped, with the function call left without answer.
+	if diff := cmp.Diff(history[:2], curatedHistory(history)); diff != "" {
+		t.Errorf("curatedHistory() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,318 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChatSnapshotVersion is the version of the snapshots written by this package.
+const ChatSnapshotVersion = 1
+
+// ChatSnapshot is the serializable state of a [Chat]. Its JSON encoding is stable
+// across versions of this package: snapshots carry a version, and snapshots of
+// newer, unknown versions are rejected when decoded.
+//
+// The AutomaticFunctionCalling and Strict fields of the configuration, and the
+// history policies of the chat, are not part of the snapshot; pass them again
+// when restoring the chat.
+type ChatSnapshot struct {
+	// Version is the version of the snapshot format.
+	Version int `json:"version"`
+	// ID identifies the chat in a ChatStore.
+	ID string `json:"id,omitempty"`
+	// Model is the model of the chat.
+	Model string `json:"model"`
+	// Config is the configuration of the chat. Its CachedContent field holds the
+	// name of the cached content that the chat uses, if any.
+	Config *GenerateContentConfig `json:"config,omitempty"`
+	// History is the comprehensive history of the chat.
+	History []*Content `json:"history,omitempty"`
+	// Summary summarizes the turns removed from History by history policies.
+	Summary string `json:"summary,omitempty"`
+	// UpdateTime is the time when the snapshot was taken.
+	UpdateTime time.Time `json:"updateTime"`
+}
+
+// UnmarshalJSON decodes a snapshot and checks its version.
+func (s *ChatSnapshot) UnmarshalJSON(data []byte) error {
+	type Alias ChatSnapshot
+	if err := json.Unmarshal(data, (*Alias)(s)); err != nil {
+		return err
+	}
+	switch {
+	case s.Version == 0:
+		return fmt.Errorf("chat snapshot has no version")
+	case s.Version > ChatSnapshotVersion:
+		return fmt.Errorf("chat snapshot version %d is not supported, want at most %d", s.Version, ChatSnapshotVersion)
+	}
+	return nil
+}
+
+// ChatStore persists chat snapshots by ID. Implementations must be safe for
+// concurrent use, and must return an error that matches [ErrNotFound] with
+// errors.Is for unknown IDs.
+type ChatStore interface {
+	// Save creates or replaces the snapshot of snapshot.ID.
+	Save(ctx context.Context, snapshot *ChatSnapshot) error
+	// Load returns the snapshot of id.
+	Load(ctx context.Context, id string) (*ChatSnapshot, error)
+	// List returns the IDs of the stored snapshots, in lexical order.
+	List(ctx context.Context) ([]string, error)
+	// Delete deletes the snapshot of id.
+	Delete(ctx context.Context, id string) error
+}
+
+// Snapshot returns the state of the chat.
+func (c *Chat) Snapshot() *ChatSnapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &ChatSnapshot{
+		Version:    ChatSnapshotVersion,
+		Model:      c.model,
+		Config:     c.config,
+		History:    slices.Clone(c.comprehensiveHistory),
+		Summary:    c.summary,
+		UpdateTime: time.Now().UTC(),
+	}
+}
+
+// Restore creates a chat session from a snapshot. The optional history policies
+// are the ones of [Chats.Create].
+func (c *Chats) Restore(snapshot *ChatSnapshot, policies ...HistoryPolicy) (*Chat, error) {
+	if snapshot == nil || snapshot.Model == "" {
+		return nil, fmt.Errorf("Restore: snapshot must have a model")
+	}
+	chat, err := c.Create(context.Background(), snapshot.Model, snapshot.Config, slices.Clone(snapshot.History), policies...)
+	if err != nil {
+		return nil, err
+	}
+	chat.summary = snapshot.Summary
+	return chat, nil
+}
+
+// Save saves the snapshot of chat to store under id.
+func (c *Chats) Save(ctx context.Context, store ChatStore, id string, chat *Chat) error {
+	if err := validateChatID(id); err != nil {
+		return fmt.Errorf("Save: %w", err)
+	}
+	snapshot := chat.Snapshot()
+	snapshot.ID = id
+	return store.Save(ctx, snapshot)
+}
+
+// Load restores the chat saved in store under id. The optional history policies
+// are the ones of [Chats.Create].
+func (c *Chats) Load(ctx context.Context, store ChatStore, id string, policies ...HistoryPolicy) (*Chat, error) {
+	snapshot, err := store.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return c.Restore(snapshot, policies...)
+}
+
+// List returns the IDs of the chats saved in store.
+func (c *Chats) List(ctx context.Context, store ChatStore) ([]string, error) {
+	return store.List(ctx)
+}
+
+// Delete deletes the chat saved in store under id.
+func (c *Chats) Delete(ctx context.Context, store ChatStore, id string) error {
+	return store.Delete(ctx, id)
+}
+
+var chatIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-][a-zA-Z0-9._-]*$`)
+
+// validateChatID checks that id can be used as a file name.
+func validateChatID(id string) error {
+	if !chatIDPattern.MatchString(id) {
+		return fmt.Errorf("invalid chat ID %q: it must only contain letters, digits, dots, underscores and dashes, and must not start with a dot", id)
+	}
+	return nil
+}
+
+func chatNotFound(id string) error {
+	return fmt.Errorf("chat %q: %w", id, ErrNotFound)
+}
+
+// MemoryChatStore is a [ChatStore] that keeps snapshots in memory, encoded in
+// JSON so that later changes to a chat don't affect its saved snapshots.
+type MemoryChatStore struct {
+	mu        sync.Mutex
+	snapshots map[string][]byte
+}
+
+// NewMemoryChatStore creates an empty MemoryChatStore.
+func NewMemoryChatStore() *MemoryChatStore {
+	return &MemoryChatStore{snapshots: make(map[string][]byte)}
+}
+
+// Save implements [ChatStore].
+func (s *MemoryChatStore) Save(ctx context.Context, snapshot *ChatSnapshot) error {
+	if err := validateChatID(snapshot.ID); err != nil {
+		return err
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode chat snapshot: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots[snapshot.ID] = data
+	return nil
+}
+
+// Load implements [ChatStore].
+func (s *MemoryChatStore) Load(ctx context.Context, id string) (*ChatSnapshot, error) {
+	s.mu.Lock()
+	data, ok := s.snapshots[id]
+	s.mu.Unlock()
+	if !ok {
+		return nil, chatNotFound(id)
+	}
+	snapshot := new(ChatSnapshot)
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode chat %q: %w", id, err)
+	}
+	return snapshot, nil
+}
+
+// List implements [ChatStore].
+func (s *MemoryChatStore) List(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Sorted(maps.Keys(s.snapshots)), nil
+}
+
+// Delete implements [ChatStore].
+func (s *MemoryChatStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.snapshots[id]; !ok {
+		return chatNotFound(id)
+	}
+	delete(s.snapshots, id)
+	return nil
+}
+
+// FileChatStore is a [ChatStore] that keeps each snapshot in a JSON file named
+// after its ID in a directory.
+type FileChatStore struct {
+	dir string
+}
+
+const chatFileExt = ".json"
+
+// NewFileChatStore creates a FileChatStore in dir, creating the directory if
+// needed.
+func NewFileChatStore(dir string) (*FileChatStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("NewFileChatStore: %w", err)
+	}
+	return &FileChatStore{dir: dir}, nil
+}
+
+func (s *FileChatStore) path(id string) (string, error) {
+	if err := validateChatID(id); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, id+chatFileExt), nil
+}
+
+// Save implements [ChatStore]. The file is replaced atomically.
+func (s *FileChatStore) Save(ctx context.Context, snapshot *ChatSnapshot) error {
+	path, err := s.path(snapshot.ID)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode chat snapshot: %w", err)
+	}
+	f, err := os.CreateTemp(s.dir, "."+snapshot.ID+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Load implements [ChatStore].
+func (s *FileChatStore) Load(ctx context.Context, id string) (*ChatSnapshot, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, chatNotFound(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapshot := new(ChatSnapshot)
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode chat %q: %w", id, err)
+	}
+	return snapshot, nil
+}
+
+// List implements [ChatStore].
+func (s *FileChatStore) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), chatFileExt)
+		if ok && entry.Type().IsRegular() && validateChatID(id) == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// Delete implements [ChatStore].
+func (s *FileChatStore) Delete(ctx context.Context, id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return chatNotFound(id)
+	}
+	return err
+}
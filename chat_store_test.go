@@ -0,0 +1,116 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestChatStores(t *testing.T) {
+	ctx := context.Background()
+	fileStore, err := NewFileChatStore(filepath.Join(t.TempDir(), "chats"))
+	if err != nil {
+		t.Fatalf("NewFileChatStore() failed: %v", err)
+	}
+	stores := map[string]ChatStore{
+		"memory": NewMemoryChatStore(),
+		"file":   fileStore,
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ts, requests := chatHistoryServer(t)
+			defer ts.Close()
+			client := newTestClient(t, ts, nil)
+
+			config := &GenerateContentConfig{CachedContent: "cachedContents/123", Temperature: Ptr[float32](0.5)}
+			chat, err := client.Chats.Create(ctx, "gemini-2.0-flash", config, nil)
+			if err != nil {
+				t.Fatalf("Create() failed: %v", err)
+			}
+			if _, err := chat.SendMessage(ctx, Part{Text: "one"}); err != nil {
+				t.Fatalf("SendMessage() failed: %v", err)
+			}
+			if err := client.Chats.Save(ctx, store, "session-1", chat); err != nil {
+				t.Fatalf("Save() failed: %v", err)
+			}
+			if err := client.Chats.Save(ctx, store, "../escape", chat); err == nil {
+				t.Errorf("Save(%q) succeeded, want an error", "../escape")
+			}
+
+			ids, err := client.Chats.List(ctx, store)
+			if err != nil {
+				t.Fatalf("List() failed: %v", err)
+			}
+			if diff := cmp.Diff([]string{"session-1"}, ids); diff != "" {
+				t.Errorf("List() mismatch (-want +got):\n%s", diff)
+			}
+
+			restored, err := client.Chats.Load(ctx, store, "session-1")
+			if err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+			if diff := cmp.Diff(chat.History(false), restored.History(false)); diff != "" {
+				t.Errorf("restored history mismatch (-want +got):\n%s", diff)
+			}
+			if _, err := restored.SendMessage(ctx, Part{Text: "two"}); err != nil {
+				t.Fatalf("SendMessage() failed: %v", err)
+			}
+			last := (*requests)[len(*requests)-1]
+			if diff := cmp.Diff([]string{"one", "reply to one", "two"}, requestTexts(last)); diff != "" {
+				t.Errorf("request contents mismatch (-want +got):\n%s", diff)
+			}
+			if got := last["cachedContent"]; got != "cachedContents/123" {
+				t.Errorf("request cachedContent = %v, want %q", got, "cachedContents/123")
+			}
+
+			if err := client.Chats.Delete(ctx, store, "session-1"); err != nil {
+				t.Fatalf("Delete() failed: %v", err)
+			}
+			if _, err := client.Chats.Load(ctx, store, "session-1"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Load() after Delete() error = %v, want ErrNotFound", err)
+			}
+			if err := client.Chats.Delete(ctx, store, "session-1"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Delete() after Delete() error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestChatSnapshotVersion(t *testing.T) {
+	tests := []struct {
+		desc    string
+		data    string
+		wantErr bool
+	}{
+		{desc: "current", data: `{"version": 1, "model": "gemini-2.0-flash", "summary": "s"}`},
+		{desc: "missing", data: `{"model": "gemini-2.0-flash"}`, wantErr: true},
+		{desc: "newer", data: `{"version": 2, "model": "gemini-2.0-flash"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var snapshot ChatSnapshot
+			err := json.Unmarshal([]byte(tt.data), &snapshot)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Errorf("json.Unmarshal() error = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
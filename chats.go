@@ -18,9 +18,11 @@ package genai
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"iter"
-	"log"
+	"slices"
+	"sync"
 )
 
 // Chats provides util functions for creating a new chat session.
@@ -35,35 +37,129 @@ type Chats struct {
 //		client, _ := genai.NewClient(ctx, &genai.ClientConfig{})
 //		chat, _ := client.Chats.Create(ctx, "gemini-2.0-flash", nil, nil)
 //	  result, err = chat.SendMessage(ctx, genai.Part{Text: "What is 1 + 2?"})
+//
+// A Chat is safe for concurrent use. Messages are sent one at a time: a message
+// is sent with the history that includes the turns of the messages sent before
+// it. A message sent with SendMessageStream is being sent until its iterator
+// returns, so the loop over the iterator must not send messages, nor call
+// Rewind or EditTurn, on the same chat.
 type Chat struct {
 	Models
 	apiClient *apiClient
 	model     string
 	config    *GenerateContentConfig
+	// cacheManager sends the messages of the chat, if set.
+	cacheManager *CacheManager
+
+	// sendMu serializes the messages and the changes of the turns of the chat.
+	sendMu sync.Mutex
+	// mu guards the fields below.
+	mu sync.Mutex
 	// History of the chat.
 	comprehensiveHistory []*Content
+	// Policies applied to the history before sending a message.
+	historyPolicies []HistoryPolicy
+	// Summary of the turns removed from the history by the policies.
+	summary string
 }
 
-// Create initializes a new chat session.
-func (c *Chats) Create(ctx context.Context, model string, config *GenerateContentConfig, history []*Content) (*Chat, error) {
+// Create initializes a new chat session. The optional history policies limit the
+// history that the chat keeps and sends to the model, in order; see
+// [KeepLastTurns], [KeepWithinTokenBudget] and [SummarizeOlderTurns].
+func (c *Chats) Create(ctx context.Context, model string, config *GenerateContentConfig, history []*Content, policies ...HistoryPolicy) (*Chat, error) {
 	chat := &Chat{
 		apiClient:            c.apiClient,
 		model:                model,
 		config:               config,
 		comprehensiveHistory: history,
+		historyPolicies:      policies,
 	}
 	chat.Models.apiClient = c.apiClient
 	return chat, nil
 }
 
-func (c *Chat) recordHistory(ctx context.Context, inputContent *Content, outputContents []*Content) {
+// recordHistory records a turn of the chat. kept is the history that the history
+// policies kept when the message was sent, if the chat has policies.
+func (c *Chat) recordHistory(ctx context.Context, kept *ChatHistory, inputContent *Content, functionCallingHistory []*Content, outputContents []*Content) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if kept != nil {
+		c.keepHistory(kept)
+	}
 	c.comprehensiveHistory = append(c.comprehensiveHistory, inputContent)
+	// Turns exchanged in automatic function calling mode keep their roles.
+	c.comprehensiveHistory = append(c.comprehensiveHistory, functionCallingHistory...)
 
+	if len(outputContents) == 0 {
+		// Record the turn that failed, such as a blocked prompt, with an empty model
+		// content, which the curated history drops together with the input.
+		c.comprehensiveHistory = append(c.comprehensiveHistory, &Content{Role: RoleModel})
+	}
 	for _, outputContent := range outputContents {
 		c.comprehensiveHistory = append(c.comprehensiveHistory, copySanitizedModelContent(outputContent))
 	}
 }
 
+// keepHistory limits the history of the chat to the history that its policies
+// kept when a message was sent. The turns that the policies removed from the
+// curated history are dropped from the comprehensive history, which keeps the
+// invalid turns that follow them. The caller must hold c.mu and c.sendMu.
+func (c *Chat) keepHistory(kept *ChatHistory) {
+	c.summary = kept.Summary
+	curated := curatedHistory(c.comprehensiveHistory)
+	removed := len(curated) - len(kept.Contents)
+	switch {
+	case removed >= 0 && slices.Equal(curated[removed:], kept.Contents):
+		// The policies removed the oldest turns, if any.
+		if len(kept.Contents) == 0 {
+			c.comprehensiveHistory = nil
+		} else if removed > 0 {
+			first := slices.Index(c.comprehensiveHistory, kept.Contents[0])
+			c.comprehensiveHistory = slices.Clip(c.comprehensiveHistory[first:])
+		}
+	default:
+		// The policies rewrote the history.
+		c.comprehensiveHistory = slices.Clip(kept.Contents)
+	}
+}
+
+// request returns the contents and configuration of the request that sends
+// inputContent, after applying the history policies of the chat. The history
+// that the policies kept is returned too, for recordHistory, so that the history
+// of the chat only changes once the message is sent; it is nil if the chat has
+// no policies. The caller must hold c.sendMu.
+func (c *Chat) request(ctx context.Context, inputContent *Content) ([]*Content, *GenerateContentConfig, *ChatHistory, error) {
+	c.mu.Lock()
+	history, summary := curatedHistory(c.comprehensiveHistory), c.summary
+	c.mu.Unlock()
+	var kept *ChatHistory
+	if len(c.historyPolicies) > 0 {
+		kept = &ChatHistory{Contents: slices.Clone(history), Message: inputContent, Summary: summary}
+		for _, policy := range c.historyPolicies {
+			if err := policy.Apply(ctx, &c.Models, c.model, kept); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+		history, summary = kept.Contents, kept.Summary
+	}
+
+	config := c.config
+	if summary != "" {
+		var requestConfig GenerateContentConfig
+		if config != nil {
+			requestConfig = *config
+		}
+		systemInstruction := &Content{Role: RoleUser}
+		if requestConfig.SystemInstruction != nil {
+			systemInstruction.Parts = slices.Clone(requestConfig.SystemInstruction.Parts)
+		}
+		systemInstruction.Parts = append(systemInstruction.Parts, NewPartFromText("Summary of the earlier conversation:\n"+summary))
+		requestConfig.SystemInstruction = systemInstruction
+		config = &requestConfig
+	}
+	return append(slices.Clip(history), inputContent), config, kept, nil
+}
+
 // copySanitizedModelContent creates a (shallow) copy of modelContent with role set to
 // model and all Parts copied verbatim.
 func copySanitizedModelContent(modelContent *Content) *Content {
@@ -72,17 +168,101 @@ func copySanitizedModelContent(modelContent *Content) *Content {
 	return newContent
 }
 
-// History returns the chat history. Curated (valid only) history is not supported yet.
+// History returns the chat history. The comprehensive history holds every turn,
+// including the model turns that are empty because the response was blocked or
+// failed. The curated history drops these turns and the user inputs that they
+// answer; it is the history that the chat sends to the model. The returned slice
+// is a copy that later messages don't change.
 func (c *Chat) History(curated bool) []*Content {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if curated {
-		log.Println("curated history is not supported yet")
-		return nil
+		return curatedHistory(c.comprehensiveHistory)
+	}
+	return slices.Clone(c.comprehensiveHistory)
+}
+
+// Turns returns the number of turns in the history of the chat, a turn being a
+// user message and all the contents that answer it.
+func (c *Chat) Turns() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(turnStarts(c.comprehensiveHistory))
+}
+
+// Fork returns a new chat session with the same model, configuration, history
+// policies and a copy of the history of c. Messages sent to either chat don't
+// change the history of the other. To branch the conversation from an earlier
+// turn, call Rewind on the fork.
+func (c *Chat) Fork() *Chat {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fork := &Chat{
+		apiClient:            c.apiClient,
+		model:                c.model,
+		config:               c.config,
+		cacheManager:         c.cacheManager,
+		comprehensiveHistory: slices.Clone(c.comprehensiveHistory),
+		historyPolicies:      c.historyPolicies,
+		summary:              c.summary,
+	}
+	fork.Models.apiClient = c.apiClient
+	return fork
+}
+
+// Rewind removes the last n turns from the history of the chat. It waits for the
+// message being sent, if any.
+func (c *Chat) Rewind(n int) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	starts := turnStarts(c.comprehensiveHistory)
+	if n < 0 || n > len(starts) {
+		return fmt.Errorf("Rewind: n must be between 0 and the number of turns %d, got %d", len(starts), n)
+	}
+	if n > 0 {
+		c.comprehensiveHistory = slices.Clip(c.comprehensiveHistory[:starts[len(starts)-n]])
+	}
+	return nil
+}
+
+// EditTurn replaces the user message of the given turn, counted from 0, with
+// parts, removes the turns that follow it, and sends the new message. Editing
+// the last turn with its original message regenerates the last answer.
+func (c *Chat) EditTurn(ctx context.Context, turn int, parts ...Part) (*GenerateContentResponse, error) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	c.mu.Lock()
+	starts := turnStarts(c.comprehensiveHistory)
+	if turn < 0 || turn >= len(starts) {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("EditTurn: turn must be between 0 and %d, got %d", len(starts)-1, turn)
 	}
-	return c.comprehensiveHistory
+	previous := c.comprehensiveHistory
+	c.comprehensiveHistory = slices.Clip(c.comprehensiveHistory[:starts[turn]])
+	c.mu.Unlock()
+
+	resp, err := c.sendMessage(ctx, parts)
+	if err != nil {
+		// Keep the turns if the new message could not be sent.
+		c.mu.Lock()
+		c.comprehensiveHistory = previous
+		c.mu.Unlock()
+		return nil, err
+	}
+	return resp, nil
 }
 
 // SendMessage sends the conversation history with the additional user's message and returns the model's response.
 func (c *Chat) SendMessage(ctx context.Context, parts ...Part) (*GenerateContentResponse, error) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	return c.sendMessage(ctx, parts)
+}
+
+// sendMessage implements SendMessage. The caller must hold c.sendMu.
+func (c *Chat) sendMessage(ctx context.Context, parts []Part) (*GenerateContentResponse, error) {
 	// Transform Parts to single Content
 	p := make([]*Part, len(parts))
 	for i, part := range parts {
@@ -91,10 +271,13 @@ func (c *Chat) SendMessage(ctx context.Context, parts ...Part) (*GenerateContent
 	inputContent := &Content{Parts: p, Role: RoleUser}
 
 	// Combine history with input content to send to model
-	contents := append(c.comprehensiveHistory, inputContent)
+	contents, config, kept, err := c.request(ctx, inputContent)
+	if err != nil {
+		return nil, err
+	}
 
 	// Generate Content
-	modelOutput, err := c.GenerateContent(ctx, c.model, contents, c.config)
+	modelOutput, err := c.generateContent(ctx, contents, config)
 	if err != nil {
 		return nil, err
 	}
@@ -104,7 +287,7 @@ func (c *Chat) SendMessage(ctx context.Context, parts ...Part) (*GenerateContent
 	if len(modelOutput.Candidates) > 0 && modelOutput.Candidates[0].Content != nil {
 		outputContents = append(outputContents, modelOutput.Candidates[0].Content)
 	}
-	c.recordHistory(ctx, inputContent, outputContents)
+	c.recordHistory(ctx, kept, inputContent, modelOutput.AutomaticFunctionCallingHistory, outputContents)
 
 	return modelOutput, err
 }
@@ -118,15 +301,22 @@ func (c *Chat) SendMessageStream(ctx context.Context, parts ...Part) iter.Seq2[*
 	}
 	inputContent := &Content{Parts: p, Role: "user"}
 
-	// Combine history with input content to send to model
-	contents := append(c.comprehensiveHistory, inputContent)
-
-	// Generate Content
-	response := c.GenerateContentStream(ctx, c.model, contents, c.config)
-
 	// Return a new iterator that will yield the responses and record history with merged response.
 	return func(yield func(*GenerateContentResponse, error) bool) {
-		var outputContents []*Content
+		c.sendMu.Lock()
+		defer c.sendMu.Unlock()
+
+		// Combine history with input content to send to model
+		contents, config, kept, err := c.request(ctx, inputContent)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		// Generate Content
+		var aggregator ResponseAggregator
+		response := aggregator.Aggregate(c.generateContentStream(ctx, contents, config))
+
 		for chunk, err := range response {
 			if err == io.EOF {
 				break
@@ -135,12 +325,38 @@ func (c *Chat) SendMessageStream(ctx context.Context, parts ...Part) iter.Seq2[*
 				yield(nil, err)
 				return
 			}
-			if len(chunk.Candidates) > 0 && chunk.Candidates[0].Content != nil {
-				outputContents = append(outputContents, chunk.Candidates[0].Content)
+			if !yield(chunk, nil) {
+				// The caller stopped reading: record the answer received so far, so that
+				// the history keeps alternating between user and model turns.
+				break
+			}
+		}
+		// Record history with the merged response. By default, use the first
+		// candidate for history.
+		var outputContents, functionCallingHistory []*Content
+		if merged := aggregator.Response(); merged != nil {
+			functionCallingHistory = merged.AutomaticFunctionCallingHistory
+			if len(merged.Candidates) > 0 && merged.Candidates[0].Content != nil && validModelContent(merged.Candidates[0].Content) {
+				outputContents = append(outputContents, merged.Candidates[0].Content)
 			}
-			yield(chunk, nil)
 		}
-		// Record history. By default, use the first candidate for history.
-		c.recordHistory(ctx, inputContent, outputContents)
+		c.recordHistory(ctx, kept, inputContent, functionCallingHistory, outputContents)
+	}
+}
+
+// generateContent sends a request of the chat, with its CacheManager if set.
+func (c *Chat) generateContent(ctx context.Context, contents []*Content, config *GenerateContentConfig) (*GenerateContentResponse, error) {
+	if c.cacheManager != nil {
+		return c.cacheManager.GenerateContent(ctx, c.model, contents, config)
+	}
+	return c.GenerateContent(ctx, c.model, contents, config)
+}
+
+// generateContentStream sends a streamed request of the chat, with its
+// CacheManager if set.
+func (c *Chat) generateContentStream(ctx context.Context, contents []*Content, config *GenerateContentConfig) iter.Seq2[*GenerateContentResponse, error] {
+	if c.cacheManager != nil {
+		return c.cacheManager.GenerateContentStream(ctx, c.model, contents, config)
 	}
+	return c.GenerateContentStream(ctx, c.model, contents, config)
 }
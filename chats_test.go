@@ -20,9 +20,11 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 
 	"cloud.google.com/go/auth"
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestChatsUnitTest(t *testing.T) {
@@ -263,10 +265,10 @@ func TestChatsHistory(t *testing.T) {
 				t.Errorf("Expected single text part in latest model response")
 			}
 
-			// Curated history is not supported
+			// Check curated history.
 			history = chat.History(true)
-			if history != nil {
-				log.Fatal("Curated history should return nil since it is not supported yet")
+			if len(history) != 4 {
+				t.Errorf("Expected 4 curated history entries, got %d", len(history))
 			}
 		})
 	}
@@ -418,17 +420,17 @@ data:{
 			}
 		}
 
-		expectedResponses := []string{"1 + ", "2", " = 3"}
+		// The chunks are merged into a single model turn.
 		history := chat.History(false)
 		expectedUserMessage := "What is 1 + 2?"
 		if history[0].Parts[0].Text != expectedUserMessage {
 			t.Errorf("Expected history to start with %s, got %s", expectedUserMessage, history[0].Parts[0].Text)
 		}
-		for i, expectedResponse := range expectedResponses {
-			gotResponse := history[i+1].Parts[0].Text
-			if gotResponse != expectedResponse {
-				t.Errorf("Expected model response to be %s, got %s", expectedResponse, gotResponse)
-			}
+		if len(history) != 2 {
+			t.Fatalf("Expected a single model response, got %d contents", len(history)-1)
+		}
+		if len(history[1].Parts) != 1 || history[1].Parts[0].Text != "1 + 2 = 3" {
+			t.Errorf("Expected model response to be %s, got %s", "1 + 2 = 3", history[1].Parts[0].Text)
 		}
 	})
 }
@@ -503,24 +505,120 @@ data:{
 			}
 		}
 
-		var expectedResponses []*Content
-		expectedResponses = append(expectedResponses, &Content{Role: "model", Parts: []*Part{&Part{Text: "text1_candidate1"}}})
-		expectedResponses = append(expectedResponses, &Content{Role: "model", Parts: []*Part{&Part{Text: " "}}})
-		expectedResponses = append(expectedResponses, &Content{Role: "model", Parts: []*Part{&Part{Text: "text3_candidate1"}, &Part{Text: " additional text3_candidate1 "}}})
-		expectedResponses = append(expectedResponses, &Content{Role: "model", Parts: []*Part{&Part{Text: "text4_candidate1"}, &Part{Text: " additional text4_candidate1"}}})
-
+		// The chunks of the first candidate are merged into a single model turn.
 		history := chat.History(false)
 		expectedUserMessage := "What is 1 + 2?"
 		if history[0].Parts[0].Text != expectedUserMessage {
 			t.Errorf("Expected history to start with %s, got %s", expectedUserMessage, history[0].Parts[0].Text)
 		}
-		for i, expectedResponse := range expectedResponses {
-			for j, expectedPart := range history[i+1].Parts {
-				if expectedPart.Text != expectedResponse.Parts[j].Text {
-					t.Errorf("Expected model response to be %s, got %s", expectedResponse.Parts[j].Text, part.Text)
-				}
-			}
+		expectedResponse := "text1_candidate1 text3_candidate1 additional text3_candidate1 text4_candidate1 additional text4_candidate1"
+		if len(history) != 2 || len(history[1].Parts) != 1 || history[1].Parts[0].Text != expectedResponse {
+			t.Errorf("Expected a single model response %q, got %v", expectedResponse, history[1:])
 		}
-
 	})
 }
+
+func TestChatForkRewindEditTurn(t *testing.T) {
+	ctx := context.Background()
+	ts, requests := chatHistoryServer(t)
+	defer ts.Close()
+	client := newTestClient(t, ts, nil)
+
+	chat, err := client.Chats.Create(ctx, "gemini-2.0-flash", nil, nil)
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	for _, message := range []string{"one", "two", "three"} {
+		if _, err := chat.SendMessage(ctx, Part{Text: message}); err != nil {
+			t.Fatalf("SendMessage(%q) failed: %v", message, err)
+		}
+	}
+
+	fork := chat.Fork()
+	if err := fork.Rewind(2); err != nil {
+		t.Fatalf("Rewind() failed: %v", err)
+	}
+	if err := fork.Rewind(2); err == nil {
+		t.Error("Rewind() of more turns than the history has succeeded, want error")
+	}
+	if _, err := fork.SendMessage(ctx, Part{Text: "four"}); err != nil {
+		t.Fatalf("SendMessage() failed: %v", err)
+	}
+	if diff := cmp.Diff([]string{"one", "reply to one", "four"}, requestTexts((*requests)[len(*requests)-1])); diff != "" {
+		t.Errorf("fork request contents mismatch (-want +got):\n%s", diff)
+	}
+	if got := chat.Turns(); got != 3 {
+		t.Errorf("Turns() of the forked chat = %d, want 3", got)
+	}
+
+	if _, err := chat.EditTurn(ctx, 1, Part{Text: "deux"}); err != nil {
+		t.Fatalf("EditTurn() failed: %v", err)
+	}
+	if diff := cmp.Diff([]string{"one", "reply to one", "deux"}, requestTexts((*requests)[len(*requests)-1])); diff != "" {
+		t.Errorf("edited request contents mismatch (-want +got):\n%s", diff)
+	}
+	if got := len(chat.History(false)); got != 4 {
+		t.Errorf("len(History(false)) after EditTurn() = %d, want 4", got)
+	}
+	if _, err := chat.EditTurn(ctx, 2, Part{Text: "trois"}); err == nil {
+		t.Error("EditTurn() of a missing turn succeeded, want error")
+	}
+}
+
+func TestChatAbandonedStream(t *testing.T) {
+	ctx := context.Background()
+	ts, requests := chatHistoryServer(t)
+	defer ts.Close()
+	client := newTestClient(t, ts, nil)
+
+	chat, err := client.Chats.Create(ctx, "gemini-2.0-flash", nil, nil)
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	for _, err := range chat.SendMessageStream(ctx, Part{Text: "one"}) {
+		if err != nil {
+			t.Fatalf("SendMessageStream() failed: %v", err)
+		}
+		break
+	}
+	if _, err := chat.SendMessage(ctx, Part{Text: "two"}); err != nil {
+		t.Fatalf("SendMessage() failed: %v", err)
+	}
+	if diff := cmp.Diff([]string{"one", "reply ", "two"}, requestTexts((*requests)[len(*requests)-1])); diff != "" {
+		t.Errorf("request contents mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestChatConcurrentSends(t *testing.T) {
+	ctx := context.Background()
+	ts, _ := chatHistoryServer(t)
+	defer ts.Close()
+	client := newTestClient(t, ts, nil)
+
+	chat, err := client.Chats.Create(ctx, "gemini-2.0-flash", nil, nil)
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := chat.SendMessage(ctx, Part{Text: fmt.Sprint(i)}); err != nil {
+				t.Errorf("SendMessage() failed: %v", err)
+			}
+			chat.History(true)
+		}()
+	}
+	wg.Wait()
+
+	history := chat.History(false)
+	if len(history) != 16 {
+		t.Fatalf("len(History(false)) = %d, want 16", len(history))
+	}
+	for i := 0; i < len(history); i += 2 {
+		if want := "reply to " + history[i].Parts[0].Text; history[i+1].Parts[0].Text != want {
+			t.Errorf("History(false)[%d] = %q, want %q", i+1, history[i+1].Parts[0].Text, want)
+		}
+	}
+}
@@ -24,6 +24,8 @@ import (
 	"cloud.google.com/go/auth"
 	"cloud.google.com/go/auth/credentials"
 	"cloud.google.com/go/auth/httptransport"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Client is the GenAI client. It provides access to the various GenAI services.
@@ -99,6 +101,29 @@ type ClientConfig struct {
 	// Optional HTTP options to override.
 	HTTPOptions HTTPOptions
 
+	// Optional interceptors that observe and modify the calls sent to the server.
+	// They run in order, the first interceptor being the outermost. See [Interceptor].
+	Interceptors []Interceptor
+
+	// Optional OpenTelemetry tracer provider. If set, the client records a span for
+	// every operation of the Models, Caches, Files, Operations and Live services, and
+	// propagates the trace context to the server with the global propagator.
+	TracerProvider trace.TracerProvider
+
+	// Optional OpenTelemetry meter provider. If set, the client records the duration
+	// and token usage of operations, and the chunk latency of streaming operations.
+	MeterProvider metric.MeterProvider
+
+	// Optional. If true, GenerateContent and GenerateContentStream check requests with
+	// [ValidateGenerateContent] before sending them, and return a *ValidationError
+	// instead of sending invalid requests.
+	ValidateRequests bool
+
+	// Optional. Cloud Storage location where the Files service stores files on the
+	// Vertex AI backend. If nil, the Files service is only supported by the Gemini
+	// Developer backend, except for downloads of gs:// URIs. See [FileStorageConfig].
+	FileStorage *FileStorageConfig
+
 	envVarProvider func() map[string]string
 }
 
@@ -260,7 +285,12 @@ func NewClient(ctx context.Context, cc *ClientConfig) (*Client, error) {
 		}
 	}
 
-	ac := &apiClient{clientConfig: cc}
+	telemetry, err := newTelemetry(cc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up telemetry: %w", err)
+	}
+
+	ac := &apiClient{clientConfig: cc, telemetry: telemetry}
 	c := &Client{
 		clientConfig: *cc,
 		Models:       &Models{apiClient: ac},
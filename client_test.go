@@ -17,6 +17,7 @@ package genai
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
@@ -560,3 +561,35 @@ func TestClientConfigHTTPOptions(t *testing.T) {
 		})
 	}
 }
+
+// newTestClient creates a client that sends its requests to ts. Unset fields of
+// config default to the Gemini API with a test key, ts.URL as the base URL and
+// a single attempt per request. The environment variables are never read.
+func newTestClient(t *testing.T, ts *httptest.Server, config *ClientConfig) *Client {
+	t.Helper()
+	var cc ClientConfig
+	if config != nil {
+		cc = *config
+	}
+	if cc.Backend == BackendUnspecified {
+		cc.Backend = BackendGeminiAPI
+	}
+	if cc.Backend == BackendGeminiAPI && cc.APIKey == "" {
+		cc.APIKey = "test-api-key"
+	}
+	if cc.HTTPClient == nil {
+		cc.HTTPClient = ts.Client()
+	}
+	if cc.HTTPOptions.BaseURL == "" {
+		cc.HTTPOptions.BaseURL = ts.URL
+	}
+	if cc.HTTPOptions.RetryOptions == nil {
+		cc.HTTPOptions.RetryOptions = &RetryOptions{Attempts: 1, InitialDelay: time.Millisecond}
+	}
+	cc.envVarProvider = func() map[string]string { return map[string]string{} }
+	client, err := NewClient(context.Background(), &cc)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	return client
+}
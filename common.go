@@ -271,13 +271,15 @@ func mergeHTTPOptions(clientConfig *ClientConfig, configHTTPOptions *HTTPOptions
 		return nil
 	} else if clientHTTPOptions == nil {
 		result = HTTPOptions{
-			BaseURL:    configHTTPOptions.BaseURL,
-			APIVersion: configHTTPOptions.APIVersion,
+			BaseURL:      configHTTPOptions.BaseURL,
+			APIVersion:   configHTTPOptions.APIVersion,
+			RetryOptions: configHTTPOptions.RetryOptions,
 		}
 	} else {
 		result = HTTPOptions{
-			BaseURL:    clientHTTPOptions.BaseURL,
-			APIVersion: clientHTTPOptions.APIVersion,
+			BaseURL:      clientHTTPOptions.BaseURL,
+			APIVersion:   clientHTTPOptions.APIVersion,
+			RetryOptions: clientHTTPOptions.RetryOptions,
 		}
 	}
 
@@ -288,6 +290,9 @@ func mergeHTTPOptions(clientConfig *ClientConfig, configHTTPOptions *HTTPOptions
 		if configHTTPOptions.APIVersion != "" {
 			result.APIVersion = configHTTPOptions.APIVersion
 		}
+		if configHTTPOptions.RetryOptions != nil {
+			result.RetryOptions = configHTTPOptions.RetryOptions
+		}
 	}
 	result.Headers = mergeHeaders(clientHTTPOptions, configHTTPOptions)
 	return &result
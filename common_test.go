@@ -167,6 +167,38 @@ func TestMergeHTTPOptions(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "request retry options override client",
+			clientConfig: &ClientConfig{
+				HTTPOptions: HTTPOptions{
+					BaseURL:      "https://client.com",
+					RetryOptions: &RetryOptions{Attempts: 2},
+				},
+			},
+			requestHTTPOptions: &HTTPOptions{
+				RetryOptions: &RetryOptions{Attempts: 4},
+			},
+			want: &HTTPOptions{
+				BaseURL:      "https://client.com",
+				Headers:      http.Header{},
+				RetryOptions: &RetryOptions{Attempts: 4},
+			},
+		},
+		{
+			name: "client retry options",
+			clientConfig: &ClientConfig{
+				HTTPOptions: HTTPOptions{
+					BaseURL:      "https://client.com",
+					RetryOptions: &RetryOptions{Attempts: 2},
+				},
+			},
+			requestHTTPOptions: &HTTPOptions{},
+			want: &HTTPOptions{
+				BaseURL:      "https://client.com",
+				Headers:      http.Header{},
+				RetryOptions: &RetryOptions{Attempts: 2},
+			},
+		},
 	}
 
 	for _, tt := range tests {
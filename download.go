@@ -0,0 +1,315 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const defaultDownloadResumeAttempts = 3
+
+// ErrChecksumMismatch is returned, wrapped, when the SHA-256 hash of downloaded
+// bytes doesn't match the hash of the file.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// DownloadProgress reports the progress of a download.
+type DownloadProgress struct {
+	// DownloadedBytes is the number of bytes written so far.
+	DownloadedBytes int64
+	// TotalBytes is the size of the file, or 0 if it is unknown.
+	TotalBytes int64
+}
+
+// downloader streams a file to a writer. When reading the response body fails,
+// it requests the rest of the file with an HTTP Range header.
+type downloader struct {
+	ac   *apiClient
+	path string
+	// url is the absolute URL of the file, used instead of the API path if set.
+	url            string
+	httpOptions    *HTTPOptions
+	resumeAttempts int
+	progress       func(DownloadProgress)
+	// sha256Hash is the expected hash of the file, base64 or hex encoded, if known.
+	sha256Hash string
+
+	written int64
+	total   int64
+}
+
+func newDownloader(ac *apiClient, path string, httpOptions *HTTPOptions, config *DownloadFileConfig) *downloader {
+	d := &downloader{ac: ac, path: path, httpOptions: httpOptions, resumeAttempts: defaultDownloadResumeAttempts}
+	if config != nil {
+		if config.ResumeAttempts != 0 {
+			d.resumeAttempts = max(config.ResumeAttempts, 0)
+		}
+		d.progress = config.Progress
+	}
+	return d
+}
+
+// download writes the file to w and returns the number of bytes written.
+func (d *downloader) download(ctx context.Context, w io.Writer) (n int64, err error) {
+	ctx, span := d.ac.startSpan(ctx, "", nil, d.httpOptions)
+	defer func() { span.end(err) }()
+
+	var h hash.Hash
+	if d.sha256Hash != "" {
+		h = sha256.New()
+		w = io.MultiWriter(w, h)
+	}
+	retryOptions := resolveRetryOptions(d.ac, d.httpOptions)
+	if retryOptions == nil {
+		retryOptions = &RetryOptions{}
+	}
+	for attempt := 0; ; attempt++ {
+		err := d.copyFrom(ctx, w)
+		if err == nil {
+			break
+		}
+		if attempt >= d.resumeAttempts || ctx.Err() != nil || !resumableError(err) || errors.Is(err, errDownloadWrite) {
+			return d.written, err
+		}
+		if err := sleepContext(ctx, retryOptions.backoff(attempt+1)); err != nil {
+			return d.written, err
+		}
+	}
+
+	if h != nil {
+		if err := verifySHA256(h.Sum(nil), d.sha256Hash); err != nil {
+			return d.written, err
+		}
+	}
+	return d.written, nil
+}
+
+// errDownloadWrite marks the errors of the writer, which are not retried.
+var errDownloadWrite = errors.New("failed to write downloaded bytes")
+
+// copyFrom requests the file from offset d.written and copies the response body
+// to w.
+func (d *downloader) copyFrom(ctx context.Context, w io.Writer) error {
+	req, err := d.request(ctx)
+	if err != nil {
+		return err
+	}
+	if d.written > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", d.written))
+	}
+
+	call := &InterceptedCall{Operation: operationFromContext(ctx), Kind: CallKindDownload, Request: req}
+	intercepted, err := intercept(ctx, d.ac, call, func(ctx context.Context, call *InterceptedCall) (*InterceptedResponse, error) {
+		resp, err := doRequest(d.ac, call.Request, d.httpOptions)
+		if err != nil {
+			return nil, err
+		}
+		return &InterceptedResponse{HTTPResponse: resp}, nil
+	})
+	if err != nil {
+		return err
+	}
+	resp := intercepted.HTTPResponse
+	if resp == nil {
+		return fmt.Errorf("download request returned no HTTP response")
+	}
+	defer resp.Body.Close()
+	if !httpStatusOk(resp) {
+		return newAPIError(resp)
+	}
+
+	body := io.Reader(resp.Body)
+	switch {
+	case d.written == 0 || resp.StatusCode == http.StatusPartialContent:
+		if start, total, ok := parseContentRange(resp.Header.Get("Content-Range")); ok {
+			if start != d.written {
+				return fmt.Errorf("download resumed at byte %d, want %d", start, d.written)
+			}
+			d.total = total
+		} else if resp.ContentLength >= 0 {
+			d.total = d.written + resp.ContentLength
+		}
+	default:
+		// The server ignored the Range header and sent the whole file: skip the
+		// bytes already written.
+		if _, err := io.CopyN(io.Discard, body, d.written); err != nil {
+			return fmt.Errorf("failed to skip %d downloaded bytes: %w", d.written, err)
+		}
+		if resp.ContentLength >= 0 {
+			d.total = resp.ContentLength
+		}
+	}
+
+	buffer := make([]byte, 32*1024)
+	for {
+		n, readErr := body.Read(buffer)
+		if n > 0 {
+			if _, err := w.Write(buffer[:n]); err != nil {
+				return fmt.Errorf("%w: %w", errDownloadWrite, err)
+			}
+			d.written += int64(n)
+			if d.progress != nil {
+				d.progress(DownloadProgress{DownloadedBytes: d.written, TotalBytes: d.total})
+			}
+		}
+		if readErr == io.EOF {
+			if d.total > 0 && d.written < d.total {
+				return fmt.Errorf("download ended at byte %d of %d: %w", d.written, d.total, io.ErrUnexpectedEOF)
+			}
+			return nil
+		}
+		if readErr != nil {
+			return fmt.Errorf("failed to read download at byte %d: %w", d.written, readErr)
+		}
+	}
+}
+
+// request builds the request of the file, from d.url if set, or else from the
+// API path d.path.
+func (d *downloader) request(ctx context.Context) (*http.Request, error) {
+	if d.url == "" {
+		return buildRequest(ctx, d.ac, d.path, nil, http.MethodGet, d.httpOptions)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if d.httpOptions != nil {
+		doMergeHeaders(d.httpOptions.Headers, &req.Header)
+	}
+	d.ac.injectTraceContext(ctx, req.Header)
+	return req, nil
+}
+
+// parseContentRange parses a Content-Range header such as "bytes 10-99/100". The
+// total is 0 if it is unknown.
+func parseContentRange(header string) (start, total int64, ok bool) {
+	r, found := strings.CutPrefix(header, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	r, size, found := strings.Cut(r, "/")
+	if !found {
+		return 0, 0, false
+	}
+	first, _, found := strings.Cut(r, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	total, _ = strconv.ParseInt(size, 10, 64)
+	return start, total, true
+}
+
+// verifySHA256 compares a digest with the expected hash.
+func verifySHA256(digest []byte, expected string) error {
+	want, ok := decodeSHA256(expected)
+	if !ok {
+		return fmt.Errorf("invalid SHA-256 hash %q", expected)
+	}
+	if !bytes.Equal(digest, want) {
+		return fmt.Errorf("%w: SHA-256 of the downloaded bytes is %s, want %s", ErrChecksumMismatch, base64.StdEncoding.EncodeToString(digest), expected)
+	}
+	return nil
+}
+
+// decodeSHA256 decodes a SHA-256 hash encoded in base64 or hex, or in base64 of
+// its hex encoding, as File.Sha256Hash may be.
+func decodeSHA256(s string) ([]byte, bool) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || (len(b) != sha256.Size && len(b) != hex.EncodedLen(sha256.Size)) {
+		b = []byte(s)
+	}
+	if len(b) == hex.EncodedLen(sha256.Size) {
+		if decoded, err := hex.DecodeString(string(b)); err == nil {
+			return decoded, true
+		}
+	}
+	return b, len(b) == sha256.Size
+}
+
+// download implements Files.Download.
+func (m Files) download(ctx context.Context, uri DownloadURI, config *DownloadFileConfig) ([]byte, error) {
+	ctx = withOperation(ctx, "Files.Download")
+	d, err := m.downloader(uri, config)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if _, err := d.download(ctx, &b); err != nil {
+		return nil, err
+	}
+	data := b.Bytes()
+	if config == nil || !config.SkipVideoBytes {
+		_ = uri.setVideoBytes(data)
+	}
+	return data, nil
+}
+
+// DownloadTo streams a file from the specified URI to w, and returns the number
+// of bytes written. If the connection fails, the download resumes with an HTTP
+// Range request; see [DownloadFileConfig]. If the URI is a [File] with a
+// Sha256Hash, the downloaded bytes are verified against it, and an error wrapping
+// [ErrChecksumMismatch] is returned if they don't match. The VideoBytes field of
+// a video is not populated.
+func (m Files) DownloadTo(ctx context.Context, uri DownloadURI, w io.Writer, config *DownloadFileConfig) (int64, error) {
+	ctx = withOperation(ctx, "Files.DownloadTo")
+	d, err := m.downloader(uri, config)
+	if err != nil {
+		return 0, err
+	}
+	return d.download(ctx, w)
+}
+
+// downloader returns the downloader of the file of uri.
+func (m Files) downloader(uri DownloadURI, config *DownloadFileConfig) (*downloader, error) {
+	if uri.uri() == "" {
+		return nil, fmt.Errorf("the resource doesn't support download")
+	}
+	if m.apiClient.clientConfig.Backend == BackendVertexAI {
+		return m.storageDownloader(uri, config)
+	}
+	fileName, err := tFileName(m.apiClient, uri.uri())
+	if err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("files/%s:download?alt=media", fileName)
+
+	var httpOptions *HTTPOptions
+	if config == nil {
+		httpOptions = mergeHTTPOptions(m.apiClient.clientConfig, nil)
+	} else {
+		httpOptions = mergeHTTPOptions(m.apiClient.clientConfig, config.HTTPOptions)
+	}
+
+	d := newDownloader(m.apiClient, path, httpOptions, config)
+	if f, ok := uri.(*File); ok {
+		d.sha256Hash = f.Sha256Hash
+	}
+	return d, nil
+}
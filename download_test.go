@@ -0,0 +1,147 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// flakyDownloadServer serves content at any path. The first response is cut in
+// the middle; the following requests are served with Range support, except the
+// second request of a path containing "unavailable", which fails with 503.
+func flakyDownloadServer(t *testing.T, content string) (*httptest.Server, *[]string) {
+	t.Helper()
+	var ranges []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "missing") {
+			http.Error(w, `{"error": {"code": 404, "message": "not found", "status": "NOT_FOUND"}}`, http.StatusNotFound)
+			return
+		}
+		rangeHeader := r.Header.Get("Range")
+		ranges = append(ranges, rangeHeader)
+		if len(ranges) == 2 && strings.Contains(r.URL.Path, "unavailable") {
+			http.Error(w, `{"error": {"code": 503, "message": "overloaded", "status": "UNAVAILABLE"}}`, http.StatusServiceUnavailable)
+			return
+		}
+		if len(ranges) == 1 {
+			// Announce the whole content but send half of it.
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			fmt.Fprint(w, content[:len(content)/2])
+			return
+		}
+		start := 0
+		if rangeHeader != "" {
+			start, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rangeHeader, "bytes="), "-"))
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(content)-1, len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		fmt.Fprint(w, content[start:])
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &ranges
+}
+
+func TestFilesDownloadTo(t *testing.T) {
+	ctx := context.Background()
+	content := strings.Repeat("video bytes ", 1000)
+	sum := sha256.Sum256([]byte(content))
+	hash := base64.StdEncoding.EncodeToString(sum[:])
+
+	t.Run("resume", func(t *testing.T) {
+		ts, ranges := flakyDownloadServer(t, content)
+		client := newTestClient(t, ts, nil)
+
+		var last DownloadProgress
+		var b bytes.Buffer
+		config := &DownloadFileConfig{Progress: func(p DownloadProgress) { last = p }}
+		n, err := client.Files.DownloadTo(ctx, &File{DownloadURI: "files/video", Sha256Hash: hash}, &b, config)
+		if err != nil {
+			t.Fatalf("DownloadTo() failed: %v", err)
+		}
+		if n != int64(len(content)) || b.String() != content {
+			t.Errorf("DownloadTo() wrote %d bytes, want the %d bytes of the content", n, len(content))
+		}
+		if want := fmt.Sprintf("bytes=%d-", len(content)/2); len(*ranges) != 2 || (*ranges)[1] != want {
+			t.Errorf("Range headers = %q, want a second request with %q", *ranges, want)
+		}
+		if want := (DownloadProgress{DownloadedBytes: int64(len(content)), TotalBytes: int64(len(content))}); last != want {
+			t.Errorf("last progress = %+v, want %+v", last, want)
+		}
+	})
+
+	t.Run("resume after unavailable", func(t *testing.T) {
+		ts, ranges := flakyDownloadServer(t, content)
+		client := newTestClient(t, ts, nil)
+		var b bytes.Buffer
+		if _, err := client.Files.DownloadTo(ctx, &File{DownloadURI: "files/unavailable", Sha256Hash: hash}, &b, nil); err != nil {
+			t.Fatalf("DownloadTo() failed: %v", err)
+		}
+		if b.String() != content {
+			t.Errorf("DownloadTo() wrote %d bytes, want the %d bytes of the content", b.Len(), len(content))
+		}
+		if want := fmt.Sprintf("bytes=%d-", len(content)/2); len(*ranges) != 3 || (*ranges)[2] != want {
+			t.Errorf("Range headers = %q, want a third request with %q", *ranges, want)
+		}
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		ts, _ := flakyDownloadServer(t, content)
+		client := newTestClient(t, ts, nil)
+		wrong := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+		_, err := client.Files.DownloadTo(ctx, &File{DownloadURI: "files/video", Sha256Hash: wrong}, &bytes.Buffer{}, nil)
+		if !errors.Is(err, ErrChecksumMismatch) {
+			t.Errorf("DownloadTo() error = %v, want ErrChecksumMismatch", err)
+		}
+	})
+
+	t.Run("no resume", func(t *testing.T) {
+		ts, _ := flakyDownloadServer(t, content)
+		client := newTestClient(t, ts, nil)
+		if _, err := client.Files.DownloadTo(ctx, &File{DownloadURI: "files/video"}, &bytes.Buffer{}, &DownloadFileConfig{ResumeAttempts: -1}); err == nil {
+			t.Error("DownloadTo() of a cut response succeeded, want error")
+		}
+	})
+
+	t.Run("status", func(t *testing.T) {
+		ts, _ := flakyDownloadServer(t, content)
+		client := newTestClient(t, ts, nil)
+		if _, err := client.Files.Download(ctx, &File{DownloadURI: "files/missing"}, nil); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Download() error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("skip video bytes", func(t *testing.T) {
+		ts, _ := flakyDownloadServer(t, content)
+		client := newTestClient(t, ts, nil)
+		video := &Video{URI: "files/video"}
+		data, err := client.Files.Download(ctx, video, &DownloadFileConfig{SkipVideoBytes: true})
+		if err != nil {
+			t.Fatalf("Download() failed: %v", err)
+		}
+		if string(data) != content || video.VideoBytes != nil {
+			t.Errorf("Download() = %d bytes, VideoBytes = %d bytes, want %d and none", len(data), len(video.VideoBytes), len(content))
+		}
+	})
+}
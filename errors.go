@@ -0,0 +1,263 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Sentinel errors that classify the failures reported by the server. An
+// [APIError] matches the sentinel of its failure class with errors.Is, so
+// callers can branch on the class of a failure of any call:
+//
+//	if errors.Is(err, genai.ErrRateLimited) {
+//		// Back off and try again later.
+//	}
+var (
+	// ErrRateLimited is matched by errors for exhausted quotas or rate limits
+	// (HTTP 429, RESOURCE_EXHAUSTED).
+	ErrRateLimited = errors.New("rate limited")
+	// ErrPermissionDenied is matched by errors for missing permissions or invalid
+	// credentials (HTTP 401 and 403, PERMISSION_DENIED and UNAUTHENTICATED).
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrNotFound is matched by errors for missing resources (HTTP 404, NOT_FOUND).
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidArgument is matched by errors for invalid requests (HTTP 400,
+	// INVALID_ARGUMENT, FAILED_PRECONDITION and OUT_OF_RANGE).
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrDeadlineExceeded is matched by errors for requests that the server did
+	// not complete in time (HTTP 504, DEADLINE_EXCEEDED).
+	ErrDeadlineExceeded = errors.New("deadline exceeded")
+	// ErrUnavailable is matched by errors for temporarily unavailable services
+	// (HTTP 503, UNAVAILABLE).
+	ErrUnavailable = errors.New("unavailable")
+)
+
+// class returns the sentinel error of the failure class of e, or nil if e does
+// not belong to any class.
+func (e APIError) class() error {
+	switch e.Status {
+	case "RESOURCE_EXHAUSTED":
+		return ErrRateLimited
+	case "PERMISSION_DENIED", "UNAUTHENTICATED":
+		return ErrPermissionDenied
+	case "NOT_FOUND":
+		return ErrNotFound
+	case "INVALID_ARGUMENT", "FAILED_PRECONDITION", "OUT_OF_RANGE":
+		return ErrInvalidArgument
+	case "DEADLINE_EXCEEDED":
+		return ErrDeadlineExceeded
+	case "UNAVAILABLE":
+		return ErrUnavailable
+	}
+	switch e.Code {
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrPermissionDenied
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusBadRequest:
+		return ErrInvalidArgument
+	case http.StatusGatewayTimeout:
+		return ErrDeadlineExceeded
+	case http.StatusServiceUnavailable:
+		return ErrUnavailable
+	}
+	return nil
+}
+
+// Is reports whether target is the sentinel error of the failure class of e,
+// such as [ErrNotFound].
+func (e APIError) Is(target error) bool {
+	class := e.class()
+	return class != nil && target == class
+}
+
+const (
+	errorInfoType    = "type.googleapis.com/google.rpc.ErrorInfo"
+	retryInfoType    = "type.googleapis.com/google.rpc.RetryInfo"
+	quotaFailureType = "type.googleapis.com/google.rpc.QuotaFailure"
+	badRequestType   = "type.googleapis.com/google.rpc.BadRequest"
+	helpType         = "type.googleapis.com/google.rpc.Help"
+)
+
+// ErrorDetails holds the typed details of an [APIError].
+type ErrorDetails struct {
+	// ErrorInfo describes the cause of the error.
+	ErrorInfo *ErrorInfo
+	// RetryInfo tells when the request can be retried.
+	RetryInfo *RetryInfo
+	// QuotaFailure describes the exceeded quotas.
+	QuotaFailure *QuotaFailure
+	// BadRequest describes the invalid fields of the request.
+	BadRequest *BadRequest
+	// Help links to documentation about the error.
+	Help *Help
+	// Unknown holds the details of other types, and details that could not be
+	// decoded.
+	Unknown []map[string]any
+}
+
+// ErrorInfo describes the cause of an error.
+type ErrorInfo struct {
+	// Reason is a short, constant identifier of the cause, such as "API_KEY_INVALID".
+	Reason string `json:"reason,omitempty"`
+	// Domain is the logical grouping of Reason, such as "googleapis.com".
+	Domain string `json:"domain,omitempty"`
+	// Metadata holds additional structured information about the cause.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// RetryInfo tells when a failed request can be retried.
+type RetryInfo struct {
+	// RetryDelay is the time to wait before retrying the request.
+	RetryDelay time.Duration
+}
+
+// QuotaFailure describes the quotas that a request exceeded.
+type QuotaFailure struct {
+	// Violations lists the exceeded quotas.
+	Violations []QuotaViolation `json:"violations,omitempty"`
+}
+
+// QuotaViolation describes an exceeded quota.
+type QuotaViolation struct {
+	// Subject is the subject on which the quota check failed, such as "project:123".
+	Subject string `json:"subject,omitempty"`
+	// Description describes how the quota was exceeded.
+	Description string `json:"description,omitempty"`
+	// QuotaMetric is the metric of the exceeded quota.
+	QuotaMetric string `json:"quotaMetric,omitempty"`
+	// QuotaID is the identifier of the exceeded quota.
+	QuotaID string `json:"quotaId,omitempty"`
+	// QuotaDimensions are the dimensions of the exceeded quota, such as the model.
+	QuotaDimensions map[string]string `json:"quotaDimensions,omitempty"`
+	// QuotaValue is the value of the exceeded quota.
+	QuotaValue int64 `json:"quotaValue,omitempty,string"`
+}
+
+// BadRequest describes the invalid fields of a request.
+type BadRequest struct {
+	// FieldViolations lists the invalid fields.
+	FieldViolations []FieldViolation `json:"fieldViolations,omitempty"`
+}
+
+// FieldViolation describes an invalid field of a request.
+type FieldViolation struct {
+	// Field is the path to the invalid field, such as "contents[0].parts".
+	Field string `json:"field,omitempty"`
+	// Description describes why the field is invalid.
+	Description string `json:"description,omitempty"`
+	// Reason is a short, constant identifier of the violation.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Help links to documentation about an error.
+type Help struct {
+	// Links lists the documentation links.
+	Links []HelpLink `json:"links,omitempty"`
+}
+
+// HelpLink is a link to documentation.
+type HelpLink struct {
+	// Description describes what the link offers.
+	Description string `json:"description,omitempty"`
+	// URL is the URL of the link.
+	URL string `json:"url,omitempty"`
+}
+
+// ErrorDetails decodes the Details of e into their typed form.
+func (e APIError) ErrorDetails() ErrorDetails {
+	var details ErrorDetails
+	for _, detail := range e.Details {
+		decoded := false
+		switch detail["@type"] {
+		case errorInfoType:
+			details.ErrorInfo, decoded = decodeErrorDetail[ErrorInfo](detail)
+		case retryInfoType:
+			details.RetryInfo, decoded = decodeRetryInfo(detail)
+		case quotaFailureType:
+			details.QuotaFailure, decoded = decodeErrorDetail[QuotaFailure](detail)
+		case badRequestType:
+			details.BadRequest, decoded = decodeErrorDetail[BadRequest](detail)
+		case helpType:
+			details.Help, decoded = decodeErrorDetail[Help](detail)
+		}
+		if !decoded {
+			details.Unknown = append(details.Unknown, detail)
+		}
+	}
+	return details
+}
+
+func decodeErrorDetail[T any](detail map[string]any) (*T, bool) {
+	v := new(T)
+	if err := mapToStruct(detail, v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// decodeRetryInfo decodes a RetryInfo, whose delay is a protobuf Duration in its
+// JSON form, such as "1.5s".
+func decodeRetryInfo(detail map[string]any) (*RetryInfo, bool) {
+	s, ok := detail["retryDelay"].(string)
+	if !ok {
+		return nil, false
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return nil, false
+	}
+	return &RetryInfo{RetryDelay: d}, true
+}
+
+// apiErrorFromMap returns the APIError of a response body that carries an
+// "error" object, such as an error sent in a stream or a Live session.
+func apiErrorFromMap(body map[string]any) (APIError, bool) {
+	errorMap, ok := body["error"].(map[string]any)
+	if !ok {
+		return APIError{}, false
+	}
+	var apiErr APIError
+	if err := mapToStruct(errorMap, &apiErr); err != nil {
+		return APIError{}, false
+	}
+	return apiErr, true
+}
+
+// liveCloseError converts the close frame of a Live session into an APIError.
+// It returns false for a normal closure.
+func liveCloseError(closeErr *websocket.CloseError) (APIError, bool) {
+	apiErr := APIError{Message: closeErr.Text}
+	switch closeErr.Code {
+	case websocket.CloseNormalClosure:
+		return APIError{}, false
+	case websocket.CloseInvalidFramePayloadData:
+		apiErr.Code, apiErr.Status = http.StatusBadRequest, "INVALID_ARGUMENT"
+	case websocket.ClosePolicyViolation:
+		apiErr.Code, apiErr.Status = http.StatusForbidden, "PERMISSION_DENIED"
+	case websocket.CloseGoingAway, websocket.CloseTryAgainLater:
+		apiErr.Code, apiErr.Status = http.StatusServiceUnavailable, "UNAVAILABLE"
+	default:
+		apiErr.Code, apiErr.Status = http.StatusInternalServerError, "INTERNAL"
+	}
+	return apiErr, true
+}
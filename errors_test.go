@@ -0,0 +1,252 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/websocket"
+)
+
+func TestAPIErrorIs(t *testing.T) {
+	sentinels := []error{ErrRateLimited, ErrPermissionDenied, ErrNotFound, ErrInvalidArgument, ErrDeadlineExceeded, ErrUnavailable}
+	tests := []struct {
+		desc string
+		err  APIError
+		want error
+	}{
+		{"rate limited status", APIError{Code: 429, Status: "RESOURCE_EXHAUSTED"}, ErrRateLimited},
+		{"rate limited code", APIError{Code: 429, Status: "429 Too Many Requests"}, ErrRateLimited},
+		{"permission denied", APIError{Code: 403, Status: "PERMISSION_DENIED"}, ErrPermissionDenied},
+		{"unauthenticated", APIError{Code: 401, Status: "UNAUTHENTICATED"}, ErrPermissionDenied},
+		{"not found", APIError{Code: 404, Status: "NOT_FOUND"}, ErrNotFound},
+		{"invalid argument", APIError{Code: 400, Status: "INVALID_ARGUMENT"}, ErrInvalidArgument},
+		{"failed precondition", APIError{Code: 400, Status: "FAILED_PRECONDITION"}, ErrInvalidArgument},
+		{"deadline exceeded", APIError{Code: 504, Status: "DEADLINE_EXCEEDED"}, ErrDeadlineExceeded},
+		{"unavailable", APIError{Code: 503, Status: "UNAVAILABLE"}, ErrUnavailable},
+		{"status wins over code", APIError{Code: 400, Status: "NOT_FOUND"}, ErrNotFound},
+		{"internal", APIError{Code: 500, Status: "INTERNAL"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			wrapped := fmt.Errorf("wrapped: %w", tt.err)
+			for _, sentinel := range sentinels {
+				if got := errors.Is(wrapped, sentinel); got != (sentinel == tt.want) {
+					t.Errorf("errors.Is(%v, %v) = %v, want %v", tt.err, sentinel, got, !got)
+				}
+			}
+			var apiErr APIError
+			if !errors.As(wrapped, &apiErr) || apiErr.Code != tt.err.Code {
+				t.Errorf("errors.As() = %v, want %v", apiErr, tt.err)
+			}
+		})
+	}
+}
+
+func TestAPIErrorDetails(t *testing.T) {
+	var apiErr APIError
+	err := json.Unmarshal([]byte(`{
+		"code": 429,
+		"message": "quota exceeded",
+		"status": "RESOURCE_EXHAUSTED",
+		"details": [
+			{"@type": "type.googleapis.com/google.rpc.ErrorInfo", "reason": "RATE_LIMIT_EXCEEDED", "domain": "googleapis.com", "metadata": {"service": "generativelanguage.googleapis.com"}},
+			{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "1.5s"},
+			{"@type": "type.googleapis.com/google.rpc.QuotaFailure", "violations": [{"quotaMetric": "generate_content_requests", "quotaId": "PerMinute", "quotaDimensions": {"model": "gemini-2.0-flash"}, "quotaValue": "15"}]},
+			{"@type": "type.googleapis.com/google.rpc.BadRequest", "fieldViolations": [{"field": "contents[0]", "description": "must not be empty"}]},
+			{"@type": "type.googleapis.com/google.rpc.Help", "links": [{"description": "Quotas", "url": "https://ai.google.dev/gemini-api/docs/rate-limits"}]},
+			{"@type": "type.googleapis.com/google.rpc.LocalizedMessage", "locale": "en-US", "message": "quota exceeded"}
+		]
+	}`), &apiErr)
+	if err != nil {
+		t.Fatalf("json.Unmarshal() failed: %v", err)
+	}
+
+	want := ErrorDetails{
+		ErrorInfo: &ErrorInfo{Reason: "RATE_LIMIT_EXCEEDED", Domain: "googleapis.com", Metadata: map[string]string{"service": "generativelanguage.googleapis.com"}},
+		RetryInfo: &RetryInfo{RetryDelay: 1500 * time.Millisecond},
+		QuotaFailure: &QuotaFailure{Violations: []QuotaViolation{
+			{QuotaMetric: "generate_content_requests", QuotaID: "PerMinute", QuotaDimensions: map[string]string{"model": "gemini-2.0-flash"}, QuotaValue: 15},
+		}},
+		BadRequest: &BadRequest{FieldViolations: []FieldViolation{{Field: "contents[0]", Description: "must not be empty"}}},
+		Help:       &Help{Links: []HelpLink{{Description: "Quotas", URL: "https://ai.google.dev/gemini-api/docs/rate-limits"}}},
+		Unknown:    []map[string]any{{"@type": "type.googleapis.com/google.rpc.LocalizedMessage", "locale": "en-US", "message": "quota exceeded"}},
+	}
+	if diff := cmp.Diff(want, apiErr.ErrorDetails()); diff != "" {
+		t.Errorf("ErrorDetails() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSendRequestErrorClasses(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		desc        string
+		code        int
+		body        string
+		want        error
+		wantMessage string
+	}{
+		{"json error", http.StatusNotFound, `{"error": {"code": 404, "message": "model not found", "status": "NOT_FOUND"}}`, ErrNotFound, "model not found"},
+		{"json without error key", http.StatusServiceUnavailable, `{"message": "overloaded"}`, ErrUnavailable, `{"message": "overloaded"}`},
+		{"json error without code", http.StatusForbidden, `{"error": {"message": "denied"}}`, ErrPermissionDenied, "denied"},
+		{"plain text error", http.StatusTooManyRequests, `slow down`, ErrRateLimited, "slow down"},
+		{"html error", http.StatusBadGateway, "<html>" + strings.Repeat("x", 2000), nil, "<html>" + strings.Repeat("x", 1018) + "..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.code)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer ts.Close()
+			ac := &apiClient{clientConfig: &ClientConfig{HTTPClient: ts.Client(), HTTPOptions: HTTPOptions{BaseURL: ts.URL}}}
+
+			_, err := sendRequest(ctx, ac, "foo", http.MethodGet, nil, &HTTPOptions{BaseURL: ts.URL})
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("sendRequest() error = %v, want %v", err, tt.want)
+			}
+			apiErr, ok := err.(APIError)
+			if !ok || apiErr.Code != tt.code || apiErr.Message != tt.wantMessage {
+				t.Errorf("sendRequest() error = %#v, want APIError with code %d and message %q", err, tt.code, tt.wantMessage)
+			}
+			if want := fmt.Sprintf("%d %s", tt.code, http.StatusText(tt.code)); !strings.Contains(tt.body, `"error"`) && apiErr.Status != want {
+				t.Errorf("sendRequest() error Status = %q, want %q for a body without error", apiErr.Status, want)
+			}
+		})
+	}
+}
+
+func TestStreamErrorChunk(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data:{\"candidates\": [{\"content\": {\"role\": \"model\", \"parts\": [{\"text\": \"a\"}]}}]}\n\n")
+		fmt.Fprint(w, "data:{\"error\": {\"code\": 429, \"message\": \"quota\", \"status\": \"RESOURCE_EXHAUSTED\"}}\n\n")
+		fmt.Fprint(w, "data:{\"candidates\": [{\"content\": {\"role\": \"model\", \"parts\": [{\"text\": \"b\"}]}}]}\n\n")
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts, nil)
+
+	var texts []string
+	var gotErr error
+	for resp, err := range client.Models.GenerateContentStream(context.Background(), "gemini-2.0-flash", Text("hello"), nil) {
+		if err != nil {
+			gotErr = err
+			continue
+		}
+		texts = append(texts, resp.Text())
+	}
+	if diff := cmp.Diff([]string{"a"}, texts); diff != "" {
+		t.Errorf("stream mismatch (-want +got):\n%s", diff)
+	}
+	if !errors.Is(gotErr, ErrRateLimited) {
+		t.Errorf("stream error = %v, want %v", gotErr, ErrRateLimited)
+	}
+}
+
+func TestUploadErrorClass(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Goog-Upload-Command") == "start" {
+			w.Header().Set("X-Goog-Upload-URL", "http://"+r.Host+"/upload")
+			fmt.Fprint(w, `{}`)
+			return
+		}
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, "upload session expired")
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts, nil)
+
+	_, err := client.Files.Upload(context.Background(), bytes.NewReader([]byte("hello")), &UploadFileConfig{MIMEType: "text/plain"})
+	if !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Upload() error = %v, want %v", err, ErrPermissionDenied)
+	}
+}
+
+func TestLiveErrorClasses(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rejected handshake", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"error": {"code": 403, "message": "API key not valid", "status": "PERMISSION_DENIED"}}`)
+		}))
+		defer ts.Close()
+		client := newTestClient(t, ts, nil)
+		client.Live.apiClient.clientConfig.HTTPOptions.BaseURL = strings.Replace(ts.URL, "http", "ws", 1)
+
+		_, err := client.Live.Connect(ctx, "test-model", nil)
+		if !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("Connect() error = %v, want %v", err, ErrPermissionDenied)
+		}
+	})
+
+	tests := []struct {
+		desc  string
+		reply func(conn *websocket.Conn)
+		want  error
+	}{
+		{
+			desc: "error message",
+			reply: func(conn *websocket.Conn) {
+				conn.WriteMessage(websocket.TextMessage, []byte(`{"error": {"code": 400, "message": "bad setup", "status": "INVALID_ARGUMENT"}}`))
+			},
+			want: ErrInvalidArgument,
+		},
+		{
+			desc: "close frame",
+			reply: func(conn *websocket.Conn) {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "try again later"))
+			},
+			want: ErrUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			upgrader := websocket.Upgrader{}
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, err := upgrader.Upgrade(w, r, nil)
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				conn.ReadMessage()
+				tt.reply(conn)
+			}))
+			defer ts.Close()
+			client := newTestClient(t, ts, nil)
+			client.Live.apiClient.clientConfig.HTTPOptions.BaseURL = strings.Replace(ts.URL, "http", "ws", 1)
+
+			session, err := client.Live.Connect(ctx, "test-model", nil)
+			if err != nil {
+				t.Fatalf("Connect() failed: %v", err)
+			}
+			defer session.Close()
+			if _, err := session.Receive(); !errors.Is(err, tt.want) {
+				t.Errorf("Receive() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
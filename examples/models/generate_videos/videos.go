@@ -49,17 +49,23 @@ func run(ctx context.Context) {
 		log.Fatal(err)
 	}
 
-	for !operation.Done {
-		fmt.Println("Waiting for operation to complete...")
-		time.Sleep(20 * time.Second)
-		operation, err = client.Operations.GetVideosOperation(ctx, operation, nil)
-		if err != nil {
-			log.Fatal(err)
-		}
+	// Wait for the operation to complete.
+	op, err := client.Operations.VideosOperation(operation)
+	if err != nil {
+		log.Fatal(err)
+	}
+	result, err := op.Wait(ctx, &genai.OperationWaitConfig{
+		InitialDelay: 20 * time.Second,
+		Progress: func(map[string]any) {
+			fmt.Println("Waiting for operation to complete...")
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
 	}
 
 	// Marshal the result to JSON and pretty-print it to a byte array.
-	response, err := json.MarshalIndent(*operation, "", "  ")
+	response, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -68,7 +74,7 @@ func run(ctx context.Context) {
 
 	// Download the video file.
 	if client.ClientConfig().Backend != genai.BackendVertexAI {
-		for _, v := range operation.Response.GeneratedVideos {
+		for _, v := range result.GeneratedVideos {
 			data, err := client.Files.Download(ctx, genai.NewDownloadURIFromGeneratedVideo(v), nil)
 			if err != nil {
 				log.Println(err)
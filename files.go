@@ -25,8 +25,6 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
-	"strconv"
-	"strings"
 )
 
 func listFilesConfigToMldev(ac *apiClient, fromObject map[string]any, parentObject map[string]any) (toObject map[string]any, err error) {
@@ -370,7 +368,7 @@ type Files struct {
 	apiClient *apiClient
 }
 
-func (m Files) list(ctx context.Context, config *ListFilesConfig) (*ListFilesResponse, error) {
+func (m Files) listFiles(ctx context.Context, config *ListFilesConfig) (*ListFilesResponse, error) {
 	parameterMap := make(map[string]any)
 
 	kwargs := map[string]any{"config": config}
@@ -512,7 +510,7 @@ func (m Files) create(ctx context.Context, file *File, config *CreateFileConfig)
 	return response, nil
 }
 
-func (m Files) Get(ctx context.Context, name string, config *GetFileConfig) (*File, error) {
+func (m Files) getFile(ctx context.Context, name string, config *GetFileConfig) (*File, error) {
 	parameterMap := make(map[string]any)
 
 	kwargs := map[string]any{"name": name, "config": config}
@@ -583,7 +581,7 @@ func (m Files) Get(ctx context.Context, name string, config *GetFileConfig) (*Fi
 	return response, nil
 }
 
-func (m Files) Delete(ctx context.Context, name string, config *DeleteFileConfig) (*DeleteFileResponse, error) {
+func (m Files) deleteFile(ctx context.Context, name string, config *DeleteFileConfig) (*DeleteFileResponse, error) {
 	parameterMap := make(map[string]any)
 
 	kwargs := map[string]any{"name": name, "config": config}
@@ -698,85 +696,32 @@ func (m Files) All(ctx context.Context) iter.Seq2[*File, error] {
 }
 
 // Download function downloads a file from the specified URI.
-// If the URI refers to a video([Video], [GeneratedVideo]), the video bytes will be populated to the video's VideoBytes field.
+// If the URI refers to a video([Video], [GeneratedVideo]), the video bytes will be populated to the video's VideoBytes field,
+// unless DownloadFileConfig.SkipVideoBytes is set. To download large files without holding them in memory, use [Files.DownloadTo].
 func (m Files) Download(ctx context.Context, uri DownloadURI, config *DownloadFileConfig) ([]byte, error) {
-	if m.apiClient.clientConfig.Backend == BackendVertexAI {
-		return nil, fmt.Errorf("method Download is only supported in the Gemini Developer client. You can choose to use Gemini Developer client by setting ClientConfig.Backend to BackendGeminiAPI.")
-	}
-	if uri.uri() == "" {
-		return nil, fmt.Errorf("the resource doesn't support download")
-	}
-	fileName, err := tFileName(m.apiClient, uri.uri())
-	if err != nil {
-		return nil, err
-	}
-	path := fmt.Sprintf("files/%s:download?alt=media", fileName)
-
-	var httpOptions *HTTPOptions
-	if config == nil {
-		httpOptions = mergeHTTPOptions(m.apiClient.clientConfig, nil)
-	} else {
-		httpOptions = mergeHTTPOptions(m.apiClient.clientConfig, config.HTTPOptions)
-		config.HTTPOptions = nil
-	}
-
-	data, err := downloadFile(ctx, m.apiClient, path, httpOptions)
-	if err != nil {
-		return nil, err
-	}
-	_ = uri.setVideoBytes(data)
-	return data, nil
+	return m.download(ctx, uri, config)
 }
 
 // Upload copies the contents of the given io.Reader to file storage associated
 // with the service, and returns information about the resulting file.
+//
+// The file is sent in chunks. When a chunk fails, the upload resumes from the
+// offset that the server committed; see [UploadFileConfig]. When ctx is
+// cancelled, the upload session is cancelled. To resume an upload after a
+// process restart, use [Files.CreateUploadSession] and [Files.ResumeUpload]
+// instead. With UploadFileConfig.DedupIndex, a file with the same contents that
+// was already uploaded is returned instead.
+//
+// On the Vertex AI backend, the file is written to the Cloud Storage location of
+// ClientConfig.FileStorage in a single request, which is not resumed if it
+// fails, and the URI of the returned file is a gs:// URI.
 func (m Files) Upload(ctx context.Context, r io.Reader, config *UploadFileConfig) (*File, error) {
-	if m.apiClient.clientConfig.Backend == BackendVertexAI {
-		return nil, fmt.Errorf("This method is only supported in the Gemini Developer client.")
-	}
-
-	var fileToUpload File
-	if config != nil {
-		fileToUpload.MIMEType = config.MIMEType
-		fileToUpload.Name = config.Name
-		fileToUpload.DisplayName = config.DisplayName
-	}
-
-	if fileToUpload.Name != "" && !strings.HasPrefix(fileToUpload.Name, "files/") {
-		fileToUpload.Name = "files/" + fileToUpload.Name
-	}
-
-	httpOptions := HTTPOptions{Headers: http.Header{}}
-	if config != nil && config.HTTPOptions != nil {
-		httpOptions = *config.HTTPOptions
-	}
-	if httpOptions.Headers == nil {
-		httpOptions.Headers = http.Header{}
-	}
-
-	httpOptions.APIVersion = ""
-	httpOptions.Headers.Add("Content-Type", "application/json")
-	httpOptions.Headers.Add("X-Goog-Upload-Protocol", "resumable")
-	httpOptions.Headers.Add("X-Goog-Upload-Command", "start")
-	httpOptions.Headers.Add("X-Goog-Upload-Header-Content-Type", fileToUpload.MIMEType)
-
-	var createFileConfig CreateFileConfig
-	createFileConfig.HTTPOptions = &httpOptions
-
-	resp, err := m.create(ctx, &fileToUpload, &createFileConfig)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to create file. Ran into an error: %s", err)
-	}
-	if resp.HTTPHeaders == nil || resp.HTTPHeaders.Get("x-goog-upload-url") == "" {
-		return nil, fmt.Errorf("Failed to create file. Upload URL was not returned from the create file request.")
-	}
-
-	uploadURL := resp.HTTPHeaders.Get("x-goog-upload-url")
-	return m.apiClient.uploadFile(ctx, r, uploadURL, &httpOptions)
+	return m.upload(ctx, r, config)
 }
 
 // UploadFromPath uploads a file from the specified path and returns information
-// about the resulting file.
+// about the resulting file. If the config has no MIME type, it is detected from
+// the extension of the path, or else from the contents of the file.
 func (m Files) UploadFromPath(ctx context.Context, path string, config *UploadFileConfig) (*File, error) {
 	fileInfo, err := os.Stat(path)
 	if err != nil || fileInfo.IsDir() {
@@ -789,24 +734,21 @@ func (m Files) UploadFromPath(ctx context.Context, path string, config *UploadFi
 	}
 	defer osf.Close()
 
-	if config == nil {
-		config = &UploadFileConfig{}
-	}
-
-	if config.MIMEType == "" {
-		config.MIMEType = mime.TypeByExtension(filepath.Ext(path))
-		if config.MIMEType == "" {
-			return nil, fmt.Errorf("Unknown mime type: Could not determine the mimetype for your file please set the `MIMEType` argument")
+	// Copy the config, so that concurrent uploads can share it.
+	var c UploadFileConfig
+	if config != nil {
+		c = *config
+	}
+	if c.MIMEType == "" {
+		c.MIMEType = mime.TypeByExtension(filepath.Ext(path))
+		if c.MIMEType == "" {
+			c.MIMEType, _, err = sniffMIMEType(osf)
+			if err != nil {
+				return nil, fmt.Errorf("UploadFromPath: failed to detect the MIME type of %s: %w", path, err)
+			}
 		}
 	}
+	c.HTTPOptions = withUploadSize(c.HTTPOptions, fileInfo.Size())
 
-	if config.HTTPOptions == nil {
-		config.HTTPOptions = &HTTPOptions{Headers: http.Header{}}
-	}
-	if config.HTTPOptions.Headers == nil {
-		config.HTTPOptions.Headers = http.Header{}
-	}
-	config.HTTPOptions.Headers.Add("X-Goog-Upload-Header-Content-Length", strconv.FormatInt(fileInfo.Size(), 10))
-
-	return m.Upload(ctx, osf, config)
+	return m.Upload(ctx, osf, &c)
 }
@@ -0,0 +1,368 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const defaultUploadBatchConcurrency = 4
+
+// UploadBatchItem is a file of a batch upload, read from either Path or Reader.
+type UploadBatchItem struct {
+	// Path of the file to upload.
+	Path string
+	// Reader of the contents of the file to upload, if Path is empty. The MIME
+	// type is sniffed from the contents if Config has none.
+	Reader io.Reader
+	// Optional. Config of the upload of this file. Defaults to
+	// UploadBatchConfig.FileConfig.
+	Config *UploadFileConfig
+}
+
+// UploadBatchProgress is the aggregated progress of a batch upload.
+type UploadBatchProgress struct {
+	// UploadedBytes is the number of bytes that the server committed, over all
+	// files.
+	UploadedBytes int64
+	// TotalBytes is the size of all files, or 0 if the size of a reader is
+	// unknown.
+	TotalBytes int64
+	// CompletedFiles is the number of files whose upload ended, successfully or
+	// not.
+	CompletedFiles int
+	// TotalFiles is the number of files of the batch.
+	TotalFiles int
+}
+
+// UploadBatchConfig configures [Files.UploadBatch] and [Files.UploadDir].
+type UploadBatchConfig struct {
+	// Optional. Config of the uploads of the files that have no config of their
+	// own. Its Progress is called for each file.
+	FileConfig *UploadFileConfig
+	// Optional. Maximum number of files uploaded at the same time. Defaults to 4.
+	Concurrency int
+	// Optional. Called with the aggregated progress of the batch after each chunk
+	// and after each file. Calls are serialized.
+	Progress func(UploadBatchProgress)
+}
+
+// UploadFailure is the failure of a file of a batch upload.
+type UploadFailure struct {
+	// Index of the file in the batch.
+	Index int
+	// Path of the file, if it was read from a path.
+	Path string
+	// Err is the error of the upload.
+	Err error
+}
+
+// UploadBatchError is returned by a batch upload when some of its files fail to
+// upload. It matches the errors of the files with errors.Is and errors.As.
+type UploadBatchError struct {
+	// Failures are the failed files, in the order of the batch.
+	Failures []UploadFailure
+	// TotalFiles is the number of files of the batch.
+	TotalFiles int
+}
+
+// Error returns a string representation of the UploadBatchError.
+func (e *UploadBatchError) Error() string {
+	first := e.Failures[0]
+	source := first.Path
+	if source == "" {
+		source = fmt.Sprintf("file %d", first.Index)
+	}
+	return fmt.Sprintf("%d of %d files failed to upload; %s: %v", len(e.Failures), e.TotalFiles, source, first.Err)
+}
+
+// Unwrap returns the errors of the failed files.
+func (e *UploadBatchError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+// UploadBatch uploads the files of the batch, with up to config.Concurrency
+// uploads at the same time, and returns the parts that refer to the uploaded
+// files, in the order of items. If some files fail to upload, their parts are
+// nil and the returned error is an *UploadBatchError. Files that didn't start
+// uploading when ctx is done fail with the context error.
+func (m Files) UploadBatch(ctx context.Context, items []UploadBatchItem, config *UploadBatchConfig) ([]*Part, error) {
+	ctx = withOperation(ctx, "Files.UploadBatch")
+	if config == nil {
+		config = &UploadBatchConfig{}
+	}
+	concurrency := config.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultUploadBatchConcurrency
+	}
+
+	b := &uploadBatch{
+		config:   config,
+		parts:    make([]*Part, len(items)),
+		errs:     make([]error, len(items)),
+		uploaded: make([]int64, len(items)),
+		progress: UploadBatchProgress{TotalFiles: len(items)},
+	}
+	b.progress.TotalBytes = uploadBatchSize(items)
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(concurrency, len(items)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				var part *Part
+				err := ctx.Err()
+				if err == nil {
+					part, err = m.uploadBatchItem(ctx, items[i], b.fileConfig(i, items[i]))
+				}
+				b.done(i, part, err)
+			}
+		}()
+	}
+	for i := range items {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var failures []UploadFailure
+	for i, err := range b.errs {
+		if err != nil {
+			failures = append(failures, UploadFailure{Index: i, Path: items[i].Path, Err: err})
+		}
+	}
+	if len(failures) > 0 {
+		return b.parts, &UploadBatchError{Failures: failures, TotalFiles: len(items)}
+	}
+	return b.parts, nil
+}
+
+// UploadDir uploads the regular files of the directory tree rooted at dir, as
+// [Files.UploadBatch] does, and returns their parts in lexical order of their
+// paths. Hidden files and directories, whose names start with ".", are skipped.
+// Unless config.FileConfig sets one, the display name of each file is its path
+// relative to dir, with forward slashes.
+func (m Files) UploadDir(ctx context.Context, dir string, config *UploadBatchConfig) ([]*Part, error) {
+	var fileConfig UploadFileConfig
+	if config != nil && config.FileConfig != nil {
+		fileConfig = *config.FileConfig
+	}
+	var items []UploadBatchItem
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		c := fileConfig
+		if c.DisplayName == "" {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			c.DisplayName = filepath.ToSlash(rel)
+		}
+		items = append(items, UploadBatchItem{Path: path, Config: &c})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("UploadDir: %w", err)
+	}
+	return m.UploadBatch(ctx, items, config)
+}
+
+func (m Files) uploadBatchItem(ctx context.Context, item UploadBatchItem, config *UploadFileConfig) (*Part, error) {
+	var (
+		file *File
+		err  error
+	)
+	if item.Path != "" {
+		file, err = m.UploadFromPath(ctx, item.Path, config)
+	} else if item.Reader != nil {
+		r := item.Reader
+		if config.MIMEType == "" {
+			config.MIMEType, r, err = sniffMIMEType(r)
+			if err != nil {
+				return nil, fmt.Errorf("UploadBatch: failed to detect the MIME type: %w", err)
+			}
+		}
+		if size, ok := readerSize(r); ok {
+			config.HTTPOptions = withUploadSize(config.HTTPOptions, size)
+		}
+		file, err = m.Upload(ctx, r, config)
+	} else {
+		return nil, fmt.Errorf("UploadBatch: item has neither a path nor a reader")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewPartFromURI(file.URI, cmp.Or(file.MIMEType, config.MIMEType)), nil
+}
+
+// uploadBatch holds the state of a batch upload shared by its workers.
+type uploadBatch struct {
+	config *UploadBatchConfig
+
+	mu       sync.Mutex
+	parts    []*Part
+	errs     []error
+	uploaded []int64
+	progress UploadBatchProgress
+}
+
+// fileConfig returns a copy of the config of the upload of item i, whose
+// progress is reported to the batch.
+func (b *uploadBatch) fileConfig(i int, item UploadBatchItem) *UploadFileConfig {
+	var c UploadFileConfig
+	if item.Config != nil {
+		c = *item.Config
+	} else if b.config.FileConfig != nil {
+		c = *b.config.FileConfig
+	}
+	fileProgress := c.Progress
+	c.Progress = func(p UploadProgress) {
+		if fileProgress != nil {
+			fileProgress(p)
+		}
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.progress.UploadedBytes += p.UploadedBytes - b.uploaded[i]
+		b.uploaded[i] = p.UploadedBytes
+		b.reportProgress()
+	}
+	return &c
+}
+
+func (b *uploadBatch) done(i int, part *Part, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.parts[i] = part
+	b.errs[i] = err
+	b.progress.CompletedFiles++
+	b.reportProgress()
+}
+
+// reportProgress calls the progress callback. The caller must hold b.mu.
+func (b *uploadBatch) reportProgress() {
+	if b.config.Progress != nil {
+		b.config.Progress(b.progress)
+	}
+}
+
+// uploadBatchSize returns the total size of the items, or 0 if the size of one
+// of them is unknown.
+func uploadBatchSize(items []UploadBatchItem) int64 {
+	var total int64
+	for _, item := range items {
+		if item.Path != "" {
+			info, err := os.Stat(item.Path)
+			if err != nil {
+				return 0
+			}
+			total += info.Size()
+			continue
+		}
+		size, ok := readerSize(item.Reader)
+		if !ok {
+			return 0
+		}
+		total += size
+	}
+	return total
+}
+
+// readerSize returns the number of bytes left in r, if r is an io.Seeker.
+func readerSize(r io.Reader) (int64, bool) {
+	s, ok := r.(io.Seeker)
+	if !ok {
+		return 0, false
+	}
+	offset, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, false
+	}
+	end, err := s.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, false
+	}
+	if _, err := s.Seek(offset, io.SeekStart); err != nil {
+		return 0, false
+	}
+	return end - offset, true
+}
+
+// sniffMIMEType detects the MIME type of the contents of r from their first
+// bytes, with http.DetectContentType, and returns a reader of the whole
+// contents. If r is an io.Seeker, it is rewound and returned.
+func sniffMIMEType(r io.Reader) (string, io.Reader, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", nil, err
+	}
+	head = head[:n]
+	mimeType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		mimeType = "application/octet-stream"
+	}
+	if s, ok := r.(io.Seeker); ok {
+		if _, err := s.Seek(int64(-n), io.SeekCurrent); err != nil {
+			return "", nil, err
+		}
+		return mimeType, r, nil
+	}
+	return mimeType, io.MultiReader(bytes.NewReader(head), r), nil
+}
+
+// withUploadSize returns a copy of httpOptions with the header that announces
+// the size of an uploaded file.
+func withUploadSize(httpOptions *HTTPOptions, size int64) *HTTPOptions {
+	var o HTTPOptions
+	if httpOptions != nil {
+		o = *httpOptions
+	}
+	o.Headers = o.Headers.Clone()
+	if o.Headers == nil {
+		o.Headers = http.Header{}
+	}
+	o.Headers.Set("X-Goog-Upload-Header-Content-Length", strconv.FormatInt(size, 10))
+	return &o
+}
@@ -0,0 +1,97 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFilesUploadDir(t *testing.T) {
+	server := &dedupServer{}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	client := newTestClient(t, ts, nil)
+
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"notes.txt":           "some notes",
+		"docs/report":         "%PDF-1.7 report",
+		".cache/ignored.txt":  "hidden",
+		"docs/.ignored.txt":   "hidden",
+		"docs/sub/readme.txt": "read me",
+	} {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var last UploadBatchProgress
+	parts, err := client.Files.UploadDir(context.Background(), dir, &UploadBatchConfig{
+		Concurrency: 2,
+		Progress:    func(p UploadBatchProgress) { last = p },
+	})
+	if err != nil {
+		t.Fatalf("UploadDir() failed: %v", err)
+	}
+	var got []string
+	for _, part := range parts {
+		got = append(got, part.FileData.MIMEType)
+	}
+	// Parts are in lexical order: docs/report, docs/sub/readme.txt, notes.txt.
+	if want := []string{"application/pdf", "text/plain; charset=utf-8", "text/plain; charset=utf-8"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("UploadDir() MIME types = %q, want %q", got, want)
+	}
+	total := int64(len("some notes") + len("%PDF-1.7 report") + len("read me"))
+	if want := (UploadBatchProgress{UploadedBytes: total, TotalBytes: total, CompletedFiles: 3, TotalFiles: 3}); last != want {
+		t.Errorf("last progress = %+v, want %+v", last, want)
+	}
+}
+
+func TestFilesUploadBatchPartialFailure(t *testing.T) {
+	server := &dedupServer{rejected: "bad"}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	client := newTestClient(t, ts, nil)
+
+	items := []UploadBatchItem{
+		{Reader: strings.NewReader("good")},
+		{Reader: strings.NewReader("bad")},
+		{Reader: strings.NewReader("<html>good</html>")},
+	}
+	parts, err := client.Files.UploadBatch(context.Background(), items, nil)
+	var batchErr *UploadBatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("UploadBatch() error = %v, want *UploadBatchError", err)
+	}
+	if len(batchErr.Failures) != 1 || batchErr.Failures[0].Index != 1 || !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("UploadBatch() failures = %+v, want the second item with ErrInvalidArgument", batchErr.Failures)
+	}
+	if len(parts) != 3 || parts[0] == nil || parts[1] != nil || parts[2] == nil {
+		t.Fatalf("UploadBatch() = %v, want parts for the first and last items", parts)
+	}
+	if got := parts[2].FileData.MIMEType; got != "text/html" {
+		t.Errorf("UploadBatch() MIME type of the last item = %q, want text/html", got)
+	}
+}
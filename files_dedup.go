@@ -0,0 +1,244 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dedupExpirationMargin is the minimum remaining lifetime of a file for an
+// upload to reuse it, so that it doesn't expire while the caller uses it.
+const dedupExpirationMargin = 5 * time.Minute
+
+// FileIndexEntry records an uploaded file by the SHA-256 hash of its contents.
+type FileIndexEntry struct {
+	// SHA256 is the hex encoded SHA-256 hash of the contents of the file.
+	SHA256 string `json:"sha256"`
+	// Name is the name of the file, such as "files/abc-123".
+	Name string `json:"name"`
+	// ExpirationTime is the time when the file is deleted by the service.
+	ExpirationTime time.Time `json:"expirationTime"`
+}
+
+// FileIndex maps the SHA-256 hashes of file contents to uploaded files, so that
+// uploads with UploadFileConfig.DedupIndex reuse them. Implementations must be
+// safe for concurrent use, and Lookup must return an error that matches
+// [ErrNotFound] with errors.Is for unknown hashes.
+type FileIndex interface {
+	// Lookup returns the entry of the hex encoded SHA-256 hash.
+	Lookup(ctx context.Context, sha256 string) (*FileIndexEntry, error)
+	// Store creates or replaces the entry of entry.SHA256.
+	Store(ctx context.Context, entry *FileIndexEntry) error
+	// Remove removes the entry of the hash, if any.
+	Remove(ctx context.Context, sha256 string) error
+}
+
+// MemoryFileIndex is a [FileIndex] that keeps its entries in memory.
+type MemoryFileIndex struct {
+	mu      sync.Mutex
+	entries map[string]FileIndexEntry
+}
+
+// NewMemoryFileIndex creates an empty MemoryFileIndex.
+func NewMemoryFileIndex() *MemoryFileIndex {
+	return &MemoryFileIndex{entries: make(map[string]FileIndexEntry)}
+}
+
+// Lookup implements [FileIndex].
+func (x *MemoryFileIndex) Lookup(ctx context.Context, sha256 string) (*FileIndexEntry, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	entry, ok := x.entries[sha256]
+	if !ok {
+		return nil, fmt.Errorf("file with SHA-256 %s: %w", sha256, ErrNotFound)
+	}
+	return &entry, nil
+}
+
+// Store implements [FileIndex].
+func (x *MemoryFileIndex) Store(ctx context.Context, entry *FileIndexEntry) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.entries[entry.SHA256] = *entry
+	return nil
+}
+
+// Remove implements [FileIndex].
+func (x *MemoryFileIndex) Remove(ctx context.Context, sha256 string) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	delete(x.entries, sha256)
+	return nil
+}
+
+// JSONFileIndex is a [FileIndex] that keeps its entries in a JSON file, so that
+// they survive process restarts. The file is rewritten atomically on each
+// change. It is not safe to share the file between processes.
+type JSONFileIndex struct {
+	path string
+
+	mu     sync.Mutex
+	memory *MemoryFileIndex
+}
+
+// NewJSONFileIndex creates a JSONFileIndex backed by the file at path, loading
+// its entries if the file exists.
+func NewJSONFileIndex(path string) (*JSONFileIndex, error) {
+	x := &JSONFileIndex{path: path, memory: NewMemoryFileIndex()}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return x, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("NewJSONFileIndex: %w", err)
+	}
+	var entries []FileIndexEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("NewJSONFileIndex: failed to decode %s: %w", path, err)
+	}
+	for _, entry := range entries {
+		x.memory.entries[entry.SHA256] = entry
+	}
+	return x, nil
+}
+
+// Lookup implements [FileIndex].
+func (x *JSONFileIndex) Lookup(ctx context.Context, sha256 string) (*FileIndexEntry, error) {
+	return x.memory.Lookup(ctx, sha256)
+}
+
+// Store implements [FileIndex].
+func (x *JSONFileIndex) Store(ctx context.Context, entry *FileIndexEntry) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.memory.Store(ctx, entry)
+	return x.save()
+}
+
+// Remove implements [FileIndex].
+func (x *JSONFileIndex) Remove(ctx context.Context, sha256 string) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.memory.Remove(ctx, sha256)
+	return x.save()
+}
+
+// save writes the entries to the file. The caller must hold x.mu.
+func (x *JSONFileIndex) save() error {
+	x.memory.mu.Lock()
+	entries := make([]FileIndexEntry, 0, len(x.memory.entries))
+	for _, entry := range x.memory.entries {
+		entries = append(entries, entry)
+	}
+	x.memory.mu.Unlock()
+	slices.SortFunc(entries, func(a, b FileIndexEntry) int { return strings.Compare(a.SHA256, b.SHA256) })
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(x.path), "."+filepath.Base(x.path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), x.path)
+}
+
+// dedupUpload returns a non-expired file with the same contents as r, from
+// config.DedupIndex or from the files of the project. Otherwise, it returns nil
+// and the hex encoded SHA-256 hash of r, and rewinds r for the upload.
+func (m Files) dedupUpload(ctx context.Context, r io.Reader, config *UploadFileConfig) (*File, string, error) {
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		return nil, "", fmt.Errorf("Upload: UploadFileConfig.DedupIndex requires a reader that implements io.Seeker")
+	}
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, "", fmt.Errorf("Upload: %w", err)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, "", fmt.Errorf("Upload: failed to hash the file: %w", err)
+	}
+	if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+		return nil, "", fmt.Errorf("Upload: %w", err)
+	}
+	digest := h.Sum(nil)
+	sum := hex.EncodeToString(digest)
+	index := config.DedupIndex
+
+	entry, err := index.Lookup(ctx, sum)
+	switch {
+	case err == nil:
+		if reusableUntil(entry.ExpirationTime) {
+			file, err := m.Get(ctx, entry.Name, nil)
+			if err == nil && reusableFile(file) {
+				return file, sum, nil
+			}
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				return nil, "", fmt.Errorf("Upload: %w", err)
+			}
+		}
+		// The file expired or was deleted.
+		if err := index.Remove(ctx, sum); err != nil {
+			return nil, "", fmt.Errorf("Upload: %w", err)
+		}
+	case !errors.Is(err, ErrNotFound):
+		return nil, "", fmt.Errorf("Upload: %w", err)
+	}
+
+	for file, err := range m.All(ctx) {
+		if err != nil {
+			return nil, "", fmt.Errorf("Upload: failed to list files: %w", err)
+		}
+		if want, ok := decodeSHA256(file.Sha256Hash); ok && bytes.Equal(want, digest) && reusableFile(file) {
+			if err := index.Store(ctx, &FileIndexEntry{SHA256: sum, Name: file.Name, ExpirationTime: file.ExpirationTime}); err != nil {
+				return nil, "", fmt.Errorf("Upload: %w", err)
+			}
+			return file, sum, nil
+		}
+	}
+	return nil, sum, nil
+}
+
+func reusableFile(file *File) bool {
+	return file.State != FileStateFailed && reusableUntil(file.ExpirationTime)
+}
+
+func reusableUntil(expirationTime time.Time) bool {
+	return expirationTime.IsZero() || time.Until(expirationTime) > dedupExpirationMargin
+}
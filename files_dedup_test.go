@@ -0,0 +1,193 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// dedupServer serves Files.List, Files.Get and single-chunk uploads, which add
+// a file with the SHA-256 hash of the uploaded bytes to the served files.
+type dedupServer struct {
+	mu       sync.Mutex
+	files    []*File
+	uploads  int
+	sessions []File
+	// rejected are contents whose upload fails.
+	rejected string
+}
+
+func (s *dedupServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch command := r.Header.Get("X-Goog-Upload-Command"); {
+	case command == "start":
+		var req struct{ File File }
+		json.NewDecoder(r.Body).Decode(&req)
+		s.sessions = append(s.sessions, req.File)
+		w.Header().Set("X-Goog-Upload-URL", fmt.Sprintf("http://%s/session/%d", r.Host, len(s.sessions)-1))
+	case command != "":
+		body, _ := io.ReadAll(r.Body)
+		if s.rejected != "" && string(body) == s.rejected {
+			http.Error(w, `{"error": {"code": 400, "message": "rejected", "status": "INVALID_ARGUMENT"}}`, http.StatusBadRequest)
+			return
+		}
+		session, _ := strconv.Atoi(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+		sum := sha256.Sum256(body)
+		s.uploads++
+		file := &File{
+			Name:           fmt.Sprintf("files/uploaded-%d", s.uploads),
+			URI:            fmt.Sprintf("http://%s/v1beta/files/uploaded-%d", r.Host, s.uploads),
+			MIMEType:       s.sessions[session].MIMEType,
+			Sha256Hash:     base64.StdEncoding.EncodeToString(sum[:]),
+			ExpirationTime: time.Now().Add(48 * time.Hour).UTC(),
+			State:          FileStateActive,
+		}
+		s.files = append(s.files, file)
+		w.Header().Set("X-Goog-Upload-Status", "final")
+		json.NewEncoder(w).Encode(map[string]any{"file": file})
+	case strings.HasSuffix(r.URL.Path, "/files"):
+		json.NewEncoder(w).Encode(map[string]any{"files": s.files})
+	default:
+		name := "files/" + r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		for _, file := range s.files {
+			if file.Name == name {
+				json.NewEncoder(w).Encode(file)
+				return
+			}
+		}
+		http.Error(w, `{"error": {"code": 404, "message": "not found", "status": "NOT_FOUND"}}`, http.StatusNotFound)
+	}
+}
+
+func TestFilesUploadDedup(t *testing.T) {
+	ctx := context.Background()
+	content := "the same video"
+	sum := sha256.Sum256([]byte(content))
+	hash := base64.StdEncoding.EncodeToString(sum[:])
+
+	newClient := func(t *testing.T, files ...*File) (*Client, *dedupServer) {
+		server := &dedupServer{files: files}
+		ts := httptest.NewServer(server)
+		t.Cleanup(ts.Close)
+		return newTestClient(t, ts, nil), server
+	}
+	upload := func(t *testing.T, client *Client, index FileIndex) *File {
+		t.Helper()
+		file, err := client.Files.Upload(ctx, strings.NewReader(content), &UploadFileConfig{MIMEType: "video/mp4", DedupIndex: index})
+		if err != nil {
+			t.Fatalf("Upload() failed: %v", err)
+		}
+		return file
+	}
+
+	t.Run("index", func(t *testing.T) {
+		client, server := newClient(t)
+		index := NewMemoryFileIndex()
+		first := upload(t, client, index)
+		second := upload(t, client, index)
+		if first.Name != "files/uploaded-1" || second.Name != first.Name || server.uploads != 1 {
+			t.Errorf("Upload() = %s then %s after %d uploads, want files/uploaded-1 twice after 1", first.Name, second.Name, server.uploads)
+		}
+		entry, err := index.Lookup(ctx, fmt.Sprintf("%x", sum))
+		if err != nil || entry.Name != first.Name || !entry.ExpirationTime.Equal(first.ExpirationTime) {
+			t.Errorf("Lookup() = %+v, %v, want the entry of %s", entry, err, first.Name)
+		}
+	})
+
+	t.Run("existing file", func(t *testing.T) {
+		existing := &File{Name: "files/existing", Sha256Hash: hash, ExpirationTime: time.Now().Add(time.Hour).UTC()}
+		client, server := newClient(t, &File{Name: "files/other", Sha256Hash: "b3RoZXI="}, existing)
+		index := NewMemoryFileIndex()
+		if file := upload(t, client, index); file.Name != existing.Name || server.uploads != 0 {
+			t.Errorf("Upload() = %s after %d uploads, want %s after none", file.Name, server.uploads, existing.Name)
+		}
+		if _, err := index.Lookup(ctx, fmt.Sprintf("%x", sum)); err != nil {
+			t.Errorf("Lookup() of the existing file failed: %v", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		expiring := &File{Name: "files/expiring", Sha256Hash: hash, ExpirationTime: time.Now().Add(time.Minute).UTC()}
+		client, server := newClient(t, expiring)
+		index := NewMemoryFileIndex()
+		index.Store(ctx, &FileIndexEntry{SHA256: fmt.Sprintf("%x", sum), Name: expiring.Name, ExpirationTime: expiring.ExpirationTime})
+		if file := upload(t, client, index); file.Name != "files/uploaded-1" || server.uploads != 1 {
+			t.Errorf("Upload() = %s after %d uploads, want files/uploaded-1 after 1", file.Name, server.uploads)
+		}
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		client, server := newClient(t)
+		index := NewMemoryFileIndex()
+		index.Store(ctx, &FileIndexEntry{SHA256: fmt.Sprintf("%x", sum), Name: "files/deleted"})
+		if file := upload(t, client, index); file.Name != "files/uploaded-1" || server.uploads != 1 {
+			t.Errorf("Upload() = %s after %d uploads, want files/uploaded-1 after 1", file.Name, server.uploads)
+		}
+	})
+
+	t.Run("not seekable", func(t *testing.T) {
+		client, _ := newClient(t)
+		_, err := client.Files.Upload(ctx, io.MultiReader(strings.NewReader(content)), &UploadFileConfig{MIMEType: "video/mp4", DedupIndex: NewMemoryFileIndex()})
+		if err == nil {
+			t.Error("Upload() of a reader without Seek succeeded, want error")
+		}
+	})
+}
+
+func TestJSONFileIndex(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "index.json")
+	index, err := NewJSONFileIndex(path)
+	if err != nil {
+		t.Fatalf("NewJSONFileIndex() failed: %v", err)
+	}
+	entry := &FileIndexEntry{SHA256: "abc", Name: "files/abc", ExpirationTime: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)}
+	if err := index.Store(ctx, entry); err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	if err := index.Store(ctx, &FileIndexEntry{SHA256: "def", Name: "files/def"}); err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	if err := index.Remove(ctx, "def"); err != nil {
+		t.Fatalf("Remove() failed: %v", err)
+	}
+
+	reloaded, err := NewJSONFileIndex(path)
+	if err != nil {
+		t.Fatalf("NewJSONFileIndex() of an existing file failed: %v", err)
+	}
+	got, err := reloaded.Lookup(ctx, "abc")
+	if err != nil || got.Name != entry.Name || !got.ExpirationTime.Equal(entry.ExpirationTime) {
+		t.Errorf("Lookup() = %+v, %v, want %+v", got, err, entry)
+	}
+	if _, err := reloaded.Lookup(ctx, "def"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Lookup() of a removed entry error = %v, want ErrNotFound", err)
+	}
+}
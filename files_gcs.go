@@ -0,0 +1,424 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultStorageEndpoint = "https://storage.googleapis.com"
+
+// FileStorageConfig is the Cloud Storage location where the Files service
+// stores files on the Vertex AI backend. A file named "files/{id}" is stored in
+// the object "{Prefix}{id}" of the bucket, and its URI is the gs:// URI of the
+// object, which can be used in [NewPartFromURI].
+type FileStorageConfig struct {
+	// Bucket is the name of the Cloud Storage bucket, such as "my-bucket".
+	Bucket string
+	// Optional. Prefix of the names of the objects, such as "genai/files/".
+	Prefix string
+	// Optional. Endpoint of the Cloud Storage JSON API. Defaults to
+	// https://storage.googleapis.com.
+	Endpoint string
+}
+
+// storageFiles implements the Files service with the Cloud Storage JSON API.
+type storageFiles struct {
+	ac     *apiClient
+	config FileStorageConfig
+}
+
+// storage returns the Cloud Storage implementation of the Files service on the
+// Vertex AI backend. method is the name of the calling method for errors.
+func (m Files) storage(method string) (*storageFiles, error) {
+	config := m.apiClient.clientConfig.FileStorage
+	if config == nil || config.Bucket == "" {
+		return nil, fmt.Errorf("method %s is only supported in the Gemini Developer client, or in the Vertex AI client with ClientConfig.FileStorage set.", method)
+	}
+	return newStorageFiles(m.apiClient, *config), nil
+}
+
+// Get gets a file. On the Vertex AI backend, the file is an object of the Cloud
+// Storage location of ClientConfig.FileStorage.
+func (m Files) Get(ctx context.Context, name string, config *GetFileConfig) (*File, error) {
+	ctx = withOperation(ctx, "Files.Get")
+	if m.apiClient.clientConfig.Backend != BackendVertexAI {
+		return m.getFile(ctx, name, config)
+	}
+	storage, err := m.storage("Get")
+	if err != nil {
+		return nil, err
+	}
+	var httpOptions *HTTPOptions
+	if config != nil {
+		httpOptions = config.HTTPOptions
+	}
+	return storage.get(ctx, name, mergeHTTPOptions(m.apiClient.clientConfig, httpOptions))
+}
+
+// Delete deletes a file. On the Vertex AI backend, the file is an object of the
+// Cloud Storage location of ClientConfig.FileStorage.
+func (m Files) Delete(ctx context.Context, name string, config *DeleteFileConfig) (*DeleteFileResponse, error) {
+	ctx = withOperation(ctx, "Files.Delete")
+	if m.apiClient.clientConfig.Backend != BackendVertexAI {
+		return m.deleteFile(ctx, name, config)
+	}
+	storage, err := m.storage("Delete")
+	if err != nil {
+		return nil, err
+	}
+	var httpOptions *HTTPOptions
+	if config != nil {
+		httpOptions = config.HTTPOptions
+	}
+	if err := storage.delete(ctx, name, mergeHTTPOptions(m.apiClient.clientConfig, httpOptions)); err != nil {
+		return nil, err
+	}
+	return &DeleteFileResponse{}, nil
+}
+
+// list lists a page of files, from Cloud Storage on the Vertex AI backend.
+func (m Files) list(ctx context.Context, config *ListFilesConfig) (*ListFilesResponse, error) {
+	ctx = withOperation(ctx, "Files.List")
+	if m.apiClient.clientConfig.Backend != BackendVertexAI {
+		return m.listFiles(ctx, config)
+	}
+	storage, err := m.storage("List")
+	if err != nil {
+		return nil, err
+	}
+	var httpOptions *HTTPOptions
+	if config != nil {
+		httpOptions = config.HTTPOptions
+	}
+	return storage.list(ctx, config, mergeHTTPOptions(m.apiClient.clientConfig, httpOptions))
+}
+
+// storageUpload uploads a file to Cloud Storage on the Vertex AI backend.
+func (m Files) storageUpload(ctx context.Context, r io.Reader, config *UploadFileConfig) (*File, error) {
+	storage, err := m.storage("Upload")
+	if err != nil {
+		return nil, err
+	}
+	return storage.upload(ctx, r, config)
+}
+
+// storageDownloader returns the downloader of a file on the Vertex AI backend.
+func (m Files) storageDownloader(uri DownloadURI, config *DownloadFileConfig) (*downloader, error) {
+	storage, err := m.storage("Download")
+	if err != nil && strings.HasPrefix(uri.uri(), "gs://") {
+		// gs:// URIs, such as those of generated videos, don't need the bucket
+		// of the Files service.
+		storage, err = newStorageFiles(m.apiClient, FileStorageConfig{}), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return storage.downloader(uri.uri(), config)
+}
+
+func newStorageFiles(ac *apiClient, config FileStorageConfig) *storageFiles {
+	config.Endpoint = strings.TrimSuffix(cmp.Or(config.Endpoint, defaultStorageEndpoint), "/")
+	return &storageFiles{ac: ac, config: config}
+}
+
+// storageObject is the subset of the Cloud Storage object resource used by the
+// Files service.
+type storageObject struct {
+	Bucket      string            `json:"bucket,omitempty"`
+	Name        string            `json:"name,omitempty"`
+	ContentType string            `json:"contentType,omitempty"`
+	Size        string            `json:"size,omitempty"`
+	TimeCreated time.Time         `json:"timeCreated"`
+	Updated     time.Time         `json:"updated"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// file converts the object to a File.
+func (s *storageFiles) file(o *storageObject) *File {
+	uri := fmt.Sprintf("gs://%s/%s", o.Bucket, o.Name)
+	file := &File{
+		Name:        "files/" + strings.TrimPrefix(o.Name, s.config.Prefix),
+		DisplayName: o.Metadata["displayName"],
+		MIMEType:    o.ContentType,
+		CreateTime:  o.TimeCreated,
+		UpdateTime:  o.Updated,
+		URI:         uri,
+		DownloadURI: uri,
+		State:       FileStateActive,
+		Source:      FileSourceUploaded,
+	}
+	if size, err := strconv.ParseInt(o.Size, 10, 64); err == nil {
+		file.SizeBytes = &size
+	}
+	return file
+}
+
+// object returns the bucket and object of a file name, such as "files/abc", or
+// of the gs:// URI of a file, which must be under the bucket and prefix of the
+// storage.
+func (s *storageFiles) object(name string) (bucket, object string, err error) {
+	if strings.HasPrefix(name, "gs://") {
+		bucket, object, err = parseStorageURI(name)
+		if err != nil {
+			return "", "", err
+		}
+		if bucket != s.config.Bucket || !strings.HasPrefix(object, s.config.Prefix) {
+			return "", "", fmt.Errorf("%s is outside of the file storage gs://%s/%s", name, s.config.Bucket, s.config.Prefix)
+		}
+		return bucket, object, nil
+	}
+	id := strings.TrimPrefix(name, "files/")
+	if id == "" {
+		return "", "", fmt.Errorf("invalid file name: %q", name)
+	}
+	return s.config.Bucket, s.config.Prefix + id, nil
+}
+
+// parseStorageURI returns the bucket and object of a gs:// URI.
+func parseStorageURI(uri string) (bucket, object string, err error) {
+	rest, _ := strings.CutPrefix(uri, "gs://")
+	bucket, object, ok := strings.Cut(rest, "/")
+	if !ok || bucket == "" || object == "" {
+		return "", "", fmt.Errorf("invalid Cloud Storage URI: %s", uri)
+	}
+	return bucket, object, nil
+}
+
+func (s *storageFiles) objectURL(bucket, object string) string {
+	return fmt.Sprintf("%s/storage/v1/b/%s/o/%s", s.config.Endpoint, url.PathEscape(bucket), url.PathEscape(object))
+}
+
+// do sends a request to the Cloud Storage JSON API and decodes the response
+// into out, if not nil.
+func (s *storageFiles) do(ctx context.Context, method, rawURL string, body io.Reader, header http.Header, httpOptions *HTTPOptions, kind CallKind, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return err
+	}
+	if httpOptions != nil {
+		doMergeHeaders(httpOptions.Headers, &req.Header)
+	}
+	doMergeHeaders(header, &req.Header)
+	s.ac.injectTraceContext(ctx, req.Header)
+
+	call := &InterceptedCall{Operation: operationFromContext(ctx), Kind: kind, Request: req}
+	_, err = intercept(ctx, s.ac, call, func(ctx context.Context, call *InterceptedCall) (*InterceptedResponse, error) {
+		resp, err := doRequest(s.ac, call.Request, httpOptions)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if !httpStatusOk(resp) {
+			return nil, newAPIError(resp)
+		}
+		if out != nil {
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				return nil, fmt.Errorf("failed to decode Cloud Storage response: %w", err)
+			}
+		}
+		return &InterceptedResponse{HTTPResponse: resp}, nil
+	})
+	return err
+}
+
+func (s *storageFiles) get(ctx context.Context, name string, httpOptions *HTTPOptions) (*File, error) {
+	bucket, object, err := s.object(name)
+	if err != nil {
+		return nil, err
+	}
+	var o storageObject
+	if err := s.do(ctx, http.MethodGet, s.objectURL(bucket, object), nil, nil, httpOptions, CallKindUnary, &o); err != nil {
+		return nil, err
+	}
+	return s.file(&o), nil
+}
+
+func (s *storageFiles) delete(ctx context.Context, name string, httpOptions *HTTPOptions) error {
+	bucket, object, err := s.object(name)
+	if err != nil {
+		return err
+	}
+	return s.do(ctx, http.MethodDelete, s.objectURL(bucket, object), nil, nil, httpOptions, CallKindUnary, nil)
+}
+
+// list lists the objects under the prefix. Objects in "subdirectories" of the
+// prefix are listed too.
+func (s *storageFiles) list(ctx context.Context, config *ListFilesConfig, httpOptions *HTTPOptions) (*ListFilesResponse, error) {
+	query := url.Values{}
+	if s.config.Prefix != "" {
+		query.Set("prefix", s.config.Prefix)
+	}
+	if config != nil {
+		if config.PageSize > 0 {
+			query.Set("maxResults", strconv.Itoa(int(config.PageSize)))
+		}
+		if config.PageToken != "" {
+			query.Set("pageToken", config.PageToken)
+		}
+	}
+	rawURL := fmt.Sprintf("%s/storage/v1/b/%s/o", s.config.Endpoint, url.PathEscape(s.config.Bucket))
+	if len(query) > 0 {
+		rawURL += "?" + query.Encode()
+	}
+	var resp struct {
+		Items         []*storageObject `json:"items"`
+		NextPageToken string           `json:"nextPageToken"`
+	}
+	if err := s.do(ctx, http.MethodGet, rawURL, nil, nil, httpOptions, CallKindUnary, &resp); err != nil {
+		return nil, err
+	}
+	files := &ListFilesResponse{NextPageToken: resp.NextPageToken}
+	for _, o := range resp.Items {
+		if strings.HasSuffix(o.Name, "/") {
+			// Skip folder placeholders.
+			continue
+		}
+		files.Files = append(files.Files, s.file(o))
+	}
+	return files, nil
+}
+
+// upload writes the contents of r to a new object with a multipart upload. The
+// contents are streamed, so a failed upload is not resumed.
+func (s *storageFiles) upload(ctx context.Context, r io.Reader, config *UploadFileConfig) (*File, error) {
+	var c UploadFileConfig
+	if config != nil {
+		c = *config
+	}
+	if c.MIMEType == "" {
+		return nil, fmt.Errorf("Upload: MIMEType must be set in UploadFileConfig")
+	}
+	id := strings.TrimPrefix(c.Name, "files/")
+	if id == "" {
+		var err error
+		if id, err = newStorageFileID(); err != nil {
+			return nil, fmt.Errorf("Upload: %w", err)
+		}
+	}
+	metadata := map[string]any{"name": s.config.Prefix + id, "contentType": c.MIMEType}
+	if c.DisplayName != "" {
+		metadata["metadata"] = map[string]string{"displayName": c.DisplayName}
+	}
+	httpOptions := mergeHTTPOptions(s.ac.clientConfig, c.HTTPOptions)
+
+	var total int64
+	if httpOptions != nil {
+		total, _ = strconv.ParseInt(httpOptions.Headers.Get("X-Goog-Upload-Header-Content-Length"), 10, 64)
+	}
+	if c.Progress != nil {
+		r = &progressReader{r: r, progress: func(n int64) {
+			c.Progress(UploadProgress{UploadedBytes: n, TotalBytes: total})
+		}}
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeStorageMultipart(mw, metadata, c.MIMEType, r))
+	}()
+	defer pr.Close()
+
+	rawURL := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=multipart", s.config.Endpoint, url.PathEscape(s.config.Bucket))
+	header := http.Header{"Content-Type": {"multipart/related; boundary=" + mw.Boundary()}}
+	var o storageObject
+	if err := s.do(ctx, http.MethodPost, rawURL, pr, header, httpOptions, CallKindUpload, &o); err != nil {
+		return nil, err
+	}
+	return s.file(&o), nil
+}
+
+// writeStorageMultipart writes the metadata and the media parts of a multipart
+// upload.
+func writeStorageMultipart(mw *multipart.Writer, metadata map[string]any, mimeType string, r io.Reader) error {
+	part, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"application/json; charset=UTF-8"}})
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(part).Encode(metadata); err != nil {
+		return err
+	}
+	part, err = mw.CreatePart(textproto.MIMEHeader{"Content-Type": {mimeType}})
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return fmt.Errorf("failed to read the file: %w", err)
+	}
+	return mw.Close()
+}
+
+// downloader returns the downloader of an object. Unlike the other methods, it
+// accepts any gs:// URI, such as the URI of a generated video.
+func (s *storageFiles) downloader(name string, config *DownloadFileConfig) (*downloader, error) {
+	var bucket, object string
+	var err error
+	if strings.HasPrefix(name, "gs://") {
+		bucket, object, err = parseStorageURI(name)
+	} else {
+		bucket, object, err = s.object(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var httpOptions *HTTPOptions
+	if config == nil {
+		httpOptions = mergeHTTPOptions(s.ac.clientConfig, nil)
+	} else {
+		httpOptions = mergeHTTPOptions(s.ac.clientConfig, config.HTTPOptions)
+	}
+	d := newDownloader(s.ac, "", httpOptions, config)
+	d.url = s.objectURL(bucket, object) + "?alt=media"
+	return d, nil
+}
+
+// newStorageFileID returns a random file ID, made of lowercase letters and
+// digits like the IDs of the Gemini Developer API.
+func newStorageFileID() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("newStorageFileID: error generating the file ID: %w", err)
+	}
+	return strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)), nil
+}
+
+// progressReader reports the number of bytes read from r.
+type progressReader struct {
+	r        io.Reader
+	n        int64
+	progress func(n int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.n += int64(n)
+		p.progress(p.n)
+	}
+	return n, err
+}
@@ -0,0 +1,274 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/auth"
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeStorageServer implements the parts of the Cloud Storage JSON API used by
+// the Files service on the Vertex AI backend.
+type fakeStorageServer struct {
+	t      *testing.T
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string]*fakeStorageObject
+}
+
+type fakeStorageObject struct {
+	metadata storageObject
+	data     []byte
+}
+
+func newFakeStorageServer(t *testing.T, bucket string) (*fakeStorageServer, *httptest.Server) {
+	s := &fakeStorageServer{t: t, bucket: bucket, objects: make(map[string]*fakeStorageObject)}
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	return s, ts
+}
+
+func (s *fakeStorageServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bucketPath := "/storage/v1/b/" + s.bucket + "/o"
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/upload"+bucketPath:
+		s.upload(w, r)
+	case r.Method == http.MethodGet && r.URL.Path == bucketPath:
+		s.list(w, r)
+	case strings.HasPrefix(r.URL.Path, bucketPath+"/"):
+		name := strings.TrimPrefix(r.URL.Path, bucketPath+"/")
+		object, ok := s.objects[name]
+		if !ok {
+			http.Error(w, `{"error": {"code": 404, "message": "No such object"}}`, http.StatusNotFound)
+			return
+		}
+		switch {
+		case r.Method == http.MethodDelete:
+			delete(s.objects, name)
+		case r.URL.Query().Get("alt") == "media":
+			w.Write(object.data)
+		default:
+			json.NewEncoder(w).Encode(object.metadata)
+		}
+	default:
+		http.Error(w, "unexpected request "+r.Method+" "+r.URL.String(), http.StatusBadRequest)
+	}
+}
+
+func (s *fakeStorageServer) upload(w http.ResponseWriter, r *http.Request) {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/related" || r.URL.Query().Get("uploadType") != "multipart" {
+		http.Error(w, "unexpected upload "+r.Header.Get("Content-Type"), http.StatusBadRequest)
+		return
+	}
+	mr := multipart.NewReader(r.Body, params["boundary"])
+	part, err := mr.NextPart()
+	if err != nil {
+		s.t.Errorf("failed to read metadata part: %v", err)
+		return
+	}
+	var metadata storageObject
+	if err := json.NewDecoder(part).Decode(&metadata); err != nil {
+		s.t.Errorf("failed to decode metadata: %v", err)
+		return
+	}
+	part, err = mr.NextPart()
+	if err != nil {
+		s.t.Errorf("failed to read media part: %v", err)
+		return
+	}
+	data, err := io.ReadAll(part)
+	if err != nil {
+		s.t.Errorf("failed to read media: %v", err)
+		return
+	}
+	if got := part.Header.Get("Content-Type"); got != metadata.ContentType {
+		s.t.Errorf("media Content-Type = %q, want %q", got, metadata.ContentType)
+	}
+	metadata.Bucket = s.bucket
+	metadata.Size = strconv.Itoa(len(data))
+	metadata.TimeCreated = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	metadata.Updated = metadata.TimeCreated
+	s.objects[metadata.Name] = &fakeStorageObject{metadata: metadata, data: data}
+	json.NewEncoder(w).Encode(metadata)
+}
+
+func (s *fakeStorageServer) list(w http.ResponseWriter, r *http.Request) {
+	var names []string
+	for name := range s.objects {
+		if strings.HasPrefix(name, r.URL.Query().Get("prefix")) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	start, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+	end := len(names)
+	if n, err := strconv.Atoi(r.URL.Query().Get("maxResults")); err == nil {
+		end = min(start+n, end)
+	}
+	resp := map[string]any{}
+	var items []storageObject
+	for _, name := range names[start:end] {
+		items = append(items, s.objects[name].metadata)
+	}
+	resp["items"] = items
+	if end < len(names) {
+		resp["nextPageToken"] = strconv.Itoa(end)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func newVertexFilesTestClient(t *testing.T, ts *httptest.Server, storage *FileStorageConfig) *Client {
+	t.Helper()
+	return newTestClient(t, ts, &ClientConfig{
+		Backend:     BackendVertexAI,
+		Project:     "test-project",
+		Location:    "test-location",
+		Credentials: &auth.Credentials{},
+		FileStorage: storage,
+	})
+}
+
+func TestVertexFiles(t *testing.T) {
+	ctx := context.Background()
+	server, ts := newFakeStorageServer(t, "test-bucket")
+	// An object outside of the prefix, which is not listed.
+	server.objects["other/object"] = &fakeStorageObject{metadata: storageObject{Bucket: "test-bucket", Name: "other/object"}}
+	client := newVertexFilesTestClient(t, ts, &FileStorageConfig{Bucket: "test-bucket", Prefix: "genai/", Endpoint: ts.URL})
+
+	var last UploadProgress
+	file, err := client.Files.Upload(ctx, strings.NewReader("hello"), &UploadFileConfig{
+		Name:        "files/greeting",
+		DisplayName: "Greeting",
+		MIMEType:    "text/plain",
+		Progress:    func(p UploadProgress) { last = p },
+	})
+	if err != nil {
+		t.Fatalf("Upload() failed: %v", err)
+	}
+	want := &File{
+		Name:        "files/greeting",
+		DisplayName: "Greeting",
+		MIMEType:    "text/plain",
+		SizeBytes:   Ptr[int64](5),
+		CreateTime:  time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		UpdateTime:  time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		URI:         "gs://test-bucket/genai/greeting",
+		DownloadURI: "gs://test-bucket/genai/greeting",
+		State:       FileStateActive,
+		Source:      FileSourceUploaded,
+	}
+	if diff := cmp.Diff(want, file); diff != "" {
+		t.Errorf("Upload() mismatch (-want +got):\n%s", diff)
+	}
+	if last.UploadedBytes != 5 {
+		t.Errorf("last progress = %+v, want 5 uploaded bytes", last)
+	}
+	if part := NewPartFromURI(file.URI, file.MIMEType); part.FileData.FileURI != "gs://test-bucket/genai/greeting" {
+		t.Errorf("NewPartFromURI() = %+v, want the gs:// URI of the file", part.FileData)
+	}
+
+	generated, err := client.Files.Upload(ctx, strings.NewReader("generated"), &UploadFileConfig{MIMEType: "text/plain"})
+	if err != nil {
+		t.Fatalf("Upload() without a name failed: %v", err)
+	}
+	if !strings.HasPrefix(generated.URI, "gs://test-bucket/genai/") {
+		t.Errorf("Upload() URI = %s, want an object under gs://test-bucket/genai/", generated.URI)
+	}
+
+	got, err := client.Files.Get(ctx, "files/greeting", nil)
+	if err != nil || got.URI != file.URI || got.DisplayName != "Greeting" {
+		t.Errorf("Get() = %+v, %v, want %s", got, err, file.URI)
+	}
+
+	data, err := client.Files.Download(ctx, file, nil)
+	if err != nil || string(data) != "hello" {
+		t.Errorf("Download() = %q, %v, want %q", data, err, "hello")
+	}
+	data, err = client.Files.Download(ctx, &Video{URI: "gs://test-bucket/genai/greeting"}, &DownloadFileConfig{SkipVideoBytes: true})
+	if err != nil || string(data) != "hello" {
+		t.Errorf("Download() of a gs:// video = %q, %v, want %q", data, err, "hello")
+	}
+
+	page, err := client.Files.List(ctx, &ListFilesConfig{PageSize: 1})
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if len(page.Items) != 1 || page.NextPageToken == "" {
+		t.Errorf("List() = %d files, next page %q, want 1 file and a next page", len(page.Items), page.NextPageToken)
+	}
+	var names []string
+	for f, err := range client.Files.All(ctx) {
+		if err != nil {
+			t.Fatalf("All() failed: %v", err)
+		}
+		names = append(names, f.Name)
+	}
+	if len(names) != 2 || !slices.Contains(names, "files/greeting") || !slices.Contains(names, generated.Name) {
+		t.Errorf("All() = %q, want files/greeting and %s", names, generated.Name)
+	}
+
+	// Objects outside of the bucket and prefix can be downloaded, but not
+	// managed.
+	if _, err := client.Files.Delete(ctx, "gs://test-bucket/other/object", nil); err == nil || !strings.Contains(err.Error(), "outside of the file storage") {
+		t.Errorf("Delete() of an object outside of the prefix error = %v, want an error", err)
+	}
+	if _, err := client.Files.Get(ctx, "gs://other-bucket/genai/greeting", nil); err == nil || !strings.Contains(err.Error(), "outside of the file storage") {
+		t.Errorf("Get() of an object of another bucket error = %v, want an error", err)
+	}
+	if _, ok := server.objects["other/object"]; !ok {
+		t.Errorf("object outside of the prefix was deleted")
+	}
+	if _, err := client.Files.Download(ctx, &Video{URI: "gs://test-bucket/other/object"}, &DownloadFileConfig{SkipVideoBytes: true}); err != nil {
+		t.Errorf("Download() of an object outside of the prefix failed: %v", err)
+	}
+
+	if _, err := client.Files.Get(ctx, "gs://test-bucket/genai/greeting", nil); err != nil {
+		t.Errorf("Get() of the gs:// URI of a file failed: %v", err)
+	}
+	if _, err := client.Files.Delete(ctx, "files/greeting", nil); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if _, err := client.Files.Get(ctx, "files/greeting", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of a deleted file error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestVertexFilesWithoutStorage(t *testing.T) {
+	_, ts := newFakeStorageServer(t, "test-bucket")
+	client := newVertexFilesTestClient(t, ts, nil)
+	_, err := client.Files.Upload(context.Background(), strings.NewReader("hello"), &UploadFileConfig{MIMEType: "text/plain"})
+	if err == nil || !strings.Contains(err.Error(), "ClientConfig.FileStorage") {
+		t.Errorf("Upload() error = %v, want error mentioning ClientConfig.FileStorage", err)
+	}
+}
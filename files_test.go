@@ -559,7 +559,7 @@ func TestFilesUpload(t *testing.T) {
 		{
 			name:      "Error - Upload Chunk Fails (Server Error)",
 			inputData: "data",
-			config:    &UploadFileConfig{MIMEType: "text/plain"},
+			config:    &UploadFileConfig{MIMEType: "text/plain", ResumeAttempts: -1}, // Fail without resuming the upload.
 			wantErr:   true,
 			setupServer: func(s *MockUploadServer) {
 				s.uploadHandler = func(w http.ResponseWriter, r *http.Request) {
@@ -749,15 +749,19 @@ func TestFilesUploadFromPath(t *testing.T) {
 			wantErrMsg: "is not a valid file path",
 		},
 		{
-			name: "Error - Unknown MIME Type",
+			name: "Success - Sniffed MIME Type",
 			path: func() string { // Create a file with an unknown extension
 				p := filepath.Join(tempDir, "file.unknownext")
-				_ = os.WriteFile(p, []byte("data"), 0644)
+				_ = os.WriteFile(p, []byte("%PDF-1.7 data"), 0644)
 				return p
 			}(),
-			config:     nil, // No MIME override
-			wantErr:    true,
-			wantErrMsg: "Unknown mime type",
+			config: nil, // No MIME override
+			wantFile: &File{
+				Name:      "files/generated-4",
+				MIMEType:  "application/pdf", // Detected from the contents
+				SizeBytes: Ptr(int64(len("%PDF-1.7 data"))),
+				State:     FileStateActive,
+			},
 		},
 		{
 			name: "Error - Upload Fails",
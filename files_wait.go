@@ -0,0 +1,148 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultFileWaitInitialDelay = time.Second
+	defaultFileWaitMaxDelay     = 10 * time.Second
+	defaultFileWaitExpBase      = 1.5
+)
+
+// WaitUntilActiveConfig configures [Files.WaitUntilActive]. Zero valued fields
+// use the documented defaults.
+type WaitUntilActiveConfig struct {
+	// Used to override HTTP request options of the Files.Get calls.
+	HTTPOptions *HTTPOptions `json:"httpOptions,omitempty"`
+	// Delay between the first two polls. Defaults to 1s.
+	InitialDelay time.Duration `json:"initialDelay,omitempty"`
+	// Maximum delay between two polls. Defaults to 10s.
+	MaxDelay time.Duration `json:"maxDelay,omitempty"`
+	// Multiplier applied to the delay after each poll. Defaults to 1.5.
+	ExpBase float64 `json:"expBase,omitempty"`
+	// Maximum time to wait for the file. If zero, WaitUntilActive waits until ctx
+	// is done.
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+// FileProcessingError is returned when the processing of a file fails, with the
+// status reported in File.Error. It matches the sentinel error of its status
+// code, such as [ErrInvalidArgument], with errors.Is.
+type FileProcessingError struct {
+	// File is the file in the FAILED state.
+	File *File
+	// Code is the google.rpc.Code of the failure.
+	Code int32
+	// Message describes the failure.
+	Message string
+	// Details provides more context to the failure. See ErrorDetails for their
+	// typed form.
+	Details []map[string]any
+}
+
+// Error returns a string representation of the FileProcessingError.
+func (e *FileProcessingError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("processing of file %s failed: %s", e.File.Name, e.Message)
+	}
+	return fmt.Sprintf("processing of file %s failed", e.File.Name)
+}
+
+// Is reports whether target is the sentinel error of the status code of e, such
+// as [ErrNotFound].
+func (e *FileProcessingError) Is(target error) bool {
+	return APIError{Status: rpcCodeNames[e.Code]}.Is(target)
+}
+
+// ErrorDetails decodes the Details of e into their typed form.
+func (e *FileProcessingError) ErrorDetails() ErrorDetails {
+	return APIError{Details: e.Details}.ErrorDetails()
+}
+
+// rpcCodeNames are the names of the google.rpc.Code values that have a
+// sentinel error.
+var rpcCodeNames = map[int32]string{
+	3:  "INVALID_ARGUMENT",
+	4:  "DEADLINE_EXCEEDED",
+	5:  "NOT_FOUND",
+	7:  "PERMISSION_DENIED",
+	8:  "RESOURCE_EXHAUSTED",
+	9:  "FAILED_PRECONDITION",
+	11: "OUT_OF_RANGE",
+	14: "UNAVAILABLE",
+	16: "UNAUTHENTICATED",
+}
+
+func newFileProcessingError(file *File) *FileProcessingError {
+	e := &FileProcessingError{File: file}
+	if file.Error != nil {
+		if file.Error.Code != nil {
+			e.Code = *file.Error.Code
+		}
+		e.Message = file.Error.Message
+		e.Details = file.Error.Details
+	}
+	return e
+}
+
+// WaitUntilActive polls the file with the given name until its processing ends,
+// with an exponential backoff. It returns the file once it is ACTIVE, and a
+// *FileProcessingError if its processing FAILED. If the timeout of the config
+// expires, or ctx is done, it returns an error wrapping the context error.
+func (m Files) WaitUntilActive(ctx context.Context, name string, config *WaitUntilActiveConfig) (*File, error) {
+	ctx = withOperation(ctx, "Files.WaitUntilActive")
+	if config == nil {
+		config = &WaitUntilActiveConfig{}
+	}
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+	delay := config.InitialDelay
+	if delay <= 0 {
+		delay = defaultFileWaitInitialDelay
+	}
+	maxDelay := config.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultFileWaitMaxDelay
+	}
+	expBase := config.ExpBase
+	if expBase <= 0 {
+		expBase = defaultFileWaitExpBase
+	}
+
+	for {
+		file, err := m.Get(ctx, name, &GetFileConfig{HTTPOptions: config.HTTPOptions})
+		if err != nil {
+			return nil, fmt.Errorf("WaitUntilActive: %w", err)
+		}
+		switch file.State {
+		case FileStateActive:
+			return file, nil
+		case FileStateFailed:
+			return nil, newFileProcessingError(file)
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, fmt.Errorf("WaitUntilActive: file %s is still %s: %w", name, file.State, err)
+		}
+		delay = min(time.Duration(float64(delay)*expBase), maxDelay)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fileStateServer answers Files.Get with the given file states in turn, the last
+// one repeating, and handles uploads with a resumableUploadServer.
+func fileStateServer(t *testing.T, states ...string) (*httptest.Server, *int) {
+	t.Helper()
+	upload := &resumableUploadServer{t: t, status: "active"}
+	var gets int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			upload.ServeHTTP(w, r)
+			return
+		}
+		state := states[min(gets, len(states)-1)]
+		gets++
+		name := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		if state == "FAILED" {
+			fmt.Fprintf(w, `{"name": "files/%s", "state": "FAILED", "error": {"code": 3, "message": "unsupported video codec"}}`, name)
+			return
+		}
+		fmt.Fprintf(w, `{"name": "files/%s", "state": %q}`, name, state)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &gets
+}
+
+func TestFilesWaitUntilActive(t *testing.T) {
+	ctx := context.Background()
+	fastPolling := &WaitUntilActiveConfig{InitialDelay: time.Millisecond}
+
+	t.Run("active", func(t *testing.T) {
+		ts, gets := fileStateServer(t, "PROCESSING", "PROCESSING", "ACTIVE")
+		client := newTestClient(t, ts, nil)
+		file, err := client.Files.WaitUntilActive(ctx, "files/video", fastPolling)
+		if err != nil {
+			t.Fatalf("WaitUntilActive() failed: %v", err)
+		}
+		if file.State != FileStateActive || *gets != 3 {
+			t.Errorf("WaitUntilActive() = %s after %d polls, want ACTIVE after 3", file.State, *gets)
+		}
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		ts, _ := fileStateServer(t, "PROCESSING", "FAILED")
+		client := newTestClient(t, ts, nil)
+		_, err := client.Files.WaitUntilActive(ctx, "files/video", fastPolling)
+		var processingErr *FileProcessingError
+		if !errors.As(err, &processingErr) {
+			t.Fatalf("WaitUntilActive() error = %v, want *FileProcessingError", err)
+		}
+		if processingErr.Code != 3 || processingErr.Message != "unsupported video codec" || processingErr.File.Name != "files/video" {
+			t.Errorf("WaitUntilActive() error = %+v, want code 3 for files/video", processingErr)
+		}
+		if !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("errors.Is(%v, ErrInvalidArgument) = false, want true", err)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		ts, _ := fileStateServer(t, "PROCESSING")
+		client := newTestClient(t, ts, nil)
+		_, err := client.Files.WaitUntilActive(ctx, "files/video", &WaitUntilActiveConfig{InitialDelay: time.Millisecond, Timeout: 20 * time.Millisecond})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("WaitUntilActive() error = %v, want context.DeadlineExceeded", err)
+		}
+	})
+
+	t.Run("upload", func(t *testing.T) {
+		ts, gets := fileStateServer(t, "PROCESSING", "ACTIVE")
+		client := newTestClient(t, ts, nil)
+		file, err := client.Files.Upload(ctx, strings.NewReader("video"), &UploadFileConfig{MIMEType: "video/mp4", WaitUntilActive: fastPolling})
+		if err != nil {
+			t.Fatalf("Upload() failed: %v", err)
+		}
+		if file.State != FileStateActive || file.Name != "files/uploaded" || *gets != 2 {
+			t.Errorf("Upload() = %s %s after %d polls, want files/uploaded ACTIVE after 2", file.Name, file.State, *gets)
+		}
+	})
+}
@@ -0,0 +1,224 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"slices"
+	"sync"
+)
+
+// defaultMaximumRemoteCalls is the default of AutomaticFunctionCallingConfig.MaximumRemoteCalls.
+const defaultMaximumRemoteCalls = 10
+
+// FunctionHandler executes a function call of the model. args holds the
+// arguments of the call. The returned map is sent back to the model as the
+// "output" of the function, and a non-nil error as its "error".
+type FunctionHandler func(ctx context.Context, args map[string]any) (map[string]any, error)
+
+// ToolRegistry binds Go handlers to function declarations, so that the SDK can
+// execute the function calls of the model. See [AutomaticFunctionCallingConfig].
+//
+// A ToolRegistry is safe for concurrent use.
+type ToolRegistry struct {
+	mu           sync.RWMutex
+	declarations []*FunctionDeclaration
+	handlers     map[string]FunctionHandler
+}
+
+// NewToolRegistry creates an empty ToolRegistry.
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{handlers: make(map[string]FunctionHandler)}
+}
+
+// Register binds handler to the function described by declaration.
+func (r *ToolRegistry) Register(declaration *FunctionDeclaration, handler FunctionHandler) error {
+	if declaration == nil || declaration.Name == "" {
+		return fmt.Errorf("Register: function declaration must have a name")
+	}
+	if handler == nil {
+		return fmt.Errorf("Register: handler of function %q is nil", declaration.Name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.handlers[declaration.Name]; ok {
+		return fmt.Errorf("Register: function %q is already registered", declaration.Name)
+	}
+	r.declarations = append(r.declarations, declaration)
+	r.handlers[declaration.Name] = handler
+	return nil
+}
+
+// Tool returns a Tool that declares the registered functions to the model.
+func (r *ToolRegistry) Tool() *Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return &Tool{FunctionDeclarations: slices.Clone(r.declarations)}
+}
+
+// handles reports whether all calls have a registered handler.
+func (r *ToolRegistry) handles(calls []*FunctionCall) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, call := range calls {
+		if _, ok := r.handlers[call.Name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Call executes call with its registered handler and returns the response to
+// send back to the model. Handler errors, and calls of unregistered functions,
+// are reported to the model under the "error" key.
+func (r *ToolRegistry) Call(ctx context.Context, call *FunctionCall) *FunctionResponse {
+	r.mu.RLock()
+	handler, ok := r.handlers[call.Name]
+	r.mu.RUnlock()
+
+	response := &FunctionResponse{ID: call.ID, Name: call.Name}
+	if !ok {
+		response.Response = map[string]any{"error": fmt.Sprintf("function %q is not registered", call.Name)}
+		return response
+	}
+	output, err := handler(ctx, call.Args)
+	if err != nil {
+		response.Response = map[string]any{"error": err.Error()}
+	} else {
+		response.Response = map[string]any{"output": output}
+	}
+	return response
+}
+
+// AutomaticFunctionCallingConfig configures the automatic execution of the
+// function calls of the model by GenerateContent, GenerateContentStream and
+// Chat.SendMessage.
+//
+// In automatic mode, when the model answers with function calls that all have a
+// handler in Tools, the SDK executes them, sends the responses back to the model
+// together with the previous turns, and repeats until the model answers without
+// function calls. The final response carries the exchanged turns in
+// GenerateContentResponse.AutomaticFunctionCallingHistory.
+type AutomaticFunctionCallingConfig struct {
+	// Tools holds the handlers of the functions to execute. Its declarations are
+	// added to GenerateContentConfig.Tools, unless a function of the same name is
+	// already declared there.
+	Tools *ToolRegistry
+	// MaximumRemoteCalls is the maximum number of times that the SDK sends
+	// function responses back to the model. When it is reached, the last response
+	// is returned with its function calls unexecuted. Defaults to 10.
+	MaximumRemoteCalls int
+}
+
+// automaticFunctionCalling returns the automatic function calling configuration
+// of c, or nil if automatic mode is off.
+func (c *GenerateContentConfig) automaticFunctionCalling() *AutomaticFunctionCallingConfig {
+	if c == nil || c.AutomaticFunctionCalling == nil || c.AutomaticFunctionCalling.Tools == nil {
+		return nil
+	}
+	return c.AutomaticFunctionCalling
+}
+
+func (c *AutomaticFunctionCallingConfig) maximumRemoteCalls() int {
+	if c.MaximumRemoteCalls > 0 {
+		return c.MaximumRemoteCalls
+	}
+	return defaultMaximumRemoteCalls
+}
+
+// requestConfig returns a copy of config that declares the functions of the
+// registry to the model.
+func (c *AutomaticFunctionCallingConfig) requestConfig(config *GenerateContentConfig) *GenerateContentConfig {
+	requestConfig := *config
+	declared := make(map[string]bool)
+	for _, tool := range config.Tools {
+		if tool == nil {
+			continue
+		}
+		for _, declaration := range tool.FunctionDeclarations {
+			declared[declaration.Name] = true
+		}
+	}
+	tool := c.Tools.Tool()
+	tool.FunctionDeclarations = slices.DeleteFunc(tool.FunctionDeclarations, func(d *FunctionDeclaration) bool {
+		return declared[d.Name]
+	})
+	if len(tool.FunctionDeclarations) > 0 {
+		requestConfig.Tools = append(slices.Clone(config.Tools), tool)
+	}
+	return &requestConfig
+}
+
+// callFunctions executes calls and returns the content that holds their responses.
+func (c *AutomaticFunctionCallingConfig) callFunctions(ctx context.Context, calls []*FunctionCall) *Content {
+	content := &Content{Role: RoleUser}
+	for _, call := range calls {
+		content.Parts = append(content.Parts, &Part{FunctionResponse: c.Tools.Call(ctx, call)})
+	}
+	return content
+}
+
+// generateContentWithFunctionCalls runs generateContent in automatic function
+// calling mode.
+func (m Models) generateContentWithFunctionCalls(ctx context.Context, model string, contents []*Content, config *GenerateContentConfig) (*GenerateContentResponse, error) {
+	afc := config.automaticFunctionCalling()
+	var history []*Content
+	for remaining := afc.maximumRemoteCalls(); ; remaining-- {
+		resp, err := m.generateContent(ctx, model, slices.Concat(contents, history), afc.requestConfig(config))
+		if err != nil {
+			return nil, err
+		}
+		calls := resp.FunctionCalls()
+		if len(calls) == 0 || remaining == 0 || !afc.Tools.handles(calls) {
+			resp.AutomaticFunctionCallingHistory = history
+			return resp, nil
+		}
+		history = append(history, resp.Candidates[0].Content, afc.callFunctions(ctx, calls))
+	}
+}
+
+// generateContentStreamWithFunctionCalls runs generateContentStream in automatic
+// function calling mode. It yields the chunks of every request sent to the
+// model, each carrying the turns exchanged before its request.
+func (m Models) generateContentStreamWithFunctionCalls(ctx context.Context, model string, contents []*Content, config *GenerateContentConfig) iter.Seq2[*GenerateContentResponse, error] {
+	afc := config.automaticFunctionCalling()
+	return func(yield func(*GenerateContentResponse, error) bool) {
+		var history []*Content
+		for remaining := afc.maximumRemoteCalls(); ; remaining-- {
+			var calls []*FunctionCall
+			modelContent := &Content{Role: RoleModel}
+			for chunk, err := range m.generateContentStream(ctx, model, slices.Concat(contents, history), afc.requestConfig(config)) {
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				chunk.AutomaticFunctionCallingHistory = history
+				calls = append(calls, chunk.FunctionCalls()...)
+				if len(chunk.Candidates) > 0 && chunk.Candidates[0].Content != nil {
+					modelContent.Parts = append(modelContent.Parts, chunk.Candidates[0].Content.Parts...)
+				}
+				if !yield(chunk, nil) {
+					return
+				}
+			}
+			if len(calls) == 0 || remaining == 0 || !afc.Tools.handles(calls) {
+				return
+			}
+			history = append(slices.Clip(history), modelContent, afc.callFunctions(ctx, calls))
+		}
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	functionCallResponse = `{"candidates": [{"content": {"role": "model", "parts": [{"functionCall": {"name": "get_weather", "args": {"city": "Paris"}}}]}}]}`
+	textResponse         = `{"candidates": [{"content": {"role": "model", "parts": [{"text": "It is sunny."}]}}]}`
+)
+
+func newWeatherRegistry(t *testing.T, handler FunctionHandler) *ToolRegistry {
+	t.Helper()
+	registry := NewToolRegistry()
+	declaration := &FunctionDeclaration{
+		Name:       "get_weather",
+		Parameters: &Schema{Type: TypeObject, Properties: map[string]*Schema{"city": {Type: TypeString}}},
+	}
+	if err := registry.Register(declaration, handler); err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+	return registry
+}
+
+// functionCallingServer answers the requests in order with responses, and
+// records the request bodies.
+func functionCallingServer(t *testing.T, stream bool, responses ...string) (*httptest.Server, *[]map[string]any) {
+	t.Helper()
+	var requests []map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := make(map[string]any)
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		requests = append(requests, body)
+		response := responses[min(len(requests), len(responses))-1]
+		if stream {
+			fmt.Fprintf(w, "data:%s\n\n", response)
+			return
+		}
+		fmt.Fprint(w, response)
+	}))
+	return ts, &requests
+}
+
+func TestToolRegistryRegister(t *testing.T) {
+	handler := func(ctx context.Context, args map[string]any) (map[string]any, error) { return nil, nil }
+	registry := NewToolRegistry()
+	if err := registry.Register(&FunctionDeclaration{Name: "f"}, handler); err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+	if err := registry.Register(&FunctionDeclaration{Name: "f"}, handler); err == nil {
+		t.Error("Register() of a duplicate function succeeded, want error")
+	}
+	if err := registry.Register(&FunctionDeclaration{}, handler); err == nil {
+		t.Error("Register() of an unnamed function succeeded, want error")
+	}
+	if err := registry.Register(&FunctionDeclaration{Name: "g"}, nil); err == nil {
+		t.Error("Register() of a nil handler succeeded, want error")
+	}
+	if diff := cmp.Diff(&Tool{FunctionDeclarations: []*FunctionDeclaration{{Name: "f"}}}, registry.Tool()); diff != "" {
+		t.Errorf("Tool() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGenerateContentAutomaticFunctionCalling(t *testing.T) {
+	ctx := context.Background()
+	ts, requests := functionCallingServer(t, false, functionCallResponse, textResponse)
+	defer ts.Close()
+	client := newTestClient(t, ts, nil)
+
+	var gotArgs map[string]any
+	registry := newWeatherRegistry(t, func(ctx context.Context, args map[string]any) (map[string]any, error) {
+		gotArgs = args
+		return map[string]any{"forecast": "sunny"}, nil
+	})
+	config := &GenerateContentConfig{AutomaticFunctionCalling: &AutomaticFunctionCallingConfig{Tools: registry}}
+
+	resp, err := client.Models.GenerateContent(ctx, "gemini-2.0-flash", Text("Weather in Paris?"), config)
+	if err != nil {
+		t.Fatalf("GenerateContent() failed: %v", err)
+	}
+	if resp.Text() != "It is sunny." {
+		t.Errorf("GenerateContent() text = %q, want final response", resp.Text())
+	}
+	if diff := cmp.Diff(map[string]any{"city": "Paris"}, gotArgs); diff != "" {
+		t.Errorf("handler args mismatch (-want +got):\n%s", diff)
+	}
+
+	wantHistory := []*Content{
+		{Role: RoleModel, Parts: []*Part{{FunctionCall: &FunctionCall{Name: "get_weather", Args: map[string]any{"city": "Paris"}}}}},
+		{Role: RoleUser, Parts: []*Part{{FunctionResponse: &FunctionResponse{Name: "get_weather", Response: map[string]any{"output": map[string]any{"forecast": "sunny"}}}}}},
+	}
+	if diff := cmp.Diff(wantHistory, resp.AutomaticFunctionCallingHistory); diff != "" {
+		t.Errorf("AutomaticFunctionCallingHistory mismatch (-want +got):\n%s", diff)
+	}
+
+	if len(*requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(*requests))
+	}
+	second := (*requests)[1]
+	if got := len(second["contents"].([]any)); got != 3 {
+		t.Errorf("second request has %d contents, want 3", got)
+	}
+	if !strings.Contains(fmt.Sprint(second["tools"]), "get_weather") {
+		t.Errorf("second request tools = %v, want the registered declaration", second["tools"])
+	}
+	if len(config.Tools) != 0 {
+		t.Errorf("config.Tools = %v, want the caller's config unchanged", config.Tools)
+	}
+}
+
+func TestGenerateContentAutomaticFunctionCallingErrors(t *testing.T) {
+	ctx := context.Background()
+	ts, requests := functionCallingServer(t, false, functionCallResponse, textResponse)
+	defer ts.Close()
+	client := newTestClient(t, ts, nil)
+
+	registry := newWeatherRegistry(t, func(ctx context.Context, args map[string]any) (map[string]any, error) {
+		return nil, errors.New("weather service down")
+	})
+	resp, err := client.Models.GenerateContent(ctx, "gemini-2.0-flash", Text("Weather in Paris?"), &GenerateContentConfig{
+		AutomaticFunctionCalling: &AutomaticFunctionCallingConfig{Tools: registry},
+	})
+	if err != nil {
+		t.Fatalf("GenerateContent() failed: %v", err)
+	}
+	got := resp.AutomaticFunctionCallingHistory[1].Parts[0].FunctionResponse.Response
+	if diff := cmp.Diff(map[string]any{"error": "weather service down"}, got); diff != "" {
+		t.Errorf("function response mismatch (-want +got):\n%s", diff)
+	}
+	if !strings.Contains(fmt.Sprint((*requests)[1]["contents"]), "weather service down") {
+		t.Errorf("second request contents = %v, want the handler error", (*requests)[1]["contents"])
+	}
+}
+
+func TestGenerateContentAutomaticFunctionCallingLimits(t *testing.T) {
+	ctx := context.Background()
+	handler := func(ctx context.Context, args map[string]any) (map[string]any, error) { return nil, nil }
+
+	t.Run("maximum remote calls", func(t *testing.T) {
+		ts, requests := functionCallingServer(t, false, functionCallResponse)
+		defer ts.Close()
+		client := newTestClient(t, ts, nil)
+
+		resp, err := client.Models.GenerateContent(ctx, "gemini-2.0-flash", Text("Weather?"), &GenerateContentConfig{
+			AutomaticFunctionCalling: &AutomaticFunctionCallingConfig{Tools: newWeatherRegistry(t, handler), MaximumRemoteCalls: 2},
+		})
+		if err != nil {
+			t.Fatalf("GenerateContent() failed: %v", err)
+		}
+		if len(*requests) != 3 {
+			t.Errorf("got %d requests, want 3", len(*requests))
+		}
+		if len(resp.FunctionCalls()) != 1 || len(resp.AutomaticFunctionCallingHistory) != 4 {
+			t.Errorf("GenerateContent() = %d calls and %d history turns, want the unexecuted call and 4 turns",
+				len(resp.FunctionCalls()), len(resp.AutomaticFunctionCallingHistory))
+		}
+	})
+
+	t.Run("unregistered function", func(t *testing.T) {
+		ts, requests := functionCallingServer(t, false, functionCallResponse)
+		defer ts.Close()
+		client := newTestClient(t, ts, nil)
+
+		registry := NewToolRegistry()
+		if err := registry.Register(&FunctionDeclaration{Name: "other"}, handler); err != nil {
+			t.Fatalf("Register() failed: %v", err)
+		}
+		resp, err := client.Models.GenerateContent(ctx, "gemini-2.0-flash", Text("Weather?"), &GenerateContentConfig{
+			AutomaticFunctionCalling: &AutomaticFunctionCallingConfig{Tools: registry},
+		})
+		if err != nil {
+			t.Fatalf("GenerateContent() failed: %v", err)
+		}
+		if len(*requests) != 1 || len(resp.FunctionCalls()) != 1 {
+			t.Errorf("got %d requests and %d calls, want the call returned to the caller", len(*requests), len(resp.FunctionCalls()))
+		}
+	})
+}
+
+func TestGenerateContentStreamAutomaticFunctionCalling(t *testing.T) {
+	ts, _ := functionCallingServer(t, true, functionCallResponse, textResponse)
+	defer ts.Close()
+	client := newTestClient(t, ts, nil)
+
+	registry := newWeatherRegistry(t, func(ctx context.Context, args map[string]any) (map[string]any, error) {
+		return map[string]any{"forecast": "sunny"}, nil
+	})
+	var texts []string
+	var historyLengths []int
+	for chunk, err := range client.Models.GenerateContentStream(context.Background(), "gemini-2.0-flash", Text("Weather?"), &GenerateContentConfig{
+		AutomaticFunctionCalling: &AutomaticFunctionCallingConfig{Tools: registry},
+	}) {
+		if err != nil {
+			t.Fatalf("GenerateContentStream() failed: %v", err)
+		}
+		texts = append(texts, chunk.Text())
+		historyLengths = append(historyLengths, len(chunk.AutomaticFunctionCallingHistory))
+	}
+	if diff := cmp.Diff([]string{"", "It is sunny."}, texts); diff != "" {
+		t.Errorf("stream texts mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]int{0, 2}, historyLengths); diff != "" {
+		t.Errorf("history lengths mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestChatAutomaticFunctionCalling(t *testing.T) {
+	ctx := context.Background()
+	registry := newWeatherRegistry(t, func(ctx context.Context, args map[string]any) (map[string]any, error) {
+		return map[string]any{"forecast": "sunny"}, nil
+	})
+	config := &GenerateContentConfig{AutomaticFunctionCalling: &AutomaticFunctionCallingConfig{Tools: registry}}
+	wantRoles := []string{RoleUser, RoleModel, RoleUser, RoleModel}
+
+	t.Run("SendMessage", func(t *testing.T) {
+		ts, _ := functionCallingServer(t, false, functionCallResponse, textResponse)
+		defer ts.Close()
+		client := newTestClient(t, ts, nil)
+		chat, _ := client.Chats.Create(ctx, "gemini-2.0-flash", config, nil)
+
+		if _, err := chat.SendMessage(ctx, Part{Text: "Weather?"}); err != nil {
+			t.Fatalf("SendMessage() failed: %v", err)
+		}
+		var roles []string
+		for _, content := range chat.History(false) {
+			roles = append(roles, content.Role)
+		}
+		if diff := cmp.Diff(wantRoles, roles); diff != "" {
+			t.Errorf("history roles mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("SendMessageStream", func(t *testing.T) {
+		ts, _ := functionCallingServer(t, true, functionCallResponse, textResponse)
+		defer ts.Close()
+		client := newTestClient(t, ts, nil)
+		chat, _ := client.Chats.Create(ctx, "gemini-2.0-flash", config, nil)
+
+		for _, err := range chat.SendMessageStream(ctx, Part{Text: "Weather?"}) {
+			if err != nil {
+				t.Fatalf("SendMessageStream() failed: %v", err)
+			}
+		}
+		history := chat.History(false)
+		var roles []string
+		for _, content := range history {
+			roles = append(roles, content.Role)
+		}
+		if diff := cmp.Diff(wantRoles, roles); diff != "" {
+			t.Errorf("history roles mismatch (-want +got):\n%s", diff)
+		}
+		if got := history[len(history)-1].Parts[0].Text; got != "It is sunny." {
+			t.Errorf("last history turn = %q, want final response", got)
+		}
+	})
+}
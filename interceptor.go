@@ -0,0 +1,263 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// CallKind describes how a call intercepted by an [Interceptor] exchanges data
+// with the server.
+type CallKind int
+
+const (
+	// CallKindUnary is a single request and response, such as Models.GenerateContent.
+	CallKindUnary CallKind = iota
+	// CallKindStream is a request answered with server-sent events, such as
+	// Models.GenerateContentStream.
+	CallKindStream
+	// CallKindUpload is a single chunk of a resumable file upload, or a query or
+	// cancellation of its session.
+	CallKindUpload
+	// CallKindDownload is a file download.
+	CallKindDownload
+	// CallKindLive is the websocket handshake of Live.Connect.
+	CallKindLive
+)
+
+// The Stringer interface for CallKind.
+func (k CallKind) String() string {
+	switch k {
+	case CallKindUnary:
+		return "Unary"
+	case CallKindStream:
+		return "Stream"
+	case CallKindUpload:
+		return "Upload"
+	case CallKindDownload:
+		return "Download"
+	case CallKindLive:
+		return "Live"
+	default:
+		return fmt.Sprintf("CallKind(%d)", int(k))
+	}
+}
+
+// InterceptedCall is a call on its way to the server.
+type InterceptedCall struct {
+	// Operation is the logical SDK operation, for example "Models.GenerateContent".
+	Operation string
+	// Kind describes how the call exchanges data with the server.
+	Kind CallKind
+	// Body is the request body after conversion to the wire format of the backend.
+	// For Live calls it is the setup message. It is nil for uploads and downloads,
+	// whose payload is not JSON. Changes to Body are sent to the server.
+	Body map[string]any
+	// Request is the HTTP request. Interceptors may change its URL and headers, or
+	// replace it altogether.
+	Request *http.Request
+}
+
+// InterceptedResponse is the server response of an [InterceptedCall].
+type InterceptedResponse struct {
+	// HTTPResponse is the HTTP response. Its body has already been consumed for
+	// unary calls and uploads. For streaming calls and downloads the body is read
+	// by the SDK after the interceptors return. For Live calls it is the websocket
+	// handshake response. It may be nil when an interceptor answers a unary call
+	// itself.
+	HTTPResponse *http.Response
+	// Body is the deserialized response body of unary calls and uploads, before
+	// conversion to SDK types.
+	Body map[string]any
+
+	conn *websocket.Conn
+}
+
+// CallInvoker passes a call to the next interceptor in the chain, or to the
+// server for the last interceptor.
+type CallInvoker func(ctx context.Context, call *InterceptedCall) (*InterceptedResponse, error)
+
+// Interceptor intercepts the calls that the client sends to the server.
+//
+// Interceptors are set with ClientConfig.Interceptors and run in order, so the
+// first interceptor sees the call first and the response last. An interceptor
+// may inspect or modify the call before passing it to next, inspect or modify
+// the response or the error (such as an [APIError]) returned by next, or answer
+// a unary call itself without calling next. Streaming calls, downloads and Live
+// calls must call next.
+type Interceptor interface {
+	Intercept(ctx context.Context, call *InterceptedCall, next CallInvoker) (*InterceptedResponse, error)
+}
+
+// InterceptorFunc is an adapter to allow the use of ordinary functions as
+// interceptors.
+type InterceptorFunc func(ctx context.Context, call *InterceptedCall, next CallInvoker) (*InterceptedResponse, error)
+
+// Intercept calls f(ctx, call, next).
+func (f InterceptorFunc) Intercept(ctx context.Context, call *InterceptedCall, next CallInvoker) (*InterceptedResponse, error) {
+	return f(ctx, call, next)
+}
+
+type operationKey struct{}
+
+// withOperation annotates ctx with the logical name of an SDK operation. The
+// outermost operation wins, so that internal calls made on behalf of a public
+// method, such as the file creation of Files.Upload, are reported under the
+// public method.
+func withOperation(ctx context.Context, operation string) context.Context {
+	if operationFromContext(ctx) != "" {
+		return ctx
+	}
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// operationFromContext returns the operation set by withOperation.
+func operationFromContext(ctx context.Context) string {
+	operation, _ := ctx.Value(operationKey{}).(string)
+	return operation
+}
+
+// generatedOperations maps the generated methods that send requests to the SDK
+// operations that they implement. The generated code doesn't name its
+// operations, so sendRequest and sendStreamRequest look up their caller here.
+var generatedOperations = map[string]string{
+	"Caches.Create":                          "Caches.Create",
+	"Caches.Delete":                          "Caches.Delete",
+	"Caches.Get":                             "Caches.Get",
+	"Caches.Update":                          "Caches.Update",
+	"Caches.list":                            "Caches.List",
+	"Files.create":                           "Files.Create",
+	"Models.ComputeTokens":                   "Models.ComputeTokens",
+	"Models.CountTokens":                     "Models.CountTokens",
+	"Models.Delete":                          "Models.Delete",
+	"Models.EmbedContent":                    "Models.EmbedContent",
+	"Models.GenerateVideos":                  "Models.GenerateVideos",
+	"Models.Get":                             "Models.Get",
+	"Models.Update":                          "Models.Update",
+	"Models.editImage":                       "Models.EditImage",
+	"Models.generateContent":                 "Models.GenerateContent",
+	"Models.generateContentStream":           "Models.GenerateContentStream",
+	"Models.generateImages":                  "Models.GenerateImages",
+	"Models.list":                            "Models.List",
+	"Models.upscaleImage":                    "Models.UpscaleImage",
+	"Operations.fetchPredictVideosOperation": "Operations.GetVideosOperation",
+	"Operations.getVideosOperation":          "Operations.GetVideosOperation",
+}
+
+// withCallerOperation annotates ctx with the operation of the generated method
+// that called sendRequest or sendStreamRequest, unless ctx already has an
+// operation.
+func withCallerOperation(ctx context.Context) context.Context {
+	if operationFromContext(ctx) != "" {
+		return ctx
+	}
+	var pcs [4]uintptr
+	// Skip runtime.Callers and withCallerOperation.
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs[:])])
+	for {
+		frame, more := frames.Next()
+		// For example "google.golang.org/genai.Models.generateContentStream.func1".
+		name := strings.TrimPrefix(frame.Function, "google.golang.org/genai.")
+		if receiver, method, ok := strings.Cut(name, "."); ok {
+			method, _, _ = strings.Cut(method, ".")
+			if operation, ok := generatedOperations[receiver+"."+method]; ok {
+				return withOperation(ctx, operation)
+			}
+		}
+		if !more {
+			return ctx
+		}
+	}
+}
+
+// intercept runs call through the interceptors configured on the client and
+// then through invoker, which sends the call to the server.
+func intercept(ctx context.Context, ac *apiClient, call *InterceptedCall, invoker CallInvoker) (*InterceptedResponse, error) {
+	interceptors := ac.clientConfig.Interceptors
+	if len(interceptors) == 0 {
+		return invoker(ctx, call)
+	}
+
+	send := invoker
+	invoker = func(ctx context.Context, call *InterceptedCall) (*InterceptedResponse, error) {
+		if call.Request == nil {
+			return nil, fmt.Errorf("intercept: interceptor removed the request of %s", call.Operation)
+		}
+		if call.Request.Context() != ctx {
+			call.Request = call.Request.WithContext(ctx)
+		}
+		if call.Kind == CallKindUnary || call.Kind == CallKindStream {
+			if err := setJSONBody(call.Request, call.Body); err != nil {
+				return nil, err
+			}
+		}
+		return send(ctx, call)
+	}
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoker
+		invoker = func(ctx context.Context, call *InterceptedCall) (*InterceptedResponse, error) {
+			return interceptor.Intercept(ctx, call, next)
+		}
+	}
+
+	resp, err := invoker(ctx, call)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		resp = &InterceptedResponse{}
+	}
+	switch call.Kind {
+	case CallKindStream, CallKindDownload:
+		if resp.HTTPResponse == nil {
+			return nil, fmt.Errorf("intercept: interceptor returned no HTTP response for %s call %s", call.Kind, call.Operation)
+		}
+	case CallKindLive:
+		if resp.conn == nil {
+			return nil, fmt.Errorf("intercept: interceptor returned no connection for %s", call.Operation)
+		}
+	}
+	return resp, nil
+}
+
+// setJSONBody replaces the body of req with the JSON encoding of body.
+func setJSONBody(req *http.Request, body map[string]any) error {
+	b := new(bytes.Buffer)
+	if len(body) > 0 {
+		if err := json.NewEncoder(b).Encode(body); err != nil {
+			return fmt.Errorf("setJSONBody: error encoding body %#v: %w", body, err)
+		}
+	}
+	data := b.Bytes()
+	req.ContentLength = int64(len(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	if len(data) == 0 {
+		req.Body = http.NoBody
+	} else {
+		req.Body, _ = req.GetBody()
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/websocket"
+)
+
+func newInterceptorTestClient(t *testing.T, ts *httptest.Server, interceptors ...Interceptor) *Client {
+	t.Helper()
+	return newTestClient(t, ts, &ClientConfig{Interceptors: interceptors})
+}
+
+func TestInterceptorUnary(t *testing.T) {
+	ctx := context.Background()
+	var gotHeader, gotBody string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Test-Header")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		fmt.Fprint(w, `{"candidates": [{"content": {"role": "model", "parts": [{"text": "from server"}]}}]}`)
+	}))
+	defer ts.Close()
+
+	var order []string
+	var gotOperation string
+	var gotKind CallKind
+	var gotResponse map[string]any
+	first := InterceptorFunc(func(ctx context.Context, call *InterceptedCall, next CallInvoker) (*InterceptedResponse, error) {
+		order = append(order, "first")
+		gotOperation, gotKind = call.Operation, call.Kind
+		call.Request.Header.Set("X-Test-Header", "intercepted")
+		call.Body["generationConfig"] = map[string]any{"temperature": 0.25}
+		resp, err := next(ctx, call)
+		order = append(order, "first done")
+		return resp, err
+	})
+	second := InterceptorFunc(func(ctx context.Context, call *InterceptedCall, next CallInvoker) (*InterceptedResponse, error) {
+		order = append(order, "second")
+		resp, err := next(ctx, call)
+		if err == nil {
+			gotResponse = resp.Body
+		}
+		order = append(order, "second done")
+		return resp, err
+	})
+	client := newInterceptorTestClient(t, ts, first, second)
+
+	resp, err := client.Models.GenerateContent(ctx, "gemini-2.0-flash", Text("hello"), nil)
+	if err != nil {
+		t.Fatalf("GenerateContent() failed: %v", err)
+	}
+	if resp.Text() != "from server" {
+		t.Errorf("GenerateContent() text = %q, want %q", resp.Text(), "from server")
+	}
+	if gotOperation != "Models.GenerateContent" || gotKind != CallKindUnary {
+		t.Errorf("intercepted operation = %q (%v), want Models.GenerateContent (Unary)", gotOperation, gotKind)
+	}
+	if diff := cmp.Diff([]string{"first", "second", "second done", "first done"}, order); diff != "" {
+		t.Errorf("interceptor order mismatch (-want +got):\n%s", diff)
+	}
+	if gotHeader != "intercepted" {
+		t.Errorf("server header = %q, want %q", gotHeader, "intercepted")
+	}
+	if !strings.Contains(gotBody, `"temperature":0.25`) {
+		t.Errorf("server body = %s, want modified generationConfig", gotBody)
+	}
+	if gotResponse["candidates"] == nil {
+		t.Errorf("interceptor response body = %v, want candidates", gotResponse)
+	}
+}
+
+func TestInterceptorShortCircuit(t *testing.T) {
+	ctx := context.Background()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request should not reach the server")
+	}))
+	defer ts.Close()
+
+	cached := InterceptorFunc(func(ctx context.Context, call *InterceptedCall, next CallInvoker) (*InterceptedResponse, error) {
+		return &InterceptedResponse{Body: map[string]any{
+			"candidates": []any{map[string]any{"content": map[string]any{"role": "model", "parts": []any{map[string]any{"text": "cached"}}}}},
+		}}, nil
+	})
+	client := newInterceptorTestClient(t, ts, cached)
+
+	resp, err := client.Models.GenerateContent(ctx, "gemini-2.0-flash", Text("hello"), nil)
+	if err != nil {
+		t.Fatalf("GenerateContent() failed: %v", err)
+	}
+	if resp.Text() != "cached" {
+		t.Errorf("GenerateContent() text = %q, want %q", resp.Text(), "cached")
+	}
+}
+
+func TestInterceptorError(t *testing.T) {
+	ctx := context.Background()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error": {"code": 404, "message": "not found", "status": "NOT_FOUND"}}`)
+	}))
+	defer ts.Close()
+
+	errReplaced := errors.New("replaced")
+	var gotErr error
+	replace := InterceptorFunc(func(ctx context.Context, call *InterceptedCall, next CallInvoker) (*InterceptedResponse, error) {
+		resp, err := next(ctx, call)
+		gotErr = err
+		if err != nil {
+			return nil, errReplaced
+		}
+		return resp, nil
+	})
+	client := newInterceptorTestClient(t, ts, replace)
+
+	_, err := client.Models.Get(ctx, "gemini-2.0-flash", nil)
+	if !errors.Is(err, errReplaced) {
+		t.Errorf("Get() error = %v, want %v", err, errReplaced)
+	}
+	apiErr, ok := gotErr.(APIError)
+	if !ok || apiErr.Code != http.StatusNotFound {
+		t.Errorf("interceptor saw error %v, want APIError with code 404", gotErr)
+	}
+}
+
+func TestInterceptorStream(t *testing.T) {
+	ctx := context.Background()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data:{\"candidates\": [{\"content\": {\"role\": \"model\", \"parts\": [{\"text\": \"chunk\"}]}}]}\n\n")
+	}))
+	defer ts.Close()
+
+	var gotOperation string
+	var gotKind CallKind
+	record := InterceptorFunc(func(ctx context.Context, call *InterceptedCall, next CallInvoker) (*InterceptedResponse, error) {
+		gotOperation, gotKind = call.Operation, call.Kind
+		return next(ctx, call)
+	})
+	client := newInterceptorTestClient(t, ts, record)
+
+	var texts []string
+	for resp, err := range client.Models.GenerateContentStream(ctx, "gemini-2.0-flash", Text("hello"), nil) {
+		if err != nil {
+			t.Fatalf("GenerateContentStream() failed: %v", err)
+		}
+		texts = append(texts, resp.Text())
+	}
+	if diff := cmp.Diff([]string{"chunk"}, texts); diff != "" {
+		t.Errorf("stream mismatch (-want +got):\n%s", diff)
+	}
+	if gotOperation != "Models.GenerateContentStream" || gotKind != CallKindStream {
+		t.Errorf("intercepted operation = %q (%v), want Models.GenerateContentStream (Stream)", gotOperation, gotKind)
+	}
+}
+
+func TestInterceptorUpload(t *testing.T) {
+	ctx := context.Background()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Goog-Upload-Command") == "start" {
+			w.Header().Set("X-Goog-Upload-URL", "http://"+r.Host+"/upload")
+			fmt.Fprint(w, `{}`)
+			return
+		}
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("X-Goog-Upload-Status", "final")
+		fmt.Fprint(w, `{"file": {"name": "files/test"}}`)
+	}))
+	defer ts.Close()
+
+	var mu sync.Mutex
+	var calls []string
+	record := InterceptorFunc(func(ctx context.Context, call *InterceptedCall, next CallInvoker) (*InterceptedResponse, error) {
+		mu.Lock()
+		calls = append(calls, fmt.Sprintf("%s %s", call.Operation, call.Kind))
+		mu.Unlock()
+		return next(ctx, call)
+	})
+	client := newInterceptorTestClient(t, ts, record)
+
+	file, err := client.Files.Upload(ctx, bytes.NewReader([]byte("hello")), &UploadFileConfig{MIMEType: "text/plain"})
+	if err != nil {
+		t.Fatalf("Upload() failed: %v", err)
+	}
+	if file.Name != "files/test" {
+		t.Errorf("Upload() name = %q, want files/test", file.Name)
+	}
+	if diff := cmp.Diff([]string{"Files.Upload Unary", "Files.Upload Upload"}, calls); diff != "" {
+		t.Errorf("intercepted calls mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestInterceptorGeneratedOperations(t *testing.T) {
+	ctx := context.Background()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{}`)
+	}))
+	defer ts.Close()
+
+	var operations []string
+	record := InterceptorFunc(func(ctx context.Context, call *InterceptedCall, next CallInvoker) (*InterceptedResponse, error) {
+		operations = append(operations, call.Operation)
+		return next(ctx, call)
+	})
+	client := newInterceptorTestClient(t, ts, record)
+
+	client.Models.EmbedContent(ctx, "test-model", Text("hello"), nil)
+	client.Models.CountTokens(ctx, "test-model", Text("hello"), nil)
+	client.Models.GenerateImages(ctx, "test-model", "a cat", nil)
+	client.Models.Get(ctx, "test-model", nil)
+	client.Models.List(ctx, nil)
+	client.Caches.Get(ctx, "cachedContents/c1", nil)
+	client.Caches.List(ctx, nil)
+	client.Files.Get(ctx, "files/f1", nil)
+	client.Files.Delete(ctx, "files/f1", nil)
+	client.Operations.GetVideosOperation(ctx, &GenerateVideosOperation{Name: "operations/o1"}, nil)
+	want := []string{
+		"Models.EmbedContent",
+		"Models.CountTokens",
+		"Models.GenerateImages",
+		"Models.Get",
+		"Models.List",
+		"Caches.Get",
+		"Caches.List",
+		"Files.Get",
+		"Files.Delete",
+		"Operations.GetVideosOperation",
+	}
+	if diff := cmp.Diff(want, operations); diff != "" {
+		t.Errorf("intercepted operations mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestInterceptorLive(t *testing.T) {
+	ctx := context.Background()
+	var gotHeader string
+	setup := make(chan string, 1)
+	upgrader := websocket.Upgrader{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Test-Header")
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, message, _ := conn.ReadMessage()
+		setup <- string(message)
+	}))
+	defer ts.Close()
+
+	var gotOperation string
+	var gotStatus int
+	record := InterceptorFunc(func(ctx context.Context, call *InterceptedCall, next CallInvoker) (*InterceptedResponse, error) {
+		gotOperation = call.Operation
+		call.Request.Header.Set("X-Test-Header", "live")
+		call.Body["setup"].(map[string]any)["model"] = "models/rewritten"
+		resp, err := next(ctx, call)
+		if resp != nil && resp.HTTPResponse != nil {
+			gotStatus = resp.HTTPResponse.StatusCode
+		}
+		return resp, err
+	})
+	client := newInterceptorTestClient(t, ts, record)
+	client.Live.apiClient.clientConfig.HTTPOptions.BaseURL = strings.Replace(ts.URL, "http", "ws", 1)
+
+	session, err := client.Live.Connect(ctx, "test-model", nil)
+	if err != nil {
+		t.Fatalf("Connect() failed: %v", err)
+	}
+	defer session.Close()
+	gotSetup := <-setup
+
+	if gotOperation != "Live.Connect" {
+		t.Errorf("intercepted operation = %q, want Live.Connect", gotOperation)
+	}
+	if gotHeader != "live" {
+		t.Errorf("handshake header = %q, want %q", gotHeader, "live")
+	}
+	if gotStatus != http.StatusSwitchingProtocols {
+		t.Errorf("handshake status = %d, want %d", gotStatus, http.StatusSwitchingProtocols)
+	}
+	if want := `{"setup":{"model":"models/rewritten"}}`; gotSetup != want {
+		t.Errorf("setup message = %s, want %s", gotSetup, want)
+	}
+}
+
+func TestInterceptorLiveShortCircuit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	skip := InterceptorFunc(func(ctx context.Context, call *InterceptedCall, next CallInvoker) (*InterceptedResponse, error) {
+		return &InterceptedResponse{}, nil
+	})
+	client := newInterceptorTestClient(t, ts, skip)
+	client.Live.apiClient.clientConfig.HTTPOptions.BaseURL = strings.Replace(ts.URL, "http", "ws", 1)
+
+	if _, err := client.Live.Connect(context.Background(), "test-model", nil); err == nil {
+		t.Error("Connect() succeeded, want error for a short-circuited handshake")
+	}
+}
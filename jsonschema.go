@@ -0,0 +1,532 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// UnsupportedSchemaError reports the keywords of a JSON Schema or OpenAPI
+// document that Schema can't express. The function that returns it also returns
+// the result of the conversion without these keywords, which callers may choose
+// to use.
+type UnsupportedSchemaError struct {
+	// Keywords holds the JSON pointers of the unsupported keywords in the source
+	// document, such as "/properties/tags/uniqueItems".
+	Keywords []string
+}
+
+func (e *UnsupportedSchemaError) Error() string {
+	return fmt.Sprintf("schema has unsupported keywords: %s", strings.Join(e.Keywords, ", "))
+}
+
+// SchemaFromJSONSchema converts a JSON Schema (draft 2020-12) document into a
+// Schema. The OpenAPI 3 schema object keywords nullable and example are accepted
+// too.
+//
+// References to the document itself, such as "#/$defs/address", are resolved by
+// inlining the referenced schema; recursive references can't be expressed and
+// return an error. oneOf is mapped onto AnyOf, and allOf is merged into a single
+// schema when its subschemas don't conflict.
+//
+// Keywords that Schema can't express are reported with an
+// *UnsupportedSchemaError, returned together with the converted schema.
+func SchemaFromJSONSchema(data []byte) (*Schema, error) {
+	var root any
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("SchemaFromJSONSchema: invalid JSON: %w", err)
+	}
+	im := newSchemaImporter(root)
+	schema, err := im.convert(root, "")
+	if err != nil {
+		return nil, fmt.Errorf("SchemaFromJSONSchema: %w", err)
+	}
+	return schema, im.err()
+}
+
+// JSONSchema returns the JSON Schema (draft 2020-12) representation of s, which
+// can be encoded with encoding/json. Nullable schemas add "null" to their type,
+// and PropertyOrdering is kept in the non-standard propertyOrdering keyword.
+func (s *Schema) JSONSchema() map[string]any {
+	m := s.export(false)
+	m["$schema"] = jsonSchemaDialect
+	return m
+}
+
+// OpenAPISchema returns the OpenAPI 3.0 schema object representation of s, which
+// can be encoded with encoding/json.
+func (s *Schema) OpenAPISchema() map[string]any {
+	return s.export(true)
+}
+
+func (s *Schema) export(openAPI bool) map[string]any {
+	m := make(map[string]any)
+	if s == nil {
+		return m
+	}
+	nullable := s.Nullable != nil && *s.Nullable
+	if s.Type != "" && s.Type != TypeUnspecified {
+		typ := strings.ToLower(string(s.Type))
+		if nullable && !openAPI {
+			m["type"] = []any{typ, "null"}
+		} else {
+			m["type"] = typ
+		}
+	}
+	if nullable && openAPI {
+		m["nullable"] = true
+	}
+	if s.Example != nil {
+		if openAPI {
+			m["example"] = s.Example
+		} else {
+			m["examples"] = []any{s.Example}
+		}
+	}
+	setString := func(key, value string) {
+		if value != "" {
+			m[key] = value
+		}
+	}
+	setString("title", s.Title)
+	setString("description", s.Description)
+	setString("format", s.Format)
+	setString("pattern", s.Pattern)
+	if s.Default != nil {
+		m["default"] = s.Default
+	}
+	for key, value := range map[string]*int64{
+		"minLength": s.MinLength, "maxLength": s.MaxLength,
+		"minItems": s.MinItems, "maxItems": s.MaxItems,
+		"minProperties": s.MinProperties, "maxProperties": s.MaxProperties,
+	} {
+		if value != nil {
+			m[key] = *value
+		}
+	}
+	if s.Minimum != nil {
+		m["minimum"] = *s.Minimum
+	}
+	if s.Maximum != nil {
+		m["maximum"] = *s.Maximum
+	}
+	if len(s.Enum) > 0 {
+		m["enum"] = slices.Clone(s.Enum)
+	}
+	if s.Items != nil {
+		m["items"] = s.Items.export(openAPI)
+	}
+	if len(s.AnyOf) > 0 {
+		anyOf := make([]any, len(s.AnyOf))
+		for i, subschema := range s.AnyOf {
+			anyOf[i] = subschema.export(openAPI)
+		}
+		m["anyOf"] = anyOf
+	}
+	if len(s.Properties) > 0 {
+		properties := make(map[string]any, len(s.Properties))
+		for name, property := range s.Properties {
+			properties[name] = property.export(openAPI)
+		}
+		m["properties"] = properties
+	}
+	if len(s.Required) > 0 {
+		m["required"] = slices.Clone(s.Required)
+	}
+	if len(s.PropertyOrdering) > 0 {
+		m["propertyOrdering"] = slices.Clone(s.PropertyOrdering)
+	}
+	return m
+}
+
+// ignoredSchemaKeywords are the keywords that don't change the values accepted
+// by a schema, or that are resolved by the importer.
+var ignoredSchemaKeywords = map[string]bool{
+	"$schema": true, "$id": true, "$anchor": true, "$comment": true, "$defs": true,
+	"definitions": true, "deprecated": true, "readOnly": true, "writeOnly": true,
+	"externalDocs": true, "xml": true, "discriminator": true,
+}
+
+var schemaTypes = map[string]Type{
+	"string": TypeString, "number": TypeNumber, "integer": TypeInteger,
+	"boolean": TypeBoolean, "array": TypeArray, "object": TypeObject,
+}
+
+// schemaImporter converts JSON Schema and OpenAPI schema objects into Schemas.
+type schemaImporter struct {
+	// root is the document that references are resolved against.
+	root any
+	// resolving holds the references being inlined, to detect recursion.
+	resolving   map[string]bool
+	unsupported []string
+}
+
+func newSchemaImporter(root any) *schemaImporter {
+	return &schemaImporter{root: root, resolving: make(map[string]bool)}
+}
+
+// err returns an *UnsupportedSchemaError for the unsupported keywords found so
+// far, or nil.
+func (im *schemaImporter) err() error {
+	if len(im.unsupported) == 0 {
+		return nil
+	}
+	return &UnsupportedSchemaError{Keywords: slices.Clone(im.unsupported)}
+}
+
+func (im *schemaImporter) unsupportedKeyword(pointer string) {
+	if !slices.Contains(im.unsupported, pointer) {
+		im.unsupported = append(im.unsupported, pointer)
+	}
+}
+
+// convert converts the schema object node, found at pointer in the document.
+func (im *schemaImporter) convert(node any, pointer string) (*Schema, error) {
+	if b, ok := node.(bool); ok {
+		if !b {
+			im.unsupportedKeyword(pointer)
+		}
+		return &Schema{}, nil
+	}
+	object, ok := node.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: schema must be an object", pointerOrRoot(pointer))
+	}
+
+	schema := &Schema{}
+	var allOf []*Schema
+	for _, key := range slices.Sorted(maps.Keys(object)) {
+		value := object[key]
+		keyPointer := pointer + "/" + escapeJSONPointer(key)
+		if ignoredSchemaKeywords[key] || strings.HasPrefix(key, "x-") {
+			continue
+		}
+		var err error
+		switch key {
+		case "$ref":
+			var ref *Schema
+			if ref, err = im.resolveRef(value, keyPointer); err == nil {
+				allOf = append(allOf, ref)
+			}
+		case "type":
+			err = im.setType(schema, value, keyPointer)
+		case "nullable":
+			var nullable bool
+			if nullable, err = schemaValue[bool](value, keyPointer); err == nil && nullable {
+				schema.Nullable = Ptr(true)
+			}
+		case "title":
+			schema.Title, err = schemaValue[string](value, keyPointer)
+		case "description":
+			schema.Description, err = schemaValue[string](value, keyPointer)
+		case "format":
+			schema.Format, err = schemaValue[string](value, keyPointer)
+		case "pattern":
+			schema.Pattern, err = schemaValue[string](value, keyPointer)
+		case "default":
+			schema.Default = value
+		case "example":
+			schema.Example = value
+		case "examples":
+			var examples []any
+			if examples, err = schemaValue[[]any](value, keyPointer); err == nil && len(examples) > 0 {
+				schema.Example = examples[0]
+			}
+		case "enum", "const":
+			values := []any{value}
+			if key == "enum" {
+				values, err = schemaValue[[]any](value, keyPointer)
+			}
+			for _, v := range values {
+				s, ok := v.(string)
+				if !ok {
+					// Schema only supports enums of strings.
+					im.unsupportedKeyword(keyPointer)
+					schema.Enum = nil
+					break
+				}
+				schema.Enum = append(schema.Enum, s)
+			}
+		case "minLength":
+			schema.MinLength, err = schemaInt(value, keyPointer)
+		case "maxLength":
+			schema.MaxLength, err = schemaInt(value, keyPointer)
+		case "minItems":
+			schema.MinItems, err = schemaInt(value, keyPointer)
+		case "maxItems":
+			schema.MaxItems, err = schemaInt(value, keyPointer)
+		case "minProperties":
+			schema.MinProperties, err = schemaInt(value, keyPointer)
+		case "maxProperties":
+			schema.MaxProperties, err = schemaInt(value, keyPointer)
+		case "minimum", "maximum":
+			var f float64
+			if f, err = schemaValue[float64](value, keyPointer); err == nil {
+				if key == "minimum" {
+					schema.Minimum = &f
+				} else {
+					schema.Maximum = &f
+				}
+			}
+		case "items":
+			if _, ok := value.([]any); ok {
+				// Tuples of draft 2019-09 and earlier.
+				im.unsupportedKeyword(keyPointer)
+				break
+			}
+			schema.Items, err = im.convert(value, keyPointer)
+		case "properties":
+			var properties map[string]any
+			if properties, err = schemaValue[map[string]any](value, keyPointer); err != nil {
+				break
+			}
+			schema.Properties = make(map[string]*Schema, len(properties))
+			for _, name := range slices.Sorted(maps.Keys(properties)) {
+				if schema.Properties[name], err = im.convert(properties[name], keyPointer+"/"+escapeJSONPointer(name)); err != nil {
+					break
+				}
+			}
+		case "required", "propertyOrdering":
+			var names []string
+			if names, err = schemaStrings(value, keyPointer); err == nil {
+				if key == "required" {
+					schema.Required = names
+				} else {
+					schema.PropertyOrdering = names
+				}
+			}
+		case "anyOf", "oneOf":
+			if key == "oneOf" && object["anyOf"] != nil {
+				// Both can't be mapped onto AnyOf.
+				im.unsupportedKeyword(keyPointer)
+				break
+			}
+			schema.AnyOf, err = im.convertAll(value, keyPointer)
+		case "allOf":
+			var subschemas []*Schema
+			if subschemas, err = im.convertAll(value, keyPointer); err == nil {
+				allOf = append(allOf, subschemas...)
+			}
+		default:
+			im.unsupportedKeyword(keyPointer)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, subschema := range allOf {
+		if err := mergeSchema(schema, subschema); err != nil {
+			return nil, fmt.Errorf("%s: allOf or $ref can't be merged: %w", pointerOrRoot(pointer), err)
+		}
+	}
+	return schema, nil
+}
+
+func (im *schemaImporter) convertAll(value any, pointer string) ([]*Schema, error) {
+	nodes, err := schemaValue[[]any](value, pointer)
+	if err != nil {
+		return nil, err
+	}
+	schemas := make([]*Schema, len(nodes))
+	for i, node := range nodes {
+		if schemas[i], err = im.convert(node, pointer+"/"+strconv.Itoa(i)); err != nil {
+			return nil, err
+		}
+	}
+	return schemas, nil
+}
+
+func (im *schemaImporter) setType(schema *Schema, value any, pointer string) error {
+	var names []string
+	if name, ok := value.(string); ok {
+		names = []string{name}
+	} else {
+		var err error
+		if names, err = schemaStrings(value, pointer); err != nil {
+			return err
+		}
+	}
+	var types []Type
+	for _, name := range names {
+		if name == "null" {
+			schema.Nullable = Ptr(true)
+			continue
+		}
+		t, ok := schemaTypes[name]
+		if !ok {
+			return fmt.Errorf("%s: unknown type %q", pointer, name)
+		}
+		types = append(types, t)
+	}
+	switch len(types) {
+	case 0:
+	case 1:
+		schema.Type = types[0]
+	default:
+		for _, t := range types {
+			schema.AnyOf = append(schema.AnyOf, &Schema{Type: t})
+		}
+	}
+	return nil
+}
+
+// resolveRef converts the schema referenced by ref, which must point into the
+// document.
+func (im *schemaImporter) resolveRef(value any, pointer string) (*Schema, error) {
+	ref, err := schemaValue[string](value, pointer)
+	if err != nil {
+		return nil, err
+	}
+	target, refPointer, err := resolveJSONPointer(im.root, ref)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", pointer, err)
+	}
+	if im.resolving[refPointer] {
+		return nil, fmt.Errorf("%s: reference %q is recursive, which schemas can't express", pointer, ref)
+	}
+	im.resolving[refPointer] = true
+	defer delete(im.resolving, refPointer)
+	return im.convert(target, refPointer)
+}
+
+// resolveJSONPointer returns the value that the local reference ref, such as
+// "#/$defs/address", points to in root, and the JSON pointer of the value.
+func resolveJSONPointer(root any, ref string) (any, string, error) {
+	fragment, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, "", fmt.Errorf("reference %q is not local to the document", ref)
+	}
+	pointer, err := url.PathUnescape(fragment)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid reference %q: %w", ref, err)
+	}
+	if pointer != "" && !strings.HasPrefix(pointer, "/") {
+		return nil, "", fmt.Errorf("reference %q is not a JSON pointer", ref)
+	}
+	node := root
+	if pointer != "" {
+		for _, token := range strings.Split(pointer[1:], "/") {
+			token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+			switch n := node.(type) {
+			case map[string]any:
+				node, ok = n[token]
+			case []any:
+				i, err := strconv.Atoi(token)
+				ok = err == nil && i >= 0 && i < len(n)
+				if ok {
+					node = n[i]
+				}
+			default:
+				ok = false
+			}
+			if !ok {
+				return nil, "", fmt.Errorf("reference %q can't be resolved", ref)
+			}
+		}
+	}
+	return node, pointer, nil
+}
+
+// mergeSchema merges from into s, as if s had an allOf of from. Titles,
+// descriptions, defaults and examples of s win over the ones of from; other
+// fields must not conflict.
+func mergeSchema(s, from *Schema) error {
+	sv, fromv := reflect.ValueOf(s).Elem(), reflect.ValueOf(from).Elem()
+	for i := 0; i < sv.NumField(); i++ {
+		field, fromField := sv.Field(i), fromv.Field(i)
+		name := sv.Type().Field(i).Name
+		switch {
+		case fromField.IsZero():
+		case field.IsZero():
+			field.Set(fromField)
+		case name == "Title" || name == "Description" || name == "Default" || name == "Example":
+		case name == "Required" || name == "PropertyOrdering":
+			for _, value := range fromField.Interface().([]string) {
+				if !slices.Contains(field.Interface().([]string), value) {
+					field.Set(reflect.Append(field, reflect.ValueOf(value)))
+				}
+			}
+		case name == "Properties":
+			for propertyName, property := range from.Properties {
+				if existing, ok := s.Properties[propertyName]; ok {
+					if err := mergeSchema(existing, property); err != nil {
+						return fmt.Errorf("property %q: %w", propertyName, err)
+					}
+					continue
+				}
+				s.Properties[propertyName] = property
+			}
+		case !reflect.DeepEqual(field.Interface(), fromField.Interface()):
+			return fmt.Errorf("conflicting %s", name)
+		}
+	}
+	return nil
+}
+
+// schemaValue returns value, which is at pointer in the document, as a T.
+func schemaValue[T any](value any, pointer string) (T, error) {
+	v, ok := value.(T)
+	if !ok {
+		return v, fmt.Errorf("%s: invalid value %v", pointer, value)
+	}
+	return v, nil
+}
+
+func schemaInt(value any, pointer string) (*int64, error) {
+	f, err := schemaValue[float64](value, pointer)
+	if err != nil {
+		return nil, err
+	}
+	if f != math.Trunc(f) {
+		return nil, fmt.Errorf("%s: %v is not an integer", pointer, f)
+	}
+	return Ptr(int64(f)), nil
+}
+
+func schemaStrings(value any, pointer string) ([]string, error) {
+	values, err := schemaValue[[]any](value, pointer)
+	if err != nil {
+		return nil, err
+	}
+	strs := make([]string, len(values))
+	for i, v := range values {
+		if strs[i], err = schemaValue[string](v, pointer+"/"+strconv.Itoa(i)); err != nil {
+			return nil, err
+		}
+	}
+	return strs, nil
+}
+
+func escapeJSONPointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+func pointerOrRoot(pointer string) string {
+	if pointer == "" {
+		return "/"
+	}
+	return pointer
+}
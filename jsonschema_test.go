@@ -0,0 +1,164 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSchemaFromJSONSchema(t *testing.T) {
+	data := `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$defs": {
+			"address": {
+				"type": "object",
+				"properties": {"city": {"type": "string"}},
+				"required": ["city"]
+			},
+			"named": {"properties": {"name": {"type": "string", "maxLength": 20}}, "required": ["name"]}
+		},
+		"type": "object",
+		"allOf": [{"$ref": "#/$defs/named"}],
+		"properties": {
+			"home": {"$ref": "#/$defs/address", "description": "Home address."},
+			"age": {"type": ["integer", "null"], "minimum": 0},
+			"id": {"oneOf": [{"type": "string"}, {"type": "integer"}]},
+			"kind": {"const": "person"},
+			"tags": {"type": "array", "items": {"type": "string", "enum": ["a", "b"]}, "examples": [["a"]]}
+		},
+		"x-internal": true
+	}`
+	got, err := SchemaFromJSONSchema([]byte(data))
+	if err != nil {
+		t.Fatalf("SchemaFromJSONSchema() failed: %v", err)
+	}
+	want := &Schema{
+		Type: TypeObject,
+		Properties: map[string]*Schema{
+			"home": {
+				Type:        TypeObject,
+				Description: "Home address.",
+				Properties:  map[string]*Schema{"city": {Type: TypeString}},
+				Required:    []string{"city"},
+			},
+			"age":  {Type: TypeInteger, Nullable: Ptr(true), Minimum: Ptr(0.0)},
+			"id":   {AnyOf: []*Schema{{Type: TypeString}, {Type: TypeInteger}}},
+			"kind": {Enum: []string{"person"}},
+			"tags": {Type: TypeArray, Items: &Schema{Type: TypeString, Enum: []string{"a", "b"}}, Example: []any{"a"}},
+			"name": {Type: TypeString, MaxLength: Ptr[int64](20)},
+		},
+		Required: []string{"name"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("SchemaFromJSONSchema() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSchemaFromJSONSchemaUnsupported(t *testing.T) {
+	data := `{
+		"type": "object",
+		"properties": {
+			"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true},
+			"level": {"type": "integer", "enum": [1, 2]}
+		},
+		"additionalProperties": false
+	}`
+	got, err := SchemaFromJSONSchema([]byte(data))
+	var unsupported *UnsupportedSchemaError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("SchemaFromJSONSchema() error = %v, want *UnsupportedSchemaError", err)
+	}
+	wantKeywords := []string{"/additionalProperties", "/properties/level/enum", "/properties/tags/uniqueItems"}
+	if diff := cmp.Diff(wantKeywords, unsupported.Keywords); diff != "" {
+		t.Errorf("unsupported keywords mismatch (-want +got):\n%s", diff)
+	}
+	want := &Schema{
+		Type: TypeObject,
+		Properties: map[string]*Schema{
+			"tags":  {Type: TypeArray, Items: &Schema{Type: TypeString}},
+			"level": {Type: TypeInteger},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("SchemaFromJSONSchema() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSchemaFromJSONSchemaErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		data    string
+		wantErr string
+	}{
+		{"recursive", `{"$defs": {"node": {"properties": {"next": {"$ref": "#/$defs/node"}}}}, "$ref": "#/$defs/node"}`, "recursive"},
+		{"remote reference", `{"$ref": "https://example.com/schema.json"}`, "not local"},
+		{"missing reference", `{"$ref": "#/$defs/missing"}`, "can't be resolved"},
+		{"conflicting allOf", `{"allOf": [{"type": "string"}, {"type": "integer"}]}`, "conflicting Type"},
+		{"unknown type", `{"type": "decimal"}`, "unknown type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := SchemaFromJSONSchema([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("SchemaFromJSONSchema() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSchemaExport(t *testing.T) {
+	schema := &Schema{
+		Type:             TypeObject,
+		Description:      "A person.",
+		PropertyOrdering: []string{"name", "age"},
+		Required:         []string{"name"},
+		Properties: map[string]*Schema{
+			"name": {Type: TypeString, MinLength: Ptr[int64](1), Example: "Ada"},
+			"age":  {Type: TypeInteger, Nullable: Ptr(true), Maximum: Ptr(150.0)},
+		},
+	}
+
+	jsonSchema := schema.JSONSchema()
+	if jsonSchema["$schema"] != jsonSchemaDialect {
+		t.Errorf("$schema = %v, want %s", jsonSchema["$schema"], jsonSchemaDialect)
+	}
+	age := jsonSchema["properties"].(map[string]any)["age"].(map[string]any)
+	if diff := cmp.Diff([]any{"integer", "null"}, age["type"]); diff != "" {
+		t.Errorf("nullable type mismatch (-want +got):\n%s", diff)
+	}
+	openAPIAge := schema.OpenAPISchema()["properties"].(map[string]any)["age"].(map[string]any)
+	if openAPIAge["type"] != "integer" || openAPIAge["nullable"] != true {
+		t.Errorf("OpenAPI age = %v, want a nullable integer", openAPIAge)
+	}
+
+	for _, exported := range []map[string]any{jsonSchema, schema.OpenAPISchema()} {
+		data, err := json.Marshal(exported)
+		if err != nil {
+			t.Fatalf("json.Marshal() failed: %v", err)
+		}
+		got, err := SchemaFromJSONSchema(data)
+		if err != nil {
+			t.Fatalf("SchemaFromJSONSchema() failed: %v", err)
+		}
+		if diff := cmp.Diff(schema, got); diff != "" {
+			t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
@@ -17,6 +17,7 @@ package genai
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -39,12 +40,29 @@ type Live struct {
 type Session struct {
 	conn      *websocket.Conn
 	apiClient *apiClient
+	// resume is set if the session reconnects, see LiveConnectConfig.Reconnect.
+	resume *liveResume
 }
 
 // Preview. Connect establishes a realtime connection to the specified model with given configuration.
 // It returns a Session object representing the connection or an error if the connection fails.
 // The live module is experimental.
-func (r *Live) Connect(context context.Context, model string, config *LiveConnectConfig) (*Session, error) {
+func (r *Live) Connect(ctx context.Context, model string, config *LiveConnectConfig) (*Session, error) {
+	if config != nil && config.Reconnect != nil {
+		return r.connectResumable(ctx, model, config)
+	}
+	conn, err := r.connect(ctx, model, config)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{conn: conn, apiClient: r.apiClient}, nil
+}
+
+// connect opens a connection to the model, and sends the setup message.
+func (r *Live) connect(ctx context.Context, model string, config *LiveConnectConfig) (conn *websocket.Conn, err error) {
+	ctx = withOperation(ctx, "Live.Connect")
+	ctx, span := r.apiClient.startSpan(ctx, model, nil, &r.apiClient.clientConfig.HTTPOptions)
+	defer func() { span.end(err) }()
 	httpOptions := r.apiClient.clientConfig.HTTPOptions
 	if httpOptions.APIVersion == "" {
 		return nil, fmt.Errorf("live module requires APIVersion to be set. You can set APIVersion to v1beta1 for BackendVertexAI or v1apha for BackendGeminiAPI")
@@ -63,7 +81,7 @@ func (r *Live) Connect(context context.Context, model string, config *LiveConnec
 	// TODO(b/406076143): Support function level httpOptions.
 	var header http.Header = mergeHeaders(&httpOptions, nil)
 	if r.apiClient.clientConfig.Backend == BackendVertexAI {
-		token, err := r.apiClient.clientConfig.Credentials.Token(context)
+		token, err := r.apiClient.clientConfig.Credentials.Token(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get token: %w", err)
 		}
@@ -82,14 +100,6 @@ func (r *Live) Connect(context context.Context, model string, config *LiveConnec
 		}
 	}
 
-	conn, _, err := websocket.DefaultDialer.Dial(u.String(), header)
-	if err != nil {
-		return nil, fmt.Errorf("Connect to %s failed: %w", u.String(), err)
-	}
-	s := &Session{
-		conn:      conn,
-		apiClient: r.apiClient,
-	}
 	modelFullName, err := tModelFullName(r.apiClient, model)
 	if err != nil {
 		return nil, err
@@ -113,15 +123,38 @@ func (r *Live) Connect(context context.Context, model string, config *LiveConnec
 	}
 	delete(body, "config")
 
-	clientBytes, err := json.Marshal(body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("marshal LiveClientSetup failed: %w", err)
+		return nil, fmt.Errorf("failed to create websocket handshake request: %w", err)
 	}
-	err = s.conn.WriteMessage(websocket.TextMessage, clientBytes)
+	req.Header = header
+	r.apiClient.injectTraceContext(ctx, req.Header)
+	call := &InterceptedCall{Operation: operationFromContext(ctx), Kind: CallKindLive, Body: body, Request: req}
+	resp, err := intercept(ctx, r.apiClient, call, func(ctx context.Context, call *InterceptedCall) (*InterceptedResponse, error) {
+		conn, httpResp, err := websocket.DefaultDialer.DialContext(ctx, call.Request.URL.String(), call.Request.Header)
+		if errors.Is(err, websocket.ErrBadHandshake) && httpResp != nil && !httpStatusOk(httpResp) {
+			// Report the rejected handshake like any other API error.
+			err = newAPIError(httpResp)
+		}
+		if err != nil {
+			return &InterceptedResponse{HTTPResponse: httpResp}, fmt.Errorf("Connect to %s failed: %w", call.Request.URL.String(), err)
+		}
+		clientBytes, err := json.Marshal(call.Body)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("marshal LiveClientSetup failed: %w", err)
+		}
+		err = conn.WriteMessage(websocket.TextMessage, clientBytes)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to write LiveClientSetup: %w", err)
+		}
+		return &InterceptedResponse{HTTPResponse: httpResp, conn: conn}, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to write LiveClientSetup: %w", err)
+		return nil, err
 	}
-	return s, nil
+	return resp.conn, nil
 }
 
 // Preview. LiveClientContentInput is the input for [SendClientContent].
@@ -162,7 +195,7 @@ func (s *Session) SendRealtimeInput(input LiveRealtimeInput) error {
 	if err != nil {
 		return fmt.Errorf("marshal client message error: %w", err)
 	}
-	return s.conn.WriteMessage(websocket.TextMessage, []byte(data))
+	return s.write(data)
 }
 
 // Preview. LiveToolResponseInput is the input for [SendToolResponse].
@@ -204,22 +237,60 @@ func (s *Session) send(input *LiveClientMessage) error {
 	if err != nil {
 		return fmt.Errorf("marshal client message error: %w", err)
 	}
-	return s.conn.WriteMessage(websocket.TextMessage, []byte(data))
+	return s.write(data)
+}
+
+// write writes a client message to the connection.
+func (s *Session) write(data []byte) error {
+	if s.resume != nil {
+		return s.resume.write(s, data)
+	}
+	return s.conn.WriteMessage(websocket.TextMessage, data)
 }
 
 // Preview. Receive reads a LiveServerMessage from the connection.
 // It returns the received message or an error if reading or unmarshalling fails.
+// If the session reconnects, Receive resumes it on a new connection when the
+// server sends GoAway or the connection fails.
 // The live module is experimental.
 func (s *Session) Receive() (*LiveServerMessage, error) {
-	messageType, msgBytes, err := s.conn.ReadMessage()
+	if s.resume != nil {
+		return s.resume.receive(s)
+	}
+	return s.receive(s.conn)
+}
+
+// receive reads a LiveServerMessage from conn.
+func (s *Session) receive(conn *websocket.Conn) (*LiveServerMessage, error) {
+	messageType, msgBytes, err := conn.ReadMessage()
 	if err != nil {
-		return nil, err
+		return nil, liveReadError(err)
 	}
+	return s.decode(messageType, msgBytes)
+}
+
+// liveReadError converts the error of a connection read, reporting close frames
+// as APIErrors.
+func liveReadError(err error) error {
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) {
+		if apiErr, ok := liveCloseError(closeErr); ok {
+			return apiErr
+		}
+	}
+	return err
+}
+
+// decode decodes a message read from the connection.
+func (s *Session) decode(messageType int, msgBytes []byte) (*LiveServerMessage, error) {
 	responseMap := make(map[string]any)
-	err = json.Unmarshal(msgBytes, &responseMap)
+	err := json.Unmarshal(msgBytes, &responseMap)
 	if err != nil {
 		return nil, fmt.Errorf("invalid message format. Error %w. messageType: %d, message: %s", err, messageType, msgBytes)
 	}
+	if apiErr, ok := apiErrorFromMap(responseMap); ok {
+		return nil, apiErr
+	}
 	if responseMap["error"] != nil {
 		return nil, fmt.Errorf("received error in response: %v", string(msgBytes))
 	}
@@ -246,6 +317,9 @@ func (s *Session) Receive() (*LiveServerMessage, error) {
 // Preview. Close terminates the connection.
 // The live module is experimental.
 func (s *Session) Close() error {
+	if s != nil && s.resume != nil {
+		return s.resume.close(s)
+	}
 	if s != nil && s.conn != nil {
 		return s.conn.Close()
 	}
@@ -0,0 +1,336 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+const defaultLiveReconnectAttempts = 5
+
+// Preview. LiveReconnectConfig configures the reconnections of a Live session.
+// Zero valued fields use the documented defaults.
+//
+// The session tracks the latest resumable handle sent in
+// LiveServerSessionResumptionUpdate messages. When the server sends GoAway, the
+// session reconnects with the handle as soon as it has one; when the connection
+// fails, the session reconnects with the latest handle. Then it sends again the
+// client messages that the server didn't consume. With
+// SessionResumptionConfig.Transparent, which is only supported on Vertex AI,
+// the consumed messages are known from LastConsumedClientMessageIndex;
+// otherwise all the messages sent before a resumable update are considered
+// consumed.
+// The live module is experimental.
+type LiveReconnectConfig struct {
+	// Maximum number of attempts of a reconnection. Defaults to 5.
+	MaxAttempts int
+	// Backoff between the attempts of a reconnection. Defaults to the retry
+	// options of the client, or to the default RetryOptions.
+	RetryOptions *RetryOptions
+	// Optional. Called after each attempt of a reconnection.
+	OnReconnect func(LiveReconnectEvent)
+}
+
+// Preview. LiveReconnectEvent reports an attempt to reconnect a Live session.
+// The live module is experimental.
+type LiveReconnectEvent struct {
+	// Attempt is the number of the attempt, starting at 1.
+	Attempt int
+	// GoAway is the message of the server that caused the reconnection, if any.
+	GoAway *LiveServerGoAway
+	// Cause is the error of the connection that caused the reconnection, if any.
+	Cause error
+	// Handle is the session resumption handle used to resume the session.
+	Handle string
+	// Replayed is the number of client messages sent again after the
+	// reconnection.
+	Replayed int
+	// Err is the error of the attempt, or nil if the session reconnected.
+	Err error
+}
+
+// liveResume is the state of a Live session that reconnects.
+type liveResume struct {
+	live   *Live
+	model  string
+	config LiveConnectConfig
+	// ctx is used for the reconnections. It is cancelled when the session is
+	// closed.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// mu guards the fields below, and the connection of the session while it is
+	// written or replaced.
+	mu     sync.Mutex
+	handle string
+	// pending are the client messages that the server may not have consumed.
+	pending []liveClientMessage
+	// sent is the index of the last client message sent on the connection.
+	sent   int64
+	goAway *LiveServerGoAway
+	closed bool
+}
+
+// liveClientMessage is a client message, with its index on the connection.
+type liveClientMessage struct {
+	index int64
+	data  []byte
+}
+
+func (r *Live) connectResumable(ctx context.Context, model string, config *LiveConnectConfig) (*Session, error) {
+	c := *config
+	if c.SessionResumption == nil {
+		c.SessionResumption = &SessionResumptionConfig{}
+	} else {
+		sr := *c.SessionResumption
+		c.SessionResumption = &sr
+	}
+	conn, err := r.connect(ctx, model, &c)
+	if err != nil {
+		return nil, err
+	}
+	resume := &liveResume{live: r, model: model, config: c, handle: c.SessionResumption.Handle}
+	resume.ctx, resume.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	return &Session{conn: conn, apiClient: r.apiClient, resume: resume}, nil
+}
+
+// write writes a client message, and keeps it until the server consumed it. If
+// the write fails, the session reconnects, and the message is sent again with
+// the other pending messages.
+func (r *liveResume) write(s *Session, data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return fmt.Errorf("session is closed")
+	}
+	r.sent++
+	r.pending = append(r.pending, liveClientMessage{index: r.sent, data: data})
+	err := s.conn.WriteMessage(websocket.TextMessage, data)
+	if err == nil {
+		return nil
+	}
+	if !liveReconnectable(err) {
+		r.pending = r.pending[:len(r.pending)-1]
+		return err
+	}
+	if err := r.reconnectLocked(s, nil, err); err != nil {
+		// The message is reported as not sent, and must not be replayed.
+		if len(r.pending) > 0 {
+			r.pending = r.pending[:len(r.pending)-1]
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *liveResume) receive(s *Session) (*LiveServerMessage, error) {
+	for {
+		r.mu.Lock()
+		conn := s.conn
+		r.mu.Unlock()
+		messageType, msgBytes, err := conn.ReadMessage()
+		if err != nil {
+			if r.isClosed() || !liveReconnectable(err) {
+				return nil, liveReadError(err)
+			}
+			if err := r.reconnect(s, conn, nil, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		message, err := s.decode(messageType, msgBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		r.mu.Lock()
+		if u := message.SessionResumptionUpdate; u != nil {
+			r.updateLocked(u)
+		}
+		if message.GoAway != nil {
+			r.goAway = message.GoAway
+		}
+		goAway := r.goAway
+		resumable := goAway != nil && r.handle != ""
+		r.mu.Unlock()
+		if resumable {
+			// Reconnect before the connection is terminated. Without a handle, the
+			// session waits for a resumable update.
+			if err := r.reconnect(s, conn, goAway, nil); err != nil {
+				return nil, err
+			}
+		}
+		return message, nil
+	}
+}
+
+// updateLocked records a session resumption update, and drops the pending
+// messages that the server consumed. The caller must hold r.mu.
+func (r *liveResume) updateLocked(u *LiveServerSessionResumptionUpdate) {
+	if !u.Resumable || u.NewHandle == "" {
+		return
+	}
+	r.handle = u.NewHandle
+	consumed := r.sent
+	if r.config.SessionResumption.Transparent {
+		consumed = u.LastConsumedClientMessageIndex
+	}
+	i := 0
+	for i < len(r.pending) && r.pending[i].index <= consumed {
+		i++
+	}
+	r.pending = r.pending[i:]
+}
+
+// reconnect resumes the session on a new connection, unless conn was already
+// replaced, such as after a failed write.
+func (r *liveResume) reconnect(s *Session, conn *websocket.Conn, goAway *LiveServerGoAway, cause error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s.conn != conn {
+		return nil
+	}
+	return r.reconnectLocked(s, goAway, cause)
+}
+
+// reconnectLocked resumes the session on a new connection, and sends the
+// pending messages again. The caller must hold r.mu.
+func (r *liveResume) reconnectLocked(s *Session, goAway *LiveServerGoAway, cause error) error {
+	if r.closed {
+		return fmt.Errorf("session is closed")
+	}
+	if r.handle == "" {
+		return fmt.Errorf("session can't be resumed without a resumable handle: %w", cause)
+	}
+	s.conn.Close()
+
+	config := r.config
+	sr := *config.SessionResumption
+	sr.Handle = r.handle
+	config.SessionResumption = &sr
+	retryOptions := r.config.Reconnect.RetryOptions
+	if retryOptions == nil {
+		retryOptions = resolveRetryOptions(r.live.apiClient, nil)
+	}
+	if retryOptions == nil {
+		retryOptions = &RetryOptions{}
+	}
+	attempts := r.config.Reconnect.MaxAttempts
+	if attempts <= 0 {
+		attempts = defaultLiveReconnectAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		conn, err := r.live.connect(r.ctx, r.model, &config)
+		if err == nil {
+			err = r.resumeLocked(s, conn)
+			if err != nil {
+				conn.Close()
+			}
+		}
+		if f := r.config.Reconnect.OnReconnect; f != nil {
+			f(LiveReconnectEvent{Attempt: attempt, GoAway: goAway, Cause: cause, Handle: sr.Handle, Replayed: len(r.pending), Err: err})
+		}
+		if err == nil {
+			s.conn = conn
+			r.goAway = nil
+			return nil
+		}
+		if attempt >= attempts || !liveReconnectable(err) {
+			return fmt.Errorf("failed to resume the session: %w", err)
+		}
+		if err := sleepContext(r.ctx, retryOptions.backoff(attempt)); err != nil {
+			return fmt.Errorf("failed to resume the session: %w", err)
+		}
+	}
+}
+
+// resumeLocked waits for the setup of a new connection, then sends the pending
+// messages again. The caller must hold r.mu.
+func (r *liveResume) resumeLocked(s *Session, conn *websocket.Conn) error {
+	// Close interrupts the wait.
+	stop := context.AfterFunc(r.ctx, func() { conn.Close() })
+	defer stop()
+	for {
+		messageType, msgBytes, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		message, err := s.decode(messageType, msgBytes)
+		if err != nil {
+			return err
+		}
+		if message.SetupComplete != nil {
+			break
+		}
+		if u := message.SessionResumptionUpdate; u != nil {
+			r.updateLocked(u)
+		}
+	}
+	// The indexes of the messages restart on the new connection.
+	r.sent = 0
+	for i := range r.pending {
+		r.sent++
+		r.pending[i].index = r.sent
+		if err := conn.WriteMessage(websocket.TextMessage, r.pending[i].data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *liveResume) isClosed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.closed
+}
+
+func (r *liveResume) close(s *Session) error {
+	// Interrupt a reconnection, which holds r.mu.
+	r.cancel()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return s.conn.Close()
+}
+
+// liveReconnectable reports whether a session can be resumed after the error
+// of its connection: network errors, transient close codes, and server errors
+// of the handshake. A normal closure ends the session.
+func liveReconnectable(err error) bool {
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) {
+		switch closeErr.Code {
+		case websocket.CloseGoingAway, websocket.CloseAbnormalClosure, websocket.CloseInternalServerErr,
+			websocket.CloseServiceRestart, websocket.CloseTryAgainLater:
+			return true
+		}
+		return false
+	}
+	var apiErr APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
@@ -0,0 +1,260 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// liveScript is the conversation of the server on one connection. Each step
+// either reads a client message and checks that it contains want, or writes
+// send, or closes the connection with closeCode, or closes the connection
+// abruptly.
+type liveScript []liveStep
+
+type liveStep struct {
+	want      string
+	send      string
+	closeCode int
+	abort     bool
+}
+
+// newLiveReconnectServer serves one script per connection, and records the
+// session resumption handle of each setup message.
+func newLiveReconnectServer(t *testing.T, scripts ...liveScript) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var handles []string
+	upgrader := websocket.Upgrader{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			t.Errorf("failed to read setup: %v", err)
+			return
+		}
+		var setup struct {
+			Setup struct {
+				SessionResumption *SessionResumptionConfig `json:"sessionResumption"`
+			} `json:"setup"`
+		}
+		json.Unmarshal(message, &setup)
+		mu.Lock()
+		n := len(handles)
+		if sr := setup.Setup.SessionResumption; sr != nil {
+			handles = append(handles, sr.Handle)
+		} else {
+			handles = append(handles, "<no session resumption>")
+		}
+		mu.Unlock()
+		if n >= len(scripts) {
+			t.Errorf("unexpected connection %d", n+1)
+			return
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"setupComplete":{}}`))
+		for _, step := range scripts[n] {
+			switch {
+			case step.abort:
+				conn.UnderlyingConn().Close()
+				return
+			case step.closeCode != 0:
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(step.closeCode, "closed"))
+				return
+			case step.send != "":
+				conn.WriteMessage(websocket.TextMessage, []byte(step.send))
+			default:
+				_, message, err := conn.ReadMessage()
+				if err != nil {
+					t.Errorf("connection %d: failed to read %q: %v", n+1, step.want, err)
+					return
+				}
+				if !strings.Contains(string(message), step.want) {
+					t.Errorf("connection %d: message = %s, want %q", n+1, message, step.want)
+				}
+			}
+		}
+		// Wait for the client to close the connection.
+		conn.ReadMessage()
+	}))
+	t.Cleanup(ts.Close)
+	return ts, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), handles...)
+	}
+}
+
+func newLiveReconnectClient(t *testing.T, ts *httptest.Server) *Client {
+	t.Helper()
+	return newTestClient(t, ts, &ClientConfig{
+		HTTPOptions: HTTPOptions{
+			BaseURL:      strings.Replace(ts.URL, "http", "ws", 1),
+			RetryOptions: &RetryOptions{InitialDelay: time.Millisecond},
+		},
+	})
+}
+
+func TestLiveReconnect(t *testing.T) {
+	ts, handles := newLiveReconnectServer(t,
+		liveScript{
+			{want: `"one"`},
+			{send: `{"sessionResumptionUpdate":{"newHandle":"h1","resumable":true}}`},
+			{want: `"two"`},
+			{send: `{"goAway":{"timeLeft":"10s"}}`},
+		},
+		liveScript{
+			// "one" was consumed before the update, "two" is sent again.
+			{want: `"two"`},
+			{send: `{"serverContent":{"modelTurn":{"parts":[{"text":"resumed"}]}}}`},
+			{send: `{"sessionResumptionUpdate":{"newHandle":"h2","resumable":true}}`},
+			{send: `{"sessionResumptionUpdate":{"resumable":false}}`},
+			{abort: true},
+		},
+		liveScript{
+			{send: `{"serverContent":{"modelTurn":{"parts":[{"text":"done"}]}}}`},
+		},
+	)
+	client := newLiveReconnectClient(t, ts)
+	var events []LiveReconnectEvent
+	session, err := client.Live.Connect(context.Background(), "test-model", &LiveConnectConfig{
+		Reconnect: &LiveReconnectConfig{OnReconnect: func(e LiveReconnectEvent) { events = append(events, e) }},
+	})
+	if err != nil {
+		t.Fatalf("Connect() failed: %v", err)
+	}
+	defer session.Close()
+
+	receive := func() *LiveServerMessage {
+		t.Helper()
+		message, err := session.Receive()
+		if err != nil {
+			t.Fatalf("Receive() failed: %v", err)
+		}
+		return message
+	}
+	if message := receive(); message.SetupComplete == nil {
+		t.Errorf("first message = %+v, want SetupComplete", message)
+	}
+	if err := session.SendRealtimeInput(LiveRealtimeInput{Text: "one"}); err != nil {
+		t.Fatalf("SendRealtimeInput() failed: %v", err)
+	}
+	if message := receive(); message.SessionResumptionUpdate == nil {
+		t.Errorf("message = %+v, want SessionResumptionUpdate", message)
+	}
+	if err := session.SendRealtimeInput(LiveRealtimeInput{Text: "two"}); err != nil {
+		t.Fatalf("SendRealtimeInput() failed: %v", err)
+	}
+	// The session reconnects when it receives GoAway, which is still surfaced.
+	if message := receive(); message.GoAway == nil {
+		t.Errorf("message = %+v, want GoAway", message)
+	}
+	if message := receive(); message.ServerContent == nil || message.ServerContent.ModelTurn.Parts[0].Text != "resumed" {
+		t.Errorf("message = %+v, want the resumed content", message)
+	}
+	receive()
+	receive()
+	// The connection fails: the session resumes with the last resumable handle.
+	if message := receive(); message.ServerContent == nil || message.ServerContent.ModelTurn.Parts[0].Text != "done" {
+		t.Errorf("message = %+v, want the content after the failure", message)
+	}
+
+	if got, want := handles(), []string{"", "h1", "h2"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("setup handles = %q, want %q", got, want)
+	}
+	if len(events) != 2 {
+		t.Fatalf("reconnect events = %+v, want 2", events)
+	}
+	if e := events[0]; e.GoAway == nil || e.Cause != nil || e.Handle != "h1" || e.Replayed != 1 || e.Err != nil {
+		t.Errorf("first event = %+v, want a GoAway reconnection with h1 and 1 replayed message", e)
+	}
+	if e := events[1]; e.GoAway != nil || e.Cause == nil || e.Handle != "h2" || e.Replayed != 0 || e.Err != nil {
+		t.Errorf("second event = %+v, want a reconnection after an error with h2", e)
+	}
+}
+
+func TestLiveReconnectWithoutHandle(t *testing.T) {
+	ts, _ := newLiveReconnectServer(t, liveScript{{abort: true}})
+	client := newLiveReconnectClient(t, ts)
+	session, err := client.Live.Connect(context.Background(), "test-model", &LiveConnectConfig{Reconnect: &LiveReconnectConfig{}})
+	if err != nil {
+		t.Fatalf("Connect() failed: %v", err)
+	}
+	defer session.Close()
+	if _, err := session.Receive(); err != nil {
+		t.Fatalf("Receive() failed: %v", err)
+	}
+	if _, err := session.Receive(); err == nil || !strings.Contains(err.Error(), "resumable handle") {
+		t.Errorf("Receive() error = %v, want an error about the missing handle", err)
+	}
+}
+
+func TestLiveReconnectCloseCodes(t *testing.T) {
+	tests := []struct {
+		code      int
+		reconnect bool
+	}{
+		{code: websocket.CloseNormalClosure},
+		{code: 4000},
+		{code: websocket.CloseInternalServerErr, reconnect: true},
+		{code: websocket.CloseTryAgainLater, reconnect: true},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.code), func(t *testing.T) {
+			ts, handles := newLiveReconnectServer(t,
+				liveScript{
+					{send: `{"sessionResumptionUpdate":{"newHandle":"h1","resumable":true}}`},
+					{closeCode: tt.code},
+				},
+				liveScript{
+					{send: `{"serverContent":{"turnComplete":true}}`},
+				},
+			)
+			client := newLiveReconnectClient(t, ts)
+			session, err := client.Live.Connect(context.Background(), "test-model", &LiveConnectConfig{Reconnect: &LiveReconnectConfig{}})
+			if err != nil {
+				t.Fatalf("Connect() failed: %v", err)
+			}
+			defer session.Close()
+			for range 2 {
+				if _, err := session.Receive(); err != nil {
+					t.Fatalf("Receive() failed: %v", err)
+				}
+			}
+			_, err = session.Receive()
+			if tt.reconnect && err != nil {
+				t.Errorf("Receive() after close code %d failed: %v, want reconnection", tt.code, err)
+			}
+			if !tt.reconnect && err == nil {
+				t.Errorf("Receive() after close code %d succeeded, want error", tt.code)
+			}
+			if want := map[bool]int{true: 2, false: 1}[tt.reconnect]; len(handles()) != want {
+				t.Errorf("%d connections, want %d", len(handles()), want)
+			}
+		})
+	}
+}
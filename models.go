@@ -4825,7 +4825,25 @@ func (m Models) GenerateContent(ctx context.Context, model string, contents []*C
 	if config != nil {
 		config.setDefaults()
 	}
-	return m.generateContent(ctx, model, contents, config)
+	if m.apiClient.clientConfig.ValidateRequests {
+		if err := ValidateGenerateContent(m.apiClient.clientConfig.Backend, contents, config); err != nil {
+			return nil, err
+		}
+	}
+	var resp *GenerateContentResponse
+	var err error
+	if config.automaticFunctionCalling() != nil {
+		resp, err = m.generateContentWithFunctionCalls(ctx, model, contents, config)
+	} else {
+		resp, err = m.generateContent(ctx, model, contents, config)
+	}
+	if err != nil || config == nil || !config.Strict {
+		return resp, err
+	}
+	if err := checkStrictResponse(resp, nil); err != nil {
+		return nil, err
+	}
+	return resp, nil
 }
 
 // GenerateContentStream generates a stream of content based on the provided model, contents, and configuration.
@@ -4833,7 +4851,23 @@ func (m Models) GenerateContentStream(ctx context.Context, model string, content
 	if config != nil {
 		config.setDefaults()
 	}
-	return m.generateContentStream(ctx, model, contents, config)
+	if m.apiClient.clientConfig.ValidateRequests {
+		if err := ValidateGenerateContentStream(m.apiClient.clientConfig.Backend, contents, config); err != nil {
+			return func(yield func(*GenerateContentResponse, error) bool) {
+				yield(nil, err)
+			}
+		}
+	}
+	var stream iter.Seq2[*GenerateContentResponse, error]
+	if config.automaticFunctionCalling() != nil {
+		stream = m.generateContentStreamWithFunctionCalls(ctx, model, contents, config)
+	} else {
+		stream = m.generateContentStream(ctx, model, contents, config)
+	}
+	if config == nil || !config.Strict {
+		return stream
+	}
+	return strictStream(stream)
 }
 
 // List retrieves a paginated list of models resources.
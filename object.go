@@ -0,0 +1,413 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// GenerateObjectConfig configures [GenerateObject] and [GenerateObjectStream].
+type GenerateObjectConfig struct {
+	// GenerateContentConfig is the configuration of the requests. Its
+	// ResponseMIMEType is set to application/json, and its ResponseSchema
+	// defaults to the schema of the generated type.
+	GenerateContentConfig
+	// RepairAttempts is the maximum number of times that GenerateObject sends an
+	// invalid response back to the model, together with the reason why it is
+	// invalid, and asks for a corrected one. Defaults to 0, which returns an
+	// *InvalidObjectError for the first invalid response.
+	RepairAttempts int
+}
+
+// InvalidObjectError is returned when the text of a response can't be decoded
+// into the requested type, or doesn't conform to the response schema.
+type InvalidObjectError struct {
+	// Text is the JSON text of the response.
+	Text string
+	// Response is the invalid response.
+	Response *GenerateContentResponse
+	// Err is the decoding or validation error.
+	Err error
+}
+
+func (e *InvalidObjectError) Error() string {
+	return fmt.Sprintf("invalid object in response: %v", e.Err)
+}
+
+func (e *InvalidObjectError) Unwrap() error {
+	return e.Err
+}
+
+// GenerateObject generates a JSON response that conforms to the schema of T and
+// decodes it into a value of type T. See [SchemaFor] for how the schema is
+// derived from T.
+//
+// The response text may be wrapped in a Markdown code fence. The decoded value is
+// validated against the response schema, and invalid responses are repaired as
+// configured by GenerateObjectConfig.RepairAttempts.
+func GenerateObject[T any](ctx context.Context, models *Models, model string, contents []*Content, config *GenerateObjectConfig) (T, *GenerateContentResponse, error) {
+	var value T
+	requestConfig, err := objectRequestConfig[T](config)
+	if err != nil {
+		return value, nil, fmt.Errorf("GenerateObject: %w", err)
+	}
+	repairAttempts := 0
+	if config != nil {
+		repairAttempts = config.RepairAttempts
+	}
+
+	contents = slices.Clip(contents)
+	for attempt := 0; ; attempt++ {
+		resp, err := models.GenerateContent(ctx, model, contents, requestConfig)
+		if err != nil {
+			return value, nil, err
+		}
+		text := extractJSON(resp.Text())
+		var decoded T
+		err = decodeObject(text, requestConfig.ResponseSchema, &decoded)
+		if err == nil {
+			return decoded, resp, nil
+		}
+		if attempt >= repairAttempts || len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+			return value, resp, &InvalidObjectError{Text: text, Response: resp, Err: err}
+		}
+		contents = append(contents, resp.Candidates[0].Content, NewContentFromText(
+			fmt.Sprintf("The previous response is invalid: %v. Respond again with JSON that conforms to the response schema.", err),
+			RoleUser,
+		))
+	}
+}
+
+// GenerateObjectStream is the streaming version of [GenerateObject]. It yields a
+// new value of type T each time the stream completes more of the object. Values
+// decoded from an incomplete object have the zero value in the fields that are
+// not generated yet. The last value is the complete object, validated against
+// the response schema; if it is invalid, the stream ends with an
+// *InvalidObjectError instead. Responses are not repaired.
+func GenerateObjectStream[T any](ctx context.Context, models *Models, model string, contents []*Content, config *GenerateObjectConfig) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		requestConfig, err := objectRequestConfig[T](config)
+		if err != nil {
+			yield(nil, fmt.Errorf("GenerateObjectStream: %w", err))
+			return
+		}
+		var text strings.Builder
+		var last *GenerateContentResponse
+		var yielded string
+		for resp, err := range models.GenerateContentStream(ctx, model, contents, requestConfig) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			last = resp
+			text.WriteString(resp.Text())
+			partial, ok := completeJSON(extractJSON(text.String()))
+			if !ok || partial == yielded {
+				continue
+			}
+			value := new(T)
+			if err := json.Unmarshal([]byte(partial), value); err != nil {
+				continue
+			}
+			yielded = partial
+			if !yield(value, nil) {
+				return
+			}
+		}
+
+		complete := extractJSON(text.String())
+		value := new(T)
+		if err := decodeObject(complete, requestConfig.ResponseSchema, value); err != nil {
+			yield(nil, &InvalidObjectError{Text: complete, Response: last, Err: err})
+			return
+		}
+		yield(value, nil)
+	}
+}
+
+// objectRequestConfig returns the configuration of the requests that generate a
+// value of type T.
+func objectRequestConfig[T any](config *GenerateObjectConfig) (*GenerateContentConfig, error) {
+	var requestConfig GenerateContentConfig
+	if config != nil {
+		requestConfig = config.GenerateContentConfig
+	}
+	requestConfig.ResponseMIMEType = "application/json"
+	if requestConfig.ResponseSchema == nil {
+		schema, err := SchemaFor[T]()
+		if err != nil {
+			return nil, err
+		}
+		requestConfig.ResponseSchema = schema
+	}
+	return &requestConfig, nil
+}
+
+// decodeObject validates the JSON text against schema and decodes it into v.
+func decodeObject(text string, schema *Schema, v any) error {
+	if text == "" {
+		return errors.New("response has no text")
+	}
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("response is not valid JSON: %w", err)
+	}
+	if decoder.More() {
+		return errors.New("response has data after the JSON value")
+	}
+	if err := validateValue(schema, value, "$"); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(text), v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// validateValue reports the first place where value, decoded with
+// json.Decoder.UseNumber, doesn't conform to schema. path locates value in the
+// response.
+func validateValue(schema *Schema, value any, path string) error {
+	if schema == nil {
+		return nil
+	}
+	if value == nil {
+		if schema.Nullable != nil && *schema.Nullable {
+			return nil
+		}
+		if schema.Type == "" || schema.Type == TypeUnspecified {
+			return nil
+		}
+		return fmt.Errorf("%s: must not be null", path)
+	}
+	if len(schema.AnyOf) > 0 {
+		var errs []error
+		for _, s := range schema.AnyOf {
+			err := validateValue(s, value, path)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err)
+		}
+		return fmt.Errorf("%s: doesn't match any schema of anyOf: %w", path, errors.Join(errs...))
+	}
+
+	switch schema.Type {
+	case TypeObject:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: must be an object", path)
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		if schema.MinProperties != nil && int64(len(object)) < *schema.MinProperties {
+			return fmt.Errorf("%s: must have at least %d properties", path, *schema.MinProperties)
+		}
+		if schema.MaxProperties != nil && int64(len(object)) > *schema.MaxProperties {
+			return fmt.Errorf("%s: must have at most %d properties", path, *schema.MaxProperties)
+		}
+		for name, property := range schema.Properties {
+			if v, ok := object[name]; ok {
+				if err := validateValue(property, v, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case TypeArray:
+		array, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: must be an array", path)
+		}
+		if schema.MinItems != nil && int64(len(array)) < *schema.MinItems {
+			return fmt.Errorf("%s: must have at least %d items", path, *schema.MinItems)
+		}
+		if schema.MaxItems != nil && int64(len(array)) > *schema.MaxItems {
+			return fmt.Errorf("%s: must have at most %d items", path, *schema.MaxItems)
+		}
+		for i, v := range array {
+			if err := validateValue(schema.Items, v, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case TypeString:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: must be a string", path)
+		}
+		if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, s) {
+			return fmt.Errorf("%s: %q is not one of %q", path, s, schema.Enum)
+		}
+		length := int64(len([]rune(s)))
+		if schema.MinLength != nil && length < *schema.MinLength {
+			return fmt.Errorf("%s: must have at least %d characters", path, *schema.MinLength)
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			return fmt.Errorf("%s: must have at most %d characters", path, *schema.MaxLength)
+		}
+		if schema.Pattern != "" {
+			re, err := regexp.Compile(schema.Pattern)
+			if err != nil {
+				return fmt.Errorf("%s: invalid pattern %q in schema: %w", path, schema.Pattern, err)
+			}
+			if !re.MatchString(s) {
+				return fmt.Errorf("%s: %q doesn't match pattern %q", path, s, schema.Pattern)
+			}
+		}
+	case TypeNumber, TypeInteger:
+		n, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s: must be a number", path)
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return fmt.Errorf("%s: invalid number %s", path, n)
+		}
+		if schema.Type == TypeInteger && f != math.Trunc(f) {
+			return fmt.Errorf("%s: must be an integer", path)
+		}
+		if schema.Minimum != nil && f < *schema.Minimum {
+			return fmt.Errorf("%s: must be at least %v", path, *schema.Minimum)
+		}
+		if schema.Maximum != nil && f > *schema.Maximum {
+			return fmt.Errorf("%s: must be at most %v", path, *schema.Maximum)
+		}
+	case TypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: must be a boolean", path)
+		}
+	}
+	return nil
+}
+
+// extractJSON returns the JSON text of a response, removing the Markdown code
+// fence that models sometimes wrap it in. The closing fence may be missing from
+// a response that is still being streamed.
+func extractJSON(text string) string {
+	text = strings.TrimSpace(text)
+	start := strings.Index(text, "```")
+	if start < 0 {
+		return text
+	}
+	fenced := text[start+3:]
+	// Skip the language of the fence, such as json.
+	if i := strings.IndexByte(fenced, '\n'); i >= 0 {
+		fenced = fenced[i+1:]
+	} else {
+		return ""
+	}
+	if end := strings.Index(fenced, "```"); end >= 0 {
+		fenced = fenced[:end]
+	}
+	return strings.TrimSpace(fenced)
+}
+
+// completeJSON closes the strings, arrays and objects left open in a prefix of a
+// JSON value, so that it can be decoded. Incomplete keys and literals at the end
+// of the prefix are dropped, as are numbers, which may continue in the rest of
+// the value. It reports false if the prefix can't be completed.
+func completeJSON(prefix string) (string, bool) {
+	var closers []byte
+	inString, escaped := false, false
+	// safe is the end of the longest prefix that is valid once closed with
+	// safeClosers.
+	safe, safeClosers := -1, ""
+	closing := func() string {
+		b := make([]byte, len(closers))
+		for i, c := range closers {
+			b[len(closers)-1-i] = c
+		}
+		return string(b)
+	}
+
+	for i := 0; i < len(prefix); i++ {
+		c := prefix[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			if c == '{' {
+				closers = append(closers, '}')
+			} else {
+				closers = append(closers, ']')
+			}
+			safe, safeClosers = i+1, closing()
+		case '}', ']':
+			if len(closers) == 0 || closers[len(closers)-1] != c {
+				return "", false
+			}
+			closers = closers[:len(closers)-1]
+			safe, safeClosers = i+1, closing()
+		case ',':
+			safe, safeClosers = i, closing()
+		}
+	}
+
+	// A number at the end of the prefix may be incomplete.
+	if inString || !endsWithNumber(prefix) {
+		candidate := prefix
+		if inString {
+			if escaped {
+				candidate = candidate[:len(candidate)-1]
+			}
+			candidate += `"`
+		}
+		candidate += closing()
+		if json.Valid([]byte(candidate)) {
+			return candidate, true
+		}
+	}
+	if safe < 0 {
+		return "", false
+	}
+	candidate := prefix[:safe] + safeClosers
+	if !json.Valid([]byte(candidate)) {
+		return "", false
+	}
+	return candidate, true
+}
+
+// endsWithNumber reports whether s ends with a number, rather than with a
+// literal such as true.
+func endsWithNumber(s string) bool {
+	i := len(s)
+	for i > 0 && strings.IndexByte("0123456789+-.eE", s[i-1]) >= 0 {
+		i--
+	}
+	return i < len(s) && (s[i] == '-' || '0' <= s[i] && s[i] <= '9')
+}
@@ -0,0 +1,225 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type recipe struct {
+	Name        string   `json:"name"`
+	Servings    int      `json:"servings"`
+	Ingredients []string `json:"ingredients"`
+	Difficulty  string   `json:"difficulty,omitempty" enum:"easy,hard"`
+}
+
+// objectTextResponse returns a GenerateContentResponse body whose text is text.
+func objectTextResponse(t *testing.T, text string) string {
+	t.Helper()
+	b, err := json.Marshal(map[string]any{
+		"candidates": []any{map[string]any{"content": map[string]any{"role": "model", "parts": []any{map[string]any{"text": text}}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestGenerateObject(t *testing.T) {
+	ctx := context.Background()
+	ts, requests := functionCallingServer(t, false, objectTextResponse(t, "```json\n{\"name\": \"Pancakes\", \"servings\": 4, \"ingredients\": [\"flour\", \"milk\"]}\n```"))
+	defer ts.Close()
+	client := newTestClient(t, ts, nil)
+
+	got, resp, err := GenerateObject[recipe](ctx, client.Models, "gemini-2.0-flash", Text("pancakes"), &GenerateObjectConfig{
+		GenerateContentConfig: GenerateContentConfig{Temperature: Ptr[float32](0.5)},
+	})
+	if err != nil {
+		t.Fatalf("GenerateObject() failed: %v", err)
+	}
+	if resp == nil {
+		t.Error("GenerateObject() response is nil")
+	}
+	want := recipe{Name: "Pancakes", Servings: 4, Ingredients: []string{"flour", "milk"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GenerateObject() mismatch (-want +got):\n%s", diff)
+	}
+
+	generationConfig := (*requests)[0]["generationConfig"].(map[string]any)
+	if generationConfig["responseMimeType"] != "application/json" {
+		t.Errorf("responseMimeType = %v, want application/json", generationConfig["responseMimeType"])
+	}
+	schema := generationConfig["responseSchema"].(map[string]any)
+	if diff := cmp.Diff([]any{"name", "servings", "ingredients"}, schema["required"]); diff != "" {
+		t.Errorf("responseSchema.required mismatch (-want +got):\n%s", diff)
+	}
+	if generationConfig["temperature"] != 0.5 {
+		t.Errorf("temperature = %v, want 0.5", generationConfig["temperature"])
+	}
+}
+
+func TestGenerateObjectRepair(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		desc           string
+		repairAttempts int
+		wantRequests   int
+		wantErr        string
+	}{
+		{desc: "no repair", repairAttempts: 0, wantRequests: 1, wantErr: `$.difficulty: "medium" is not one of`},
+		{desc: "repaired", repairAttempts: 2, wantRequests: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ts, requests := functionCallingServer(t, false,
+				objectTextResponse(t, `{"name": "Soup", "servings": 2, "ingredients": [], "difficulty": "medium"}`),
+				objectTextResponse(t, `{"name": "Soup", "servings": 2, "ingredients": [], "difficulty": "easy"}`),
+			)
+			defer ts.Close()
+			client := newTestClient(t, ts, nil)
+
+			got, _, err := GenerateObject[recipe](ctx, client.Models, "gemini-2.0-flash", Text("soup"), &GenerateObjectConfig{RepairAttempts: tt.repairAttempts})
+			if len(*requests) != tt.wantRequests {
+				t.Errorf("got %d requests, want %d", len(*requests), tt.wantRequests)
+			}
+			if tt.wantErr != "" {
+				var invalid *InvalidObjectError
+				if !errors.As(err, &invalid) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("GenerateObject() error = %v, want *InvalidObjectError containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateObject() failed: %v", err)
+			}
+			if got.Difficulty != "easy" {
+				t.Errorf("GenerateObject() = %+v, want the repaired recipe", got)
+			}
+			contents := (*requests)[1]["contents"].([]any)
+			if len(contents) != 3 {
+				t.Fatalf("repair request has %d contents, want 3", len(contents))
+			}
+			repair := contents[2].(map[string]any)["parts"].([]any)[0].(map[string]any)["text"].(string)
+			if !strings.Contains(repair, "is not one of") {
+				t.Errorf("repair prompt = %q, want the validation error", repair)
+			}
+		})
+	}
+}
+
+func TestGenerateObjectStream(t *testing.T) {
+	ctx := context.Background()
+	chunks := []string{`{"name": "Sal`, `ad", "servings": 1, "ingredients": ["let`, `tuce", "tomato"]}`}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data:%s\n\n", objectTextResponse(t, chunk))
+		}
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts, nil)
+
+	var got []recipe
+	for value, err := range GenerateObjectStream[recipe](ctx, client.Models, "gemini-2.0-flash", Text("salad"), nil) {
+		if err != nil {
+			t.Fatalf("GenerateObjectStream() failed: %v", err)
+		}
+		got = append(got, *value)
+	}
+	want := []recipe{
+		{Name: "Sal"},
+		{Name: "Salad", Servings: 1, Ingredients: []string{"let"}},
+		{Name: "Salad", Servings: 1, Ingredients: []string{"lettuce", "tomato"}},
+		{Name: "Salad", Servings: 1, Ingredients: []string{"lettuce", "tomato"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GenerateObjectStream() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCompleteJSON(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+		wantOK bool
+	}{
+		{`{"a": "he`, `{"a": "he"}`, true},
+		{`{"a": 1, "b`, `{"a": 1}`, true},
+		{`{"a": [1, 2`, `{"a": [1]}`, true},
+		{`{"n":12`, `{}`, true},
+		{`{"n": -1.5e`, `{}`, true},
+		{`{"a": true`, `{"a": true}`, true},
+		{`12`, ``, false},
+		{`{"a": tr`, `{}`, true},
+		{`{"a": "x\`, `{"a": "x"}`, true},
+		{`[{"a": 1}, {`, `[{"a": 1}, {}]`, true},
+		{`{"a": 1}`, `{"a": 1}`, true},
+		{``, ``, false},
+		{`{"a": 1]`, ``, false},
+	}
+	for _, tt := range tests {
+		got, ok := completeJSON(tt.prefix)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("completeJSON(%q) = %q, %v, want %q, %v", tt.prefix, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestValidateValue(t *testing.T) {
+	schema := &Schema{
+		Type:     TypeObject,
+		Required: []string{"id"},
+		Properties: map[string]*Schema{
+			"id":    {Type: TypeInteger, Minimum: Ptr(1.0)},
+			"tags":  {Type: TypeArray, Items: &Schema{Type: TypeString, MaxLength: Ptr[int64](3)}},
+			"note":  {Type: TypeString, Nullable: Ptr(true)},
+			"code":  {Type: TypeString, Pattern: `^[A-Z]+$`},
+			"value": {AnyOf: []*Schema{{Type: TypeNumber}, {Type: TypeBoolean}}},
+		},
+	}
+	tests := []struct {
+		value   string
+		wantErr string
+	}{
+		{`{"id": 1, "tags": ["abc"], "note": null, "code": "AB", "value": true}`, ""},
+		{`{"tags": []}`, `$: missing required property "id"`},
+		{`{"id": 1.5}`, "$.id: must be an integer"},
+		{`{"id": 0}`, "$.id: must be at least 1"},
+		{`{"id": 1, "tags": ["abcd"]}`, "$.tags[0]: must have at most 3 characters"},
+		{`{"id": 1, "code": "ab"}`, `$.code: "ab" doesn't match pattern`},
+		{`{"id": 1, "value": "x"}`, "$.value: doesn't match any schema of anyOf"},
+		{`[]`, "$: must be an object"},
+	}
+	for _, tt := range tests {
+		err := decodeObject(tt.value, schema, new(any))
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("decodeObject(%s) failed: %v", tt.value, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("decodeObject(%s) error = %v, want error containing %q", tt.value, err, tt.wantErr)
+		}
+	}
+}
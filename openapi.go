@@ -0,0 +1,233 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// openAPIMethods are the operations of an OpenAPI path item, in the order in
+// which they are declared.
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// maxFunctionNameLength is the maximum length of the name of a function declaration.
+const maxFunctionNameLength = 64
+
+var invalidFunctionNameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// FunctionDeclarationsFromOpenAPI returns a function declaration for each
+// operation of an OpenAPI 3 document encoded in JSON.
+//
+// A function is named after the operationId of its operation, or else after its
+// method and path, with the characters that function names don't allow replaced
+// by underscores. Its description is the summary and description of the
+// operation. Its parameters are an object with a property for each parameter of
+// the operation, and a "body" property for its application/json request body.
+// Parameters with the same name in different locations, such as a path and a
+// query parameter, and a parameter named "body" of an operation with a request
+// body, are reported as errors.
+//
+// Schemas are converted as described in [SchemaFromJSONSchema], and references
+// are resolved against the whole document. Keywords that Schema can't express
+// are reported with an *UnsupportedSchemaError, returned together with the
+// declarations.
+func FunctionDeclarationsFromOpenAPI(data []byte) ([]*FunctionDeclaration, error) {
+	var root any
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("FunctionDeclarationsFromOpenAPI: invalid JSON: %w", err)
+	}
+	doc, ok := root.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("FunctionDeclarationsFromOpenAPI: document must be an object")
+	}
+	if version, _ := doc["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("FunctionDeclarationsFromOpenAPI: unsupported OpenAPI version %q, want 3.x", version)
+	}
+
+	im := newSchemaImporter(root)
+	paths, _ := doc["paths"].(map[string]any)
+	var declarations []*FunctionDeclaration
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		pathPointer := "/paths/" + escapeJSONPointer(path)
+		item, ok := paths[path].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("FunctionDeclarationsFromOpenAPI: %s: path item must be an object", pathPointer)
+		}
+		for _, method := range openAPIMethods {
+			operation, ok := item[method].(map[string]any)
+			if !ok {
+				continue
+			}
+			declaration, err := im.operationDeclaration(item, operation, method, path, pathPointer)
+			if err != nil {
+				return nil, fmt.Errorf("FunctionDeclarationsFromOpenAPI: %w", err)
+			}
+			declarations = append(declarations, declaration)
+		}
+	}
+	return declarations, im.err()
+}
+
+// operationDeclaration returns the function declaration of the operation of
+// method on the path item.
+func (im *schemaImporter) operationDeclaration(item, operation map[string]any, method, path, pathPointer string) (*FunctionDeclaration, error) {
+	pointer := pathPointer + "/" + method
+	name, _ := operation["operationId"].(string)
+	if name == "" {
+		name = method + path
+	}
+	name = strings.Trim(invalidFunctionNameChars.ReplaceAllString(name, "_"), "_")
+	if len(name) > maxFunctionNameLength {
+		name = name[:maxFunctionNameLength]
+	}
+
+	var description []string
+	for _, key := range []string{"summary", "description"} {
+		if text, _ := operation[key].(string); text != "" {
+			description = append(description, strings.TrimSpace(text))
+		}
+	}
+	declaration := &FunctionDeclaration{Name: name, Description: strings.Join(description, "\n\n")}
+
+	parameters := &Schema{Type: TypeObject, Properties: make(map[string]*Schema)}
+	// locations holds the location of each parameter, such as "query".
+	locations := make(map[string]string)
+	// Parameters of the operation override the ones of the path item.
+	for _, source := range []struct {
+		node    map[string]any
+		pointer string
+	}{{item, pathPointer}, {operation, pointer}} {
+		list, _ := source.node["parameters"].([]any)
+		for i, node := range list {
+			if err := im.addParameter(parameters, locations, node, fmt.Sprintf("%s/parameters/%d", source.pointer, i)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if body, ok := operation["requestBody"]; ok {
+		if err := im.addRequestBody(parameters, body, pointer+"/requestBody"); err != nil {
+			return nil, err
+		}
+	}
+	if len(parameters.Properties) > 0 {
+		declaration.Parameters = parameters
+	}
+	return declaration, nil
+}
+
+// addParameter adds the OpenAPI parameter object node, found at pointer, to the
+// properties of parameters. A parameter overrides the parameter with the same
+// name in the same location, and collides with one in another location.
+func (im *schemaImporter) addParameter(parameters *Schema, locations map[string]string, node any, pointer string) error {
+	parameter, pointer, err := im.dereference(node, pointer)
+	if err != nil {
+		return err
+	}
+	name, _ := parameter["name"].(string)
+	if name == "" {
+		return fmt.Errorf("%s: parameter must have a name", pointer)
+	}
+	in, _ := parameter["in"].(string)
+	if location, ok := locations[name]; ok && location != in {
+		return fmt.Errorf("%s: parameter %q in %s collides with the parameter %q in %s", pointer, name, in, name, location)
+	}
+	locations[name] = in
+	schema, err := im.mediaSchema(parameter, pointer)
+	if err != nil {
+		return err
+	}
+	if schema.Description == "" {
+		schema.Description, _ = parameter["description"].(string)
+	}
+	if _, ok := parameters.Properties[name]; !ok {
+		parameters.PropertyOrdering = append(parameters.PropertyOrdering, name)
+	}
+	parameters.Properties[name] = schema
+	parameters.Required = slices.DeleteFunc(parameters.Required, func(n string) bool { return n == name })
+	if required, _ := parameter["required"].(bool); required || in == "path" {
+		parameters.Required = append(parameters.Required, name)
+	}
+	return nil
+}
+
+// addRequestBody adds the OpenAPI request body object node, found at pointer, to
+// parameters as the "body" property.
+func (im *schemaImporter) addRequestBody(parameters *Schema, node any, pointer string) error {
+	body, pointer, err := im.dereference(node, pointer)
+	if err != nil {
+		return err
+	}
+	schema, err := im.mediaSchema(body, pointer)
+	if err != nil {
+		return err
+	}
+	if schema.Description == "" {
+		schema.Description, _ = body["description"].(string)
+	}
+	if _, ok := parameters.Properties["body"]; ok {
+		return fmt.Errorf("%s: request body collides with the parameter \"body\"", pointer)
+	}
+	parameters.Properties["body"] = schema
+	parameters.PropertyOrdering = append(parameters.PropertyOrdering, "body")
+	if required, _ := body["required"].(bool); required {
+		parameters.Required = append(parameters.Required, "body")
+	}
+	return nil
+}
+
+// mediaSchema converts the schema of a parameter or request body object, which
+// is either in its schema field or in the application/json entry of its content.
+func (im *schemaImporter) mediaSchema(node map[string]any, pointer string) (*Schema, error) {
+	if schema, ok := node["schema"]; ok {
+		return im.convert(schema, pointer+"/schema")
+	}
+	content, _ := node["content"].(map[string]any)
+	mediaType, ok := content["application/json"].(map[string]any)
+	if !ok {
+		im.unsupportedKeyword(pointer + "/content")
+		return &Schema{}, nil
+	}
+	schema, ok := mediaType["schema"]
+	if !ok {
+		return &Schema{}, nil
+	}
+	return im.convert(schema, pointer+"/content/application~1json/schema")
+}
+
+// dereference returns the object that node, found at pointer, refers to if it
+// is a reference object, and the pointer of that object.
+func (im *schemaImporter) dereference(node any, pointer string) (map[string]any, string, error) {
+	for range 10 {
+		object, ok := node.(map[string]any)
+		if !ok {
+			return nil, "", fmt.Errorf("%s: must be an object", pointer)
+		}
+		ref, ok := object["$ref"].(string)
+		if !ok {
+			return object, pointer, nil
+		}
+		target, targetPointer, err := resolveJSONPointer(im.root, ref)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", pointer, err)
+		}
+		node, pointer = target, targetPointer
+	}
+	return nil, "", fmt.Errorf("%s: too many nested references", pointer)
+}
@@ -0,0 +1,155 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFunctionDeclarationsFromOpenAPI(t *testing.T) {
+	doc := `{
+		"openapi": "3.0.3",
+		"info": {"title": "Pets", "version": "1.0"},
+		"paths": {
+			"/pets/{petId}": {
+				"parameters": [{"$ref": "#/components/parameters/petId"}],
+				"get": {
+					"operationId": "getPet",
+					"summary": "Returns a pet.",
+					"parameters": [{"name": "fields", "in": "query", "description": "Fields to return.", "schema": {"type": "array", "items": {"type": "string"}}}]
+				},
+				"put": {
+					"summary": "Updates a pet.",
+					"requestBody": {"$ref": "#/components/requestBodies/pet"}
+				}
+			},
+			"/pets": {
+				"get": {"operationId": "listPets", "description": "Lists pets."}
+			}
+		},
+		"components": {
+			"parameters": {
+				"petId": {"name": "petId", "in": "path", "schema": {"type": "string", "format": "uuid"}}
+			},
+			"requestBodies": {
+				"pet": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}
+			},
+			"schemas": {
+				"Pet": {
+					"type": "object",
+					"properties": {"name": {"type": "string"}, "tag": {"type": "string", "nullable": true}},
+					"required": ["name"]
+				}
+			}
+		}
+	}`
+	got, err := FunctionDeclarationsFromOpenAPI([]byte(doc))
+	if err != nil {
+		t.Fatalf("FunctionDeclarationsFromOpenAPI() failed: %v", err)
+	}
+	petID := &Schema{Type: TypeString, Format: "uuid"}
+	want := []*FunctionDeclaration{
+		{Name: "listPets", Description: "Lists pets."},
+		{
+			Name:        "getPet",
+			Description: "Returns a pet.",
+			Parameters: &Schema{
+				Type: TypeObject,
+				Properties: map[string]*Schema{
+					"petId":  petID,
+					"fields": {Type: TypeArray, Items: &Schema{Type: TypeString}, Description: "Fields to return."},
+				},
+				PropertyOrdering: []string{"petId", "fields"},
+				Required:         []string{"petId"},
+			},
+		},
+		{
+			Name:        "put_pets_petId",
+			Description: "Updates a pet.",
+			Parameters: &Schema{
+				Type: TypeObject,
+				Properties: map[string]*Schema{
+					"petId": petID,
+					"body": {
+						Type: TypeObject,
+						Properties: map[string]*Schema{
+							"name": {Type: TypeString},
+							"tag":  {Type: TypeString, Nullable: Ptr(true)},
+						},
+						Required: []string{"name"},
+					},
+				},
+				PropertyOrdering: []string{"petId", "body"},
+				Required:         []string{"petId", "body"},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("FunctionDeclarationsFromOpenAPI() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFunctionDeclarationsFromOpenAPIErrors(t *testing.T) {
+	_, err := FunctionDeclarationsFromOpenAPI([]byte(`{"swagger": "2.0", "paths": {}}`))
+	if err == nil || !strings.Contains(err.Error(), "unsupported OpenAPI version") {
+		t.Errorf("FunctionDeclarationsFromOpenAPI() error = %v, want unsupported version error", err)
+	}
+
+	doc := `{
+		"openapi": "3.1.0",
+		"paths": {
+			"/upload": {
+				"post": {
+					"operationId": "upload",
+					"requestBody": {"content": {"multipart/form-data": {"schema": {"type": "object"}}}}
+				}
+			}
+		}
+	}`
+	got, err := FunctionDeclarationsFromOpenAPI([]byte(doc))
+	var unsupported *UnsupportedSchemaError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("FunctionDeclarationsFromOpenAPI() error = %v, want *UnsupportedSchemaError", err)
+	}
+	if diff := cmp.Diff([]string{"/paths/~1upload/post/requestBody/content"}, unsupported.Keywords); diff != "" {
+		t.Errorf("unsupported keywords mismatch (-want +got):\n%s", diff)
+	}
+	if len(got) != 1 || got[0].Name != "upload" {
+		t.Errorf("FunctionDeclarationsFromOpenAPI() = %v, want the upload declaration", got)
+	}
+
+	collisions := []struct {
+		desc       string
+		parameters string
+		body       string
+		wantErr    string
+	}{
+		{"locations", `[{"name": "id", "in": "path"}, {"name": "id", "in": "query"}]`, "", `parameter "id" in query collides`},
+		{"body", `[{"name": "body", "in": "query"}]`, `, "requestBody": {"content": {"application/json": {"schema": {"type": "object"}}}}`, `request body collides`},
+	}
+	for _, tt := range collisions {
+		t.Run(tt.desc, func(t *testing.T) {
+			doc := `{"openapi": "3.0.0", "paths": {"/items/{id}": {"put": {"operationId": "put", "parameters": ` + tt.parameters + tt.body + `}}}}`
+			_, err := FunctionDeclarationsFromOpenAPI([]byte(doc))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("FunctionDeclarationsFromOpenAPI() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ListOperationsConfig configures [Operations.List].
+type ListOperationsConfig struct {
+	// Used to override HTTP request options.
+	HTTPOptions *HTTPOptions `json:"httpOptions,omitempty"`
+	// PageSize specifies the maximum number of operations to return per API call.
+	// If zero, the server will use a default value.
+	PageSize int32 `json:"pageSize,omitempty"`
+	// PageToken represents a token used for pagination in API responses. It's an opaque
+	// string that should be passed to subsequent requests to retrieve the next page of
+	// results. An empty PageToken typically indicates that there are no further pages available.
+	PageToken string `json:"pageToken,omitempty"`
+	// Optional. The standard list filter, such as "done=false".
+	Filter string `json:"filter,omitempty"`
+	// Optional. The resource whose operations are listed, such as
+	// "models/veo-2.0-generate-001". If empty, the operations of the project are
+	// listed. The field is not sent to the server.
+	Parent string `json:"-"`
+}
+
+// CancelOperationConfig configures [Operations.Cancel].
+type CancelOperationConfig struct {
+	// Used to override HTTP request options.
+	HTTPOptions *HTTPOptions `json:"httpOptions,omitempty"`
+}
+
+// DeleteOperationConfig configures [Operations.Delete].
+type DeleteOperationConfig struct {
+	// Used to override HTTP request options.
+	HTTPOptions *HTTPOptions `json:"httpOptions,omitempty"`
+}
+
+// operationResource is the wire form of a google.longrunning.Operation.
+type operationResource struct {
+	Name     string         `json:"name,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+	Done     bool           `json:"done,omitempty"`
+	Error    map[string]any `json:"error,omitempty"`
+	Response map[string]any `json:"response,omitempty"`
+}
+
+func (m Operations) newOperation(o *operationResource) (*Operation[map[string]any], error) {
+	opErr, err := newOperationError(o.Name, o.Error)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the error of operation %s: %w", o.Name, err)
+	}
+	return &Operation[map[string]any]{
+		Name:     o.Name,
+		Metadata: o.Metadata,
+		Done:     o.Done,
+		Error:    opErr,
+		Response: o.Response,
+		fetch:    m.getOperation,
+	}, nil
+}
+
+// getOperation gets an operation of any type. On Vertex AI, the operations of
+// publisher models, such as video generations, are fetched from their model.
+func (m Operations) getOperation(ctx context.Context, name string, httpOptions *HTTPOptions) (*Operation[map[string]any], error) {
+	ctx = withOperation(ctx, "Operations.Get")
+	httpOptions = mergeHTTPOptions(m.apiClient.clientConfig, httpOptions)
+	method, path, body := http.MethodGet, name, map[string]any(nil)
+	if m.apiClient.clientConfig.Backend == BackendVertexAI && strings.Contains(name, "/publishers/") {
+		resourceName, _, ok := strings.Cut(name, "/operations/")
+		if !ok {
+			return nil, fmt.Errorf("Invalid operation name")
+		}
+		method, path, body = http.MethodPost, resourceName+":fetchPredictOperation", map[string]any{"operationName": name}
+	}
+	responseMap, err := sendRequest(ctx, m.apiClient, path, method, body, httpOptions)
+	if err != nil {
+		return nil, err
+	}
+	var o operationResource
+	if err := mapToStruct(responseMap, &o); err != nil {
+		return nil, err
+	}
+	return m.newOperation(&o)
+}
+
+type listOperationsResponse struct {
+	NextPageToken string               `json:"nextPageToken,omitempty"`
+	Operations    []*operationResource `json:"operations,omitempty"`
+}
+
+func (m Operations) list(ctx context.Context, parent string, config *ListOperationsConfig) ([]*Operation[map[string]any], string, error) {
+	ctx = withOperation(ctx, "Operations.List")
+	var c ListOperationsConfig
+	if config != nil {
+		c = *config
+	}
+	httpOptions := mergeHTTPOptions(m.apiClient.clientConfig, c.HTTPOptions)
+
+	path := "operations"
+	if parent != "" {
+		path = strings.TrimSuffix(parent, "/") + "/operations"
+	}
+	query := url.Values{}
+	if c.PageSize > 0 {
+		query.Set("pageSize", strconv.Itoa(int(c.PageSize)))
+	}
+	if c.PageToken != "" {
+		query.Set("pageToken", c.PageToken)
+	}
+	if c.Filter != "" {
+		query.Set("filter", c.Filter)
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	responseMap, err := sendRequest(ctx, m.apiClient, path, http.MethodGet, nil, httpOptions)
+	if err != nil {
+		return nil, "", err
+	}
+	var resp listOperationsResponse
+	if err := mapToStruct(responseMap, &resp); err != nil {
+		return nil, "", err
+	}
+	operations := make([]*Operation[map[string]any], 0, len(resp.Operations))
+	for _, o := range resp.Operations {
+		op, err := m.newOperation(o)
+		if err != nil {
+			return nil, "", err
+		}
+		operations = append(operations, op)
+	}
+	return operations, resp.NextPageToken, nil
+}
+
+// List retrieves a paginated list of the long-running operations, such as video
+// generations, that match the filter of the config. The responses of the
+// operations are not typed; an operation can be waited for with Wait, or typed
+// by resuming it, such as with [Operations.ResumeVideosOperation].
+func (m Operations) List(ctx context.Context, config *ListOperationsConfig) (Page[Operation[map[string]any]], error) {
+	var parent string
+	if config != nil {
+		parent = config.Parent
+	}
+	listFunc := func(ctx context.Context, config map[string]any) ([]*Operation[map[string]any], string, error) {
+		var c ListOperationsConfig
+		if err := mapToStruct(config, &c); err != nil {
+			return nil, "", err
+		}
+		return m.list(ctx, parent, &c)
+	}
+	c := make(map[string]any)
+	deepMarshal(config, &c)
+	return newPage(ctx, "operations", c, listFunc)
+}
+
+// All retrieves all long-running operations.
+//
+// This method handles pagination internally, making multiple API calls as needed
+// to fetch all entries. It returns an iterator that yields each operation one by
+// one. To filter the operations, use List and Page.Next.
+func (m Operations) All(ctx context.Context) iter.Seq2[*Operation[map[string]any], error] {
+	p, err := m.List(ctx, nil)
+	if err != nil {
+		return yieldErrorAndEndIterator[Operation[map[string]any]](err)
+	}
+	return p.all(ctx)
+}
+
+// Cancel starts the cancellation of a long-running operation. The server makes
+// a best effort to cancel it: the operation may still complete, and on success
+// it is done with an error of code CANCELLED.
+func (m Operations) Cancel(ctx context.Context, name string, config *CancelOperationConfig) error {
+	ctx = withOperation(ctx, "Operations.Cancel")
+	if name == "" {
+		return fmt.Errorf("Cancel: operation name is empty")
+	}
+	var httpOptions *HTTPOptions
+	if config != nil {
+		httpOptions = config.HTTPOptions
+	}
+	httpOptions = mergeHTTPOptions(m.apiClient.clientConfig, httpOptions)
+	if _, err := sendRequest(ctx, m.apiClient, name+":cancel", http.MethodPost, nil, httpOptions); err != nil {
+		return fmt.Errorf("Cancel: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes a long-running operation, which means that its result is no
+// longer of interest. It doesn't cancel the operation.
+func (m Operations) Delete(ctx context.Context, name string, config *DeleteOperationConfig) error {
+	ctx = withOperation(ctx, "Operations.Delete")
+	if name == "" {
+		return fmt.Errorf("Delete: operation name is empty")
+	}
+	var httpOptions *HTTPOptions
+	if config != nil {
+		httpOptions = config.HTTPOptions
+	}
+	httpOptions = mergeHTTPOptions(m.apiClient.clientConfig, httpOptions)
+	if _, err := sendRequest(ctx, m.apiClient, name, http.MethodDelete, nil, httpOptions); err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+	return nil
+}
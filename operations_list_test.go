@@ -0,0 +1,237 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/auth"
+)
+
+// operationsServer lists, cancels and deletes operations under prefix.
+type operationsServer struct {
+	prefix string
+
+	mu         sync.Mutex
+	operations []*operationResource
+	filters    []string
+	cancelled  []string
+}
+
+func (s *operationsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path, ok := strings.CutPrefix(r.URL.Path, s.prefix)
+	if !ok {
+		http.Error(w, "unexpected request "+r.Method+" "+r.URL.Path, http.StatusBadRequest)
+		return
+	}
+	switch {
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "operations"):
+		s.list(w, r, strings.TrimSuffix(path, "operations"))
+	case r.Method == http.MethodPost && strings.HasSuffix(path, ":cancel"):
+		name := strings.TrimSuffix(path, ":cancel")
+		if op := s.find(name); op != nil {
+			op.Done = true
+			op.Error = map[string]any{"code": 1, "message": "cancelled"}
+			s.cancelled = append(s.cancelled, name)
+			w.Write([]byte(`{}`))
+			return
+		}
+		http.Error(w, `{"error": {"code": 404, "message": "not found", "status": "NOT_FOUND"}}`, http.StatusNotFound)
+	case r.Method == http.MethodPost && strings.HasSuffix(path, ":fetchPredictOperation"):
+		var req struct {
+			OperationName string `json:"operationName"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if op := s.find(req.OperationName); op != nil && strings.HasPrefix(req.OperationName, strings.TrimSuffix(path, ":fetchPredictOperation")) {
+			json.NewEncoder(w).Encode(op)
+			return
+		}
+		http.Error(w, `{"error": {"code": 404, "message": "not found", "status": "NOT_FOUND"}}`, http.StatusNotFound)
+	case r.Method == http.MethodDelete:
+		n := len(s.operations)
+		s.operations = slices.DeleteFunc(s.operations, func(op *operationResource) bool { return op.Name == path })
+		if len(s.operations) == n {
+			http.Error(w, `{"error": {"code": 404, "message": "not found", "status": "NOT_FOUND"}}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{}`))
+	case r.Method == http.MethodGet:
+		if op := s.find(path); op != nil {
+			json.NewEncoder(w).Encode(op)
+			return
+		}
+		http.Error(w, `{"error": {"code": 404, "message": "not found", "status": "NOT_FOUND"}}`, http.StatusNotFound)
+	default:
+		http.Error(w, "unexpected request "+r.Method+" "+r.URL.Path, http.StatusBadRequest)
+	}
+}
+
+// list lists the operations under parent, with "done=false" as the only
+// supported filter.
+func (s *operationsServer) list(w http.ResponseWriter, r *http.Request, parent string) {
+	filter := r.URL.Query().Get("filter")
+	s.filters = append(s.filters, filter)
+	var matching []*operationResource
+	for _, op := range s.operations {
+		if strings.HasPrefix(op.Name, parent) && (filter != "done=false" || !op.Done) {
+			matching = append(matching, op)
+		}
+	}
+	start, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+	end := len(matching)
+	if n, err := strconv.Atoi(r.URL.Query().Get("pageSize")); err == nil {
+		end = min(start+n, end)
+	}
+	resp := listOperationsResponse{Operations: matching[start:end]}
+	if end < len(matching) {
+		resp.NextPageToken = strconv.Itoa(end)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (s *operationsServer) find(name string) *operationResource {
+	for _, op := range s.operations {
+		if op.Name == name {
+			return op
+		}
+	}
+	return nil
+}
+
+func TestOperationsListCancelDelete(t *testing.T) {
+	ctx := context.Background()
+	server := &operationsServer{
+		prefix: "/v1beta/",
+		operations: []*operationResource{
+			{Name: "models/veo-2.0-generate-001/operations/a"},
+			{Name: "models/veo-2.0-generate-001/operations/b", Done: true},
+			{Name: "models/veo-2.0-generate-001/operations/c", Metadata: map[string]any{"progress": 50.0}},
+			{Name: "tunedModels/m/operations/d"},
+		},
+	}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	client := newTestClient(t, ts, nil)
+
+	// The running operations of the model, one per page.
+	page, err := client.Operations.List(ctx, &ListOperationsConfig{PageSize: 1, Filter: "done=false", Parent: "models/veo-2.0-generate-001"})
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	var names []string
+	for {
+		for _, op := range page.Items {
+			names = append(names, op.Name)
+		}
+		page, err = page.Next(ctx)
+		if errors.Is(err, ErrPageDone) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next() failed: %v", err)
+		}
+	}
+	if want := []string{"models/veo-2.0-generate-001/operations/a", "models/veo-2.0-generate-001/operations/c"}; !slices.Equal(names, want) {
+		t.Errorf("List() = %q, want %q", names, want)
+	}
+	if !slices.Equal(server.filters, []string{"done=false", "done=false"}) {
+		t.Errorf("filters = %q, want the filter on every page", server.filters)
+	}
+
+	names = nil
+	for op, err := range client.Operations.All(ctx) {
+		if err != nil {
+			t.Fatalf("All() failed: %v", err)
+		}
+		names = append(names, op.Name)
+	}
+	if len(names) != 4 {
+		t.Errorf("All() = %q, want the 4 operations", names)
+	}
+
+	if err := client.Operations.Cancel(ctx, "models/veo-2.0-generate-001/operations/a", nil); err != nil {
+		t.Fatalf("Cancel() failed: %v", err)
+	}
+	// A listed operation can be waited for.
+	op := client.Operations.ResumeVideosOperation("models/veo-2.0-generate-001/operations/a")
+	if _, err := op.Wait(ctx, fastOperationWait); err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Errorf("Wait() of a cancelled operation error = %v, want cancelled", err)
+	}
+
+	if err := client.Operations.Delete(ctx, "models/veo-2.0-generate-001/operations/b", nil); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if err := client.Operations.Delete(ctx, "models/veo-2.0-generate-001/operations/b", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() of a deleted operation error = %v, want ErrNotFound", err)
+	}
+	if err := client.Operations.Cancel(ctx, "", nil); err == nil {
+		t.Errorf("Cancel() without name succeeded, want error")
+	}
+}
+
+func TestOperationsListVertex(t *testing.T) {
+	ctx := context.Background()
+	name := "projects/test-project/locations/test-location/publishers/google/models/veo-2.0-generate-001/operations/v"
+	server := &operationsServer{
+		prefix: "/v1beta1/",
+		operations: []*operationResource{
+			{Name: name, Done: true, Response: map[string]any{"videos": []any{}}},
+			{Name: "projects/test-project/locations/test-location/operations/tuning"},
+		},
+	}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	client := newTestClient(t, ts, &ClientConfig{
+		Backend:     BackendVertexAI,
+		Project:     "test-project",
+		Location:    "test-location",
+		Credentials: &auth.Credentials{},
+	})
+
+	page, err := client.Operations.List(ctx, nil)
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if len(page.Items) != 2 {
+		t.Fatalf("List() = %d operations, want 2", len(page.Items))
+	}
+	// The listed operations are refreshed from their own endpoints.
+	for _, op := range page.Items {
+		if err := op.Refresh(ctx, nil); err != nil {
+			t.Errorf("Refresh(%s) failed: %v", op.Name, err)
+		}
+	}
+	if resp := page.Items[0].Response; !page.Items[0].Done || resp["videos"] == nil {
+		t.Errorf("Refresh() = %+v, want the done video generation", page.Items[0])
+	}
+
+	if err := client.Operations.Cancel(ctx, "projects/test-project/locations/test-location/operations/tuning", nil); err != nil {
+		t.Fatalf("Cancel() failed: %v", err)
+	}
+	if !slices.Equal(server.cancelled, []string{"projects/test-project/locations/test-location/operations/tuning"}) {
+		t.Errorf("cancelled = %q, want the tuning operation", server.cancelled)
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultOperationWaitInitialDelay = 5 * time.Second
+	defaultOperationWaitMaxDelay     = time.Minute
+	defaultOperationWaitExpBase      = 1.5
+)
+
+// Operation is a long-running operation whose result has type T, such as the
+// *GenerateVideosResponse of a video generation.
+//
+// The Name of an operation identifies it across processes: a process can store
+// it, and another process can resume waiting for the operation with a handle
+// created from the name, such as [Operations.ResumeVideosOperation].
+type Operation[T any] struct {
+	// Name of the operation, such as "models/veo-2.0-generate-001/operations/abc".
+	Name string
+	// Service-specific metadata of the operation, which typically contains
+	// progress information.
+	Metadata map[string]any
+	// Done reports whether the operation completed, with either Error or Response
+	// set.
+	Done bool
+	// The error of the operation in case of failure or cancellation.
+	Error *OperationError
+	// The result of the operation in case of success.
+	Response T
+
+	// fetch gets the current state of the operation with the given name.
+	fetch func(ctx context.Context, name string, httpOptions *HTTPOptions) (*Operation[T], error)
+}
+
+// OperationWaitConfig configures [Operation.Wait]. Zero valued fields use the
+// documented defaults.
+type OperationWaitConfig struct {
+	// Used to override HTTP request options of the polls.
+	HTTPOptions *HTTPOptions
+	// Delay between the first two polls. Defaults to 5s.
+	InitialDelay time.Duration
+	// Maximum delay between two polls. Defaults to 1m.
+	MaxDelay time.Duration
+	// Multiplier applied to the delay after each poll. Defaults to 1.5.
+	ExpBase float64
+	// Maximum time to wait for the operation. If zero, Wait waits until ctx is
+	// done.
+	Timeout time.Duration
+	// Optional. Called with the metadata of the operation after each poll that
+	// finds it still running.
+	Progress func(metadata map[string]any)
+}
+
+// OperationError is the error of a failed or cancelled operation. It matches
+// the sentinel error of its status code, such as [ErrInvalidArgument], with
+// errors.Is.
+type OperationError struct {
+	// Name of the operation.
+	Name string
+	// Code is the google.rpc.Code of the failure.
+	Code int32 `json:"code,omitempty"`
+	// Message describes the failure.
+	Message string `json:"message,omitempty"`
+	// Details provides more context to the failure. See ErrorDetails for their
+	// typed form.
+	Details []map[string]any `json:"details,omitempty"`
+}
+
+// Error returns a string representation of the OperationError.
+func (e *OperationError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("operation %s failed: %s", e.Name, e.Message)
+	}
+	return fmt.Sprintf("operation %s failed with code %d", e.Name, e.Code)
+}
+
+// Is reports whether target is the sentinel error of the status code of e, such
+// as [ErrNotFound].
+func (e *OperationError) Is(target error) bool {
+	return APIError{Status: rpcCodeNames[e.Code]}.Is(target)
+}
+
+// ErrorDetails decodes the Details of e into their typed form.
+func (e *OperationError) ErrorDetails() ErrorDetails {
+	return APIError{Details: e.Details}.ErrorDetails()
+}
+
+// newOperationError decodes the google.rpc.Status of a failed operation. It
+// returns nil for a nil status.
+func newOperationError(name string, status map[string]any) (*OperationError, error) {
+	if status == nil {
+		return nil, nil
+	}
+	e := &OperationError{}
+	if err := mapToStruct(status, e); err != nil {
+		return nil, err
+	}
+	e.Name = name
+	return e, nil
+}
+
+// Refresh gets the current state of the operation. The operation must be
+// created by Operations, such as with [Operations.ResumeVideosOperation].
+func (op *Operation[T]) Refresh(ctx context.Context, config *GetOperationConfig) error {
+	if op.Name == "" {
+		return fmt.Errorf("Refresh: operation name is empty")
+	}
+	if op.fetch == nil {
+		return fmt.Errorf("Refresh: operation %s can't be refreshed, create it with Operations, such as with Operations.ResumeVideosOperation", op.Name)
+	}
+	var httpOptions *HTTPOptions
+	if config != nil {
+		httpOptions = config.HTTPOptions
+	}
+	fetched, err := op.fetch(ctx, op.Name, httpOptions)
+	if err != nil {
+		return fmt.Errorf("Refresh: %w", err)
+	}
+	fetch := op.fetch
+	*op = *fetched
+	op.fetch = fetch
+	return nil
+}
+
+// Wait polls the operation until it is done, with an exponential backoff, and
+// returns its result. If the operation failed, it returns an *OperationError.
+// If the timeout of the config expires, or ctx is done, it returns an error
+// wrapping the context error; the operation keeps running on the server, and
+// Wait can be called again.
+func (op *Operation[T]) Wait(ctx context.Context, config *OperationWaitConfig) (T, error) {
+	var zero T
+	if config == nil {
+		config = &OperationWaitConfig{}
+	}
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+	delay := config.InitialDelay
+	if delay <= 0 {
+		delay = defaultOperationWaitInitialDelay
+	}
+	maxDelay := config.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultOperationWaitMaxDelay
+	}
+	expBase := config.ExpBase
+	if expBase <= 0 {
+		expBase = defaultOperationWaitExpBase
+	}
+
+	for !op.Done {
+		if err := op.Refresh(ctx, &GetOperationConfig{HTTPOptions: config.HTTPOptions}); err != nil {
+			return zero, fmt.Errorf("Wait: %w", err)
+		}
+		if op.Done {
+			break
+		}
+		if config.Progress != nil {
+			config.Progress(op.Metadata)
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return zero, fmt.Errorf("Wait: operation %s is still running: %w", op.Name, err)
+		}
+		delay = min(time.Duration(float64(delay)*expBase), maxDelay)
+	}
+	if op.Error != nil {
+		return zero, op.Error
+	}
+	return op.Response, nil
+}
+
+// VideosOperation returns the Operation of a video generation, such as the
+// operation returned by [Models.GenerateVideos], to wait for its result.
+func (m Operations) VideosOperation(operation *GenerateVideosOperation) (*Operation[*GenerateVideosResponse], error) {
+	op, err := newVideosOperation(operation)
+	if err != nil {
+		return nil, fmt.Errorf("VideosOperation: %w", err)
+	}
+	op.fetch = m.fetchVideosOperation
+	return op, nil
+}
+
+// ResumeVideosOperation returns the Operation of a video generation with the
+// given name, such as the name of an operation started by another process. Its
+// state is unknown until it is refreshed.
+func (m Operations) ResumeVideosOperation(name string) *Operation[*GenerateVideosResponse] {
+	return &Operation[*GenerateVideosResponse]{Name: name, fetch: m.fetchVideosOperation}
+}
+
+// fetchVideosOperation gets a video generation operation, with getVideosOperation
+// on the Gemini Developer API and fetchPredictVideosOperation on Vertex AI.
+func (m Operations) fetchVideosOperation(ctx context.Context, name string, httpOptions *HTTPOptions) (*Operation[*GenerateVideosResponse], error) {
+	operation, err := m.GetVideosOperation(ctx, &GenerateVideosOperation{Name: name}, &GetOperationConfig{HTTPOptions: httpOptions})
+	if err != nil {
+		return nil, err
+	}
+	return newVideosOperation(operation)
+}
+
+func newVideosOperation(operation *GenerateVideosOperation) (*Operation[*GenerateVideosResponse], error) {
+	opErr, err := newOperationError(operation.Name, operation.Error)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the error of operation %s: %w", operation.Name, err)
+	}
+	return &Operation[*GenerateVideosResponse]{
+		Name:     operation.Name,
+		Metadata: operation.Metadata,
+		Done:     operation.Done,
+		Error:    opErr,
+		Response: operation.Response,
+	}, nil
+}
@@ -0,0 +1,190 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/auth"
+)
+
+// operationServer answers polls of an operation with the given responses, the
+// last one being repeated.
+type operationServer struct {
+	t         *testing.T
+	wantPath  string
+	wantName  string
+	responses []string
+
+	mu    sync.Mutex
+	polls int
+}
+
+func (s *operationServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !strings.HasSuffix(r.URL.Path, s.wantPath) {
+		http.Error(w, "unexpected request "+r.Method+" "+r.URL.Path, http.StatusBadRequest)
+		return
+	}
+	if s.wantName != "" {
+		var req struct {
+			OperationName string `json:"operationName"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.OperationName != s.wantName {
+			s.t.Errorf("operationName = %q, want %q", req.OperationName, s.wantName)
+		}
+	}
+	w.Write([]byte(s.responses[min(s.polls, len(s.responses)-1)]))
+	s.polls++
+}
+
+var fastOperationWait = &OperationWaitConfig{InitialDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+func TestOperationWait(t *testing.T) {
+	ctx := context.Background()
+	server := &operationServer{
+		t:        t,
+		wantPath: "/v1beta/models/veo-2.0-generate-001/operations/op1",
+		responses: []string{
+			`{"name": "models/veo-2.0-generate-001/operations/op1", "metadata": {"progress": 10}}`,
+			`{"name": "models/veo-2.0-generate-001/operations/op1", "metadata": {"progress": 60}}`,
+			`{"name": "models/veo-2.0-generate-001/operations/op1", "done": true, "response": {"generateVideoResponse": {"generatedSamples": [{"video": {"uri": "https://example.com/video"}}]}}}`,
+		},
+	}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	client := newTestClient(t, ts, nil)
+
+	op, err := client.Operations.VideosOperation(&GenerateVideosOperation{Name: "models/veo-2.0-generate-001/operations/op1"})
+	if err != nil {
+		t.Fatalf("VideosOperation() failed: %v", err)
+	}
+	var progress []any
+	config := *fastOperationWait
+	config.Progress = func(metadata map[string]any) { progress = append(progress, metadata["progress"]) }
+	resp, err := op.Wait(ctx, &config)
+	if err != nil {
+		t.Fatalf("Wait() failed: %v", err)
+	}
+	if len(resp.GeneratedVideos) != 1 || resp.GeneratedVideos[0].Video.URI != "https://example.com/video" {
+		t.Errorf("Wait() = %+v, want the generated video", resp)
+	}
+	if len(progress) != 2 || progress[0] != 10.0 || progress[1] != 60.0 {
+		t.Errorf("progress = %v, want [10 60]", progress)
+	}
+	if !op.Done || server.polls != 3 {
+		t.Errorf("operation done = %v after %d polls, want done after 3 polls", op.Done, server.polls)
+	}
+
+	// A done operation is not polled again.
+	if _, err := op.Wait(ctx, fastOperationWait); err != nil || server.polls != 3 {
+		t.Errorf("Wait() of a done operation = %v after %d polls, want no error and no poll", err, server.polls)
+	}
+}
+
+func TestOperationWaitError(t *testing.T) {
+	ctx := context.Background()
+	server := &operationServer{
+		t:        t,
+		wantPath: "/v1beta/models/veo-2.0-generate-001/operations/op2",
+		responses: []string{
+			`{"name": "models/veo-2.0-generate-001/operations/op2", "done": true, "error": {"code": 3, "message": "invalid prompt"}}`,
+		},
+	}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	client := newTestClient(t, ts, nil)
+
+	// The operation is resumed from its name only.
+	op := client.Operations.ResumeVideosOperation("models/veo-2.0-generate-001/operations/op2")
+	_, err := op.Wait(ctx, fastOperationWait)
+	var opErr *OperationError
+	if !errors.As(err, &opErr) {
+		t.Fatalf("Wait() error = %v, want *OperationError", err)
+	}
+	if opErr.Code != 3 || opErr.Message != "invalid prompt" || opErr.Name != op.Name {
+		t.Errorf("Wait() error = %+v, want code 3 and message", opErr)
+	}
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("errors.Is(%v, ErrInvalidArgument) = false, want true", err)
+	}
+}
+
+func TestOperationWaitTimeout(t *testing.T) {
+	server := &operationServer{
+		t:         t,
+		wantPath:  "/v1beta/models/veo-2.0-generate-001/operations/op3",
+		responses: []string{`{"name": "models/veo-2.0-generate-001/operations/op3"}`},
+	}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	client := newTestClient(t, ts, nil)
+
+	op := client.Operations.ResumeVideosOperation("models/veo-2.0-generate-001/operations/op3")
+	config := *fastOperationWait
+	config.Timeout = 20 * time.Millisecond
+	if _, err := op.Wait(context.Background(), &config); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait() error = %v, want context.DeadlineExceeded", err)
+	}
+	if op.Done {
+		t.Errorf("operation done after timeout, want running")
+	}
+}
+
+func TestOperationRefreshWithoutOperations(t *testing.T) {
+	op := &Operation[*GenerateVideosResponse]{Name: "models/veo-2.0-generate-001/operations/op"}
+	if err := op.Refresh(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "ResumeVideosOperation") {
+		t.Errorf("Refresh() error = %v, want an error pointing to ResumeVideosOperation", err)
+	}
+}
+
+func TestOperationWaitVertex(t *testing.T) {
+	name := "projects/test-project/locations/test-location/publishers/google/models/veo-2.0-generate-001/operations/op4"
+	server := &operationServer{
+		t:        t,
+		wantPath: "/projects/test-project/locations/test-location/publishers/google/models/veo-2.0-generate-001:fetchPredictOperation",
+		wantName: name,
+		responses: []string{
+			`{"name": "` + name + `"}`,
+			`{"name": "` + name + `", "done": true, "response": {"videos": [{"gcsUri": "gs://bucket/video.mp4"}]}}`,
+		},
+	}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	client := newTestClient(t, ts, &ClientConfig{
+		Backend:     BackendVertexAI,
+		Project:     "test-project",
+		Location:    "test-location",
+		Credentials: &auth.Credentials{},
+	})
+
+	resp, err := client.Operations.ResumeVideosOperation(name).Wait(context.Background(), fastOperationWait)
+	if err != nil {
+		t.Fatalf("Wait() failed: %v", err)
+	}
+	if len(resp.GeneratedVideos) != 1 || resp.GeneratedVideos[0].Video.URI != "gs://bucket/video.mp4" {
+		t.Errorf("Wait() = %+v, want the generated video", resp)
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+)
+
+// ResponseAggregator merges the chunks of a streamed response, as returned by
+// [Models.GenerateContentStream], into a single response with one candidate per
+// candidate index:
+//
+//   - The parts of the chunks are appended to the content of their candidate,
+//     and adjacent text parts, or adjacent thought parts, are concatenated.
+//   - Citations, grounding chunks and supports, search queries and log
+//     probabilities are appended.
+//   - The last finish reason and message, token count, safety ratings, prompt
+//     feedback and usage metadata are kept.
+//
+// In automatic function calling mode, the candidates are reset when a chunk
+// carries a longer automatic function calling history, since the previous
+// chunks were the function calls recorded in that history.
+//
+// The zero value is an empty aggregator. A ResponseAggregator must not be used
+// concurrently.
+type ResponseAggregator struct {
+	response   *GenerateContentResponse
+	candidates []*Candidate
+}
+
+// AggregateStream reads stream to the end and returns the merged response.
+func AggregateStream(stream iter.Seq2[*GenerateContentResponse, error]) (*GenerateContentResponse, error) {
+	var a ResponseAggregator
+	for _, err := range a.Aggregate(stream) {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return a.Response(), nil
+}
+
+// Aggregate returns an iterator that yields the chunks and errors of stream
+// unchanged, and adds the chunks to a.
+func (a *ResponseAggregator) Aggregate(stream iter.Seq2[*GenerateContentResponse, error]) iter.Seq2[*GenerateContentResponse, error] {
+	return func(yield func(*GenerateContentResponse, error) bool) {
+		for chunk, err := range stream {
+			if err == nil {
+				a.Add(chunk)
+			}
+			if !yield(chunk, err) {
+				return
+			}
+		}
+	}
+}
+
+// Add merges chunk into the response. It doesn't modify chunk.
+func (a *ResponseAggregator) Add(chunk *GenerateContentResponse) {
+	if chunk == nil {
+		return
+	}
+	if a.response == nil {
+		a.response = &GenerateContentResponse{}
+	}
+	r := a.response
+	if len(chunk.AutomaticFunctionCallingHistory) != len(r.AutomaticFunctionCallingHistory) {
+		r.AutomaticFunctionCallingHistory = chunk.AutomaticFunctionCallingHistory
+		a.candidates = nil
+	}
+	if r.CreateTime.IsZero() {
+		r.CreateTime = chunk.CreateTime
+	}
+	r.ResponseID = cmp.Or(chunk.ResponseID, r.ResponseID)
+	r.ModelVersion = cmp.Or(chunk.ModelVersion, r.ModelVersion)
+	r.PromptFeedback = cmp.Or(chunk.PromptFeedback, r.PromptFeedback)
+	r.UsageMetadata = cmp.Or(chunk.UsageMetadata, r.UsageMetadata)
+
+	for i, c := range chunk.Candidates {
+		if c != nil {
+			// The index is omitted when it is 0, and by some servers altogether; fall
+			// back to the position of the candidate in the chunk.
+			a.addCandidate(cmp.Or(c.Index, int32(i)), c)
+		}
+	}
+}
+
+func (a *ResponseAggregator) addCandidate(index int32, c *Candidate) {
+	i := slices.IndexFunc(a.candidates, func(candidate *Candidate) bool { return candidate.Index == index })
+	if i < 0 {
+		a.candidates = append(a.candidates, &Candidate{Index: index})
+		i = len(a.candidates) - 1
+	}
+	merged := a.candidates[i]
+
+	if c.Content != nil {
+		if merged.Content == nil {
+			merged.Content = &Content{}
+		}
+		merged.Content.Role = cmp.Or(merged.Content.Role, c.Content.Role)
+		for _, part := range c.Content.Parts {
+			if isEmptyPart(part) {
+				continue
+			}
+			parts := merged.Content.Parts
+			if n := len(parts); n > 0 && isTextPart(parts[n-1]) && isTextPart(part) && parts[n-1].Thought == part.Thought {
+				// Replace the last part rather than modifying it, since a response
+				// returned earlier may hold it.
+				parts[n-1] = &Part{Text: parts[n-1].Text + part.Text, Thought: part.Thought}
+				continue
+			}
+			p := *part
+			merged.Content.Parts = append(parts, &p)
+		}
+	}
+	if c.CitationMetadata != nil {
+		if merged.CitationMetadata == nil {
+			merged.CitationMetadata = &CitationMetadata{}
+		}
+		merged.CitationMetadata.Citations = append(merged.CitationMetadata.Citations, c.CitationMetadata.Citations...)
+	}
+	if c.GroundingMetadata != nil {
+		if merged.GroundingMetadata == nil {
+			merged.GroundingMetadata = &GroundingMetadata{}
+		}
+		mergeGroundingMetadata(merged.GroundingMetadata, c.GroundingMetadata)
+	}
+	if c.LogprobsResult != nil {
+		if merged.LogprobsResult == nil {
+			merged.LogprobsResult = &LogprobsResult{}
+		}
+		merged.LogprobsResult.ChosenCandidates = append(merged.LogprobsResult.ChosenCandidates, c.LogprobsResult.ChosenCandidates...)
+		merged.LogprobsResult.TopCandidates = append(merged.LogprobsResult.TopCandidates, c.LogprobsResult.TopCandidates...)
+	}
+	merged.FinishReason = cmp.Or(c.FinishReason, merged.FinishReason)
+	merged.FinishMessage = cmp.Or(c.FinishMessage, merged.FinishMessage)
+	merged.TokenCount = cmp.Or(c.TokenCount, merged.TokenCount)
+	merged.AvgLogprobs = cmp.Or(c.AvgLogprobs, merged.AvgLogprobs)
+	if c.SafetyRatings != nil {
+		merged.SafetyRatings = c.SafetyRatings
+	}
+}
+
+// mergeGroundingMetadata appends the grounding of src to dst. The chunk indices
+// of the supports of src are shifted past the chunks already in dst.
+func mergeGroundingMetadata(dst, src *GroundingMetadata) {
+	offset := int32(len(dst.GroundingChunks))
+	dst.GroundingChunks = append(dst.GroundingChunks, src.GroundingChunks...)
+	for _, support := range src.GroundingSupports {
+		if support == nil {
+			continue
+		}
+		s := *support
+		if offset > 0 {
+			s.GroundingChunkIndices = make([]int32, len(support.GroundingChunkIndices))
+			for i, index := range support.GroundingChunkIndices {
+				s.GroundingChunkIndices[i] = index + offset
+			}
+		}
+		dst.GroundingSupports = append(dst.GroundingSupports, &s)
+	}
+	for _, query := range src.RetrievalQueries {
+		if !slices.Contains(dst.RetrievalQueries, query) {
+			dst.RetrievalQueries = append(dst.RetrievalQueries, query)
+		}
+	}
+	for _, query := range src.WebSearchQueries {
+		if !slices.Contains(dst.WebSearchQueries, query) {
+			dst.WebSearchQueries = append(dst.WebSearchQueries, query)
+		}
+	}
+	dst.RetrievalMetadata = cmp.Or(src.RetrievalMetadata, dst.RetrievalMetadata)
+	dst.SearchEntryPoint = cmp.Or(src.SearchEntryPoint, dst.SearchEntryPoint)
+}
+
+// Response returns the merged response, with its candidates sorted by index, or
+// nil if no chunk was added. Adding more chunks doesn't change the returned
+// response.
+func (a *ResponseAggregator) Response() *GenerateContentResponse {
+	if a.response == nil {
+		return nil
+	}
+	r := *a.response
+	r.Candidates = make([]*Candidate, len(a.candidates))
+	for i, merged := range a.candidates {
+		c := *merged
+		if c.Content != nil {
+			c.Content = &Content{Role: c.Content.Role, Parts: slices.Clone(c.Content.Parts)}
+		}
+		if c.CitationMetadata != nil {
+			c.CitationMetadata = &CitationMetadata{Citations: slices.Clone(c.CitationMetadata.Citations)}
+		}
+		if c.GroundingMetadata != nil {
+			g := *c.GroundingMetadata
+			g.GroundingChunks = slices.Clone(g.GroundingChunks)
+			g.GroundingSupports = slices.Clone(g.GroundingSupports)
+			g.RetrievalQueries = slices.Clone(g.RetrievalQueries)
+			g.WebSearchQueries = slices.Clone(g.WebSearchQueries)
+			c.GroundingMetadata = &g
+		}
+		if c.LogprobsResult != nil {
+			c.LogprobsResult = &LogprobsResult{
+				ChosenCandidates: slices.Clone(c.LogprobsResult.ChosenCandidates),
+				TopCandidates:    slices.Clone(c.LogprobsResult.TopCandidates),
+			}
+		}
+		r.Candidates[i] = &c
+	}
+	slices.SortStableFunc(r.Candidates, func(a, b *Candidate) int { return cmp.Compare(a.Index, b.Index) })
+	return &r
+}
+
+// isTextPart reports whether part only holds text.
+func isTextPart(part *Part) bool {
+	return part.Text != "" && part.VideoMetadata == nil && part.CodeExecutionResult == nil && part.ExecutableCode == nil &&
+		part.FileData == nil && part.FunctionCall == nil && part.FunctionResponse == nil && part.InlineData == nil
+}
+
+// isEmptyPart reports whether part holds no data.
+func isEmptyPart(part *Part) bool {
+	return part == nil || part.Text == "" && part.InlineData == nil && part.FileData == nil && part.FunctionCall == nil &&
+		part.FunctionResponse == nil && part.ExecutableCode == nil && part.CodeExecutionResult == nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"errors"
+	"iter"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func chunkStream(chunks []*GenerateContentResponse, err error) iter.Seq2[*GenerateContentResponse, error] {
+	return func(yield func(*GenerateContentResponse, error) bool) {
+		for _, chunk := range chunks {
+			if !yield(chunk, nil) {
+				return
+			}
+		}
+		if err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+func TestAggregateStream(t *testing.T) {
+	chunks := []*GenerateContentResponse{
+		{
+			ResponseID: "r",
+			Candidates: []*Candidate{
+				{Content: &Content{Role: RoleModel, Parts: []*Part{{Text: "thinking ", Thought: true}, {Text: "It is "}}}},
+				{Index: 1, Content: &Content{Role: RoleModel, Parts: []*Part{{Text: "Other"}}}},
+			},
+			UsageMetadata: &GenerateContentResponseUsageMetadata{PromptTokenCount: 3},
+		},
+		{
+			Candidates: []*Candidate{{
+				Content:          &Content{Role: RoleModel, Parts: []*Part{{Text: "sunny"}, {FunctionCall: &FunctionCall{Name: "get_time"}}, {Text: "."}}},
+				CitationMetadata: &CitationMetadata{Citations: []*Citation{{URI: "a"}}},
+				GroundingMetadata: &GroundingMetadata{
+					GroundingChunks:   []*GroundingChunk{{}},
+					GroundingSupports: []*GroundingSupport{{GroundingChunkIndices: []int32{0}}},
+					WebSearchQueries:  []string{"weather"},
+				},
+			}},
+		},
+		{
+			Candidates: []*Candidate{{
+				Content:          &Content{Role: RoleModel, Parts: []*Part{{Text: ""}}},
+				FinishReason:     FinishReasonStop,
+				CitationMetadata: &CitationMetadata{Citations: []*Citation{{URI: "b"}}},
+				GroundingMetadata: &GroundingMetadata{
+					GroundingChunks:   []*GroundingChunk{{}},
+					GroundingSupports: []*GroundingSupport{{GroundingChunkIndices: []int32{0}}},
+					WebSearchQueries:  []string{"weather"},
+				},
+			}},
+			UsageMetadata: &GenerateContentResponseUsageMetadata{PromptTokenCount: 3, CandidatesTokenCount: 5, TotalTokenCount: 8},
+		},
+	}
+	var a ResponseAggregator
+	var passed int
+	for chunk, err := range a.Aggregate(chunkStream(chunks, nil)) {
+		if err != nil {
+			t.Fatalf("Aggregate() failed: %v", err)
+		}
+		if chunk != chunks[passed] {
+			t.Errorf("Aggregate() chunk %d = %v, want the chunk of the stream", passed, chunk)
+		}
+		passed++
+	}
+	if passed != len(chunks) {
+		t.Errorf("Aggregate() yielded %d chunks, want %d", passed, len(chunks))
+	}
+
+	want := &GenerateContentResponse{
+		ResponseID: "r",
+		Candidates: []*Candidate{
+			{
+				Content: &Content{Role: RoleModel, Parts: []*Part{
+					{Text: "thinking ", Thought: true},
+					{Text: "It is sunny"},
+					{FunctionCall: &FunctionCall{Name: "get_time"}},
+					{Text: "."},
+				}},
+				FinishReason:     FinishReasonStop,
+				CitationMetadata: &CitationMetadata{Citations: []*Citation{{URI: "a"}, {URI: "b"}}},
+				GroundingMetadata: &GroundingMetadata{
+					GroundingChunks:   []*GroundingChunk{{}, {}},
+					GroundingSupports: []*GroundingSupport{{GroundingChunkIndices: []int32{0}}, {GroundingChunkIndices: []int32{1}}},
+					WebSearchQueries:  []string{"weather"},
+				},
+			},
+			{Index: 1, Content: &Content{Role: RoleModel, Parts: []*Part{{Text: "Other"}}}},
+		},
+		UsageMetadata: &GenerateContentResponseUsageMetadata{PromptTokenCount: 3, CandidatesTokenCount: 5, TotalTokenCount: 8},
+	}
+	if diff := cmp.Diff(want, a.Response()); diff != "" {
+		t.Errorf("Response() mismatch (-want +got):\n%s", diff)
+	}
+	if got := chunks[0].Candidates[0].Content.Parts[1].Text; got != "It is " {
+		t.Errorf("Aggregate() modified a chunk: text = %q, want %q", got, "It is ")
+	}
+}
+
+func TestAggregateStreamError(t *testing.T) {
+	streamErr := errors.New("stream failed")
+	chunks := []*GenerateContentResponse{{Candidates: []*Candidate{{Content: &Content{Parts: []*Part{{Text: "a"}}}}}}}
+	if _, err := AggregateStream(chunkStream(chunks, streamErr)); !errors.Is(err, streamErr) {
+		t.Errorf("AggregateStream() error = %v, want %v", err, streamErr)
+	}
+	resp, err := AggregateStream(chunkStream(append(chunks, chunks...), nil))
+	if err != nil {
+		t.Fatalf("AggregateStream() failed: %v", err)
+	}
+	if got := resp.Text(); got != "aa" {
+		t.Errorf("AggregateStream() text = %q, want %q", got, "aa")
+	}
+}
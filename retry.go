@@ -0,0 +1,236 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryAttempts     = 5
+	defaultRetryInitialDelay = 1 * time.Second
+	defaultRetryMaxDelay     = 60 * time.Second
+	defaultRetryExpBase      = 2.0
+	defaultRetryJitter       = 0.2
+)
+
+// defaultRetryHTTPStatusCodes are the HTTP status codes retried when
+// RetryOptions.HTTPStatusCodes is empty.
+var defaultRetryHTTPStatusCodes = []int{
+	http.StatusRequestTimeout,      // 408
+	http.StatusTooManyRequests,     // 429
+	http.StatusInternalServerError, // 500
+	http.StatusBadGateway,          // 502
+	http.StatusServiceUnavailable,  // 503
+	http.StatusGatewayTimeout,      // 504
+}
+
+// RetryOptions configures automatic retries of failed HTTP requests.
+//
+// Retries apply to unary calls, such as the creation of the file of an upload,
+// and to establishing the connection of streaming calls (such as
+// [Models.GenerateContentStream]) before the first chunk is received. A nil
+// RetryOptions disables retries. Zero valued fields use the documented defaults.
+//
+// The chunks of a file upload are not retried: a failed chunk is resumed from
+// the offset that the server committed, up to UploadFileConfig.ResumeAttempts
+// times, and only the delays between the resumptions come from RetryOptions.
+//
+// When the server responds with a Retry-After header or a google.rpc.RetryInfo
+// entry in the error details, the server provided delay is used instead of
+// the computed backoff, capped at MaxDelay.
+type RetryOptions struct {
+	// Maximum number of attempts, including the original request. Defaults to 5.
+	// Set to 1 to disable retries.
+	Attempts int `json:"attempts,omitempty"`
+	// Delay before the first retry. Defaults to 1s.
+	InitialDelay time.Duration `json:"initialDelay,omitempty"`
+	// Maximum delay between two attempts. Defaults to 60s.
+	MaxDelay time.Duration `json:"maxDelay,omitempty"`
+	// Multiplier applied to the delay after each attempt. Defaults to 2.
+	ExpBase float64 `json:"expBase,omitempty"`
+	// Fraction of the delay that is randomized, in the range [0, 1]. For example
+	// 0.2 spreads a 10s delay over [8s, 12s]. Defaults to 0.2.
+	Jitter *float64 `json:"jitter,omitempty"`
+	// HTTP status codes that should be retried. Defaults to 408, 429, 500, 502,
+	// 503 and 504.
+	HTTPStatusCodes []int `json:"httpStatusCodes,omitempty"`
+}
+
+func (o *RetryOptions) attempts() int {
+	if o == nil {
+		return 1
+	}
+	if o.Attempts <= 0 {
+		return defaultRetryAttempts
+	}
+	return o.Attempts
+}
+
+func (o *RetryOptions) retryableStatus(code int) bool {
+	codes := o.HTTPStatusCodes
+	if len(codes) == 0 {
+		codes = defaultRetryHTTPStatusCodes
+	}
+	return slices.Contains(codes, code)
+}
+
+func (o *RetryOptions) maxDelay() time.Duration {
+	if o.MaxDelay <= 0 {
+		return defaultRetryMaxDelay
+	}
+	return o.MaxDelay
+}
+
+// backoff returns the delay to wait before the given retry. retry starts at 1.
+func (o *RetryOptions) backoff(retry int) time.Duration {
+	initialDelay := o.InitialDelay
+	if initialDelay <= 0 {
+		initialDelay = defaultRetryInitialDelay
+	}
+	expBase := o.ExpBase
+	if expBase <= 0 {
+		expBase = defaultRetryExpBase
+	}
+	jitter := defaultRetryJitter
+	if o.Jitter != nil {
+		jitter = min(max(*o.Jitter, 0), 1)
+	}
+
+	delay := float64(initialDelay) * math.Pow(expBase, float64(retry-1))
+	delay = min(delay, float64(o.maxDelay()))
+	delay += delay * jitter * (2*rand.Float64() - 1)
+	return time.Duration(delay)
+}
+
+// resolveRetryOptions returns the retry options of the request, falling back to
+// the client level options.
+func resolveRetryOptions(ac *apiClient, httpOptions *HTTPOptions) *RetryOptions {
+	if httpOptions != nil && httpOptions.RetryOptions != nil {
+		return httpOptions.RetryOptions
+	}
+	return ac.clientConfig.HTTPOptions.RetryOptions
+}
+
+// rewindableRequest reports whether the request body can be sent again.
+func rewindableRequest(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// doRequestWithRetry sends the request, retrying transient failures according
+// to retryOptions. The response of the last attempt is returned unchanged, so
+// callers still observe the status code and body of a request that exhausted
+// its attempts.
+func doRequestWithRetry(ac *apiClient, req *http.Request, retryOptions *RetryOptions) (*http.Response, error) {
+	client := ac.clientConfig.HTTPClient
+	attempts := retryOptions.attempts()
+	if !rewindableRequest(req) {
+		attempts = 1
+	}
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("doRequest: error rewinding request body: %w", err)
+			}
+			req.Body = body
+		}
+		resp, err := client.Do(req)
+		last := attempt >= attempts
+		if err != nil {
+			if last || ctx.Err() != nil {
+				return nil, fmt.Errorf("doRequest: error sending request: %w", err)
+			}
+			if err := sleepContext(ctx, retryOptions.backoff(attempt)); err != nil {
+				return nil, fmt.Errorf("doRequest: error sending request: %w", err)
+			}
+			continue
+		}
+		if last || httpStatusOk(resp) || !retryOptions.retryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+
+		delay, ok := serverRetryDelay(resp)
+		if ok {
+			delay = min(delay, retryOptions.maxDelay())
+		} else {
+			delay = retryOptions.backoff(attempt)
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, fmt.Errorf("doRequest: error sending request: %w", err)
+		}
+	}
+}
+
+// serverRetryDelay extracts the retry delay suggested by the server, either
+// from the Retry-After header or from a google.rpc.RetryInfo error detail. The
+// response body is consumed and closed.
+func serverRetryDelay(resp *http.Response) (time.Duration, bool) {
+	defer resp.Body.Close()
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return max(time.Until(t), 0), true
+		}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil || len(bytes.TrimSpace(body)) == 0 {
+		return 0, false
+	}
+	var respWithError responseWithError
+	if err := json.Unmarshal(body, &respWithError); err != nil || respWithError.ErrorInfo == nil {
+		return 0, false
+	}
+	return retryInfoDelay(respWithError.ErrorInfo.Details)
+}
+
+// retryInfoDelay returns the retryDelay of the first google.rpc.RetryInfo entry
+// in details.
+func retryInfoDelay(details []map[string]any) (time.Duration, bool) {
+	retryInfo := APIError{Details: details}.ErrorDetails().RetryInfo
+	if retryInfo == nil {
+		return 0, false
+	}
+	return retryInfo.RetryDelay, true
+}
+
+// sleepContext waits for d or until ctx is done, whichever happens first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSendRequestRetry(t *testing.T) {
+	ctx := context.Background()
+	fastRetry := &RetryOptions{Attempts: 3, InitialDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+
+	tests := []struct {
+		name         string
+		retryOptions *RetryOptions
+		failures     int
+		failureCode  int
+		failureBody  string
+		retryAfter   string
+		wantCalls    int32
+		wantErrCode  int
+	}{
+		{
+			name:         "no retry options",
+			retryOptions: nil,
+			failures:     1,
+			failureCode:  http.StatusServiceUnavailable,
+			wantCalls:    1,
+			wantErrCode:  http.StatusServiceUnavailable,
+		},
+		{
+			name:         "succeeds after transient failures",
+			retryOptions: fastRetry,
+			failures:     2,
+			failureCode:  http.StatusTooManyRequests,
+			wantCalls:    3,
+		},
+		{
+			name:         "attempts exhausted",
+			retryOptions: fastRetry,
+			failures:     5,
+			failureCode:  http.StatusInternalServerError,
+			wantCalls:    3,
+			wantErrCode:  http.StatusInternalServerError,
+		},
+		{
+			name:         "non retryable status",
+			retryOptions: fastRetry,
+			failures:     1,
+			failureCode:  http.StatusBadRequest,
+			wantCalls:    1,
+			wantErrCode:  http.StatusBadRequest,
+		},
+		{
+			name:         "custom status codes",
+			retryOptions: &RetryOptions{Attempts: 2, InitialDelay: time.Millisecond, HTTPStatusCodes: []int{http.StatusConflict}},
+			failures:     1,
+			failureCode:  http.StatusConflict,
+			wantCalls:    2,
+		},
+		{
+			name:         "retry after header",
+			retryOptions: fastRetry,
+			failures:     1,
+			failureCode:  http.StatusServiceUnavailable,
+			retryAfter:   "0",
+			wantCalls:    2,
+		},
+		{
+			name:         "retry info detail",
+			retryOptions: fastRetry,
+			failures:     1,
+			failureCode:  http.StatusTooManyRequests,
+			failureBody:  `{"error": {"code": 429, "message": "quota", "status": "RESOURCE_EXHAUSTED", "details": [{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "0.001s"}]}}`,
+			wantCalls:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if string(bytes.TrimSpace(body)) != `{"key":"value"}` {
+					t.Errorf("unexpected request body %q", body)
+				}
+				if int(calls.Add(1)) <= tt.failures {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(tt.failureCode)
+					fmt.Fprint(w, tt.failureBody)
+					return
+				}
+				fmt.Fprint(w, `{"response": "ok"}`)
+			}))
+			defer ts.Close()
+
+			ac := &apiClient{clientConfig: &ClientConfig{HTTPClient: ts.Client()}}
+			httpOptions := &HTTPOptions{BaseURL: ts.URL, RetryOptions: tt.retryOptions}
+			got, err := sendRequest(ctx, ac, "foo", http.MethodPost, map[string]any{"key": "value"}, httpOptions)
+
+			if gotCalls := calls.Load(); gotCalls != tt.wantCalls {
+				t.Errorf("server called %d times, want %d", gotCalls, tt.wantCalls)
+			}
+			if tt.wantErrCode != 0 {
+				apiErr, ok := err.(APIError)
+				if !ok {
+					t.Fatalf("sendRequest() error = %v, want APIError", err)
+				}
+				if apiErr.Code != tt.wantErrCode {
+					t.Errorf("APIError.Code = %d, want %d", apiErr.Code, tt.wantErrCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sendRequest() failed: %v", err)
+			}
+			if got["response"] != "ok" {
+				t.Errorf("sendRequest() got = %v, want response ok", got)
+			}
+		})
+	}
+}
+
+func TestSendRequestRetryClientOptions(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer ts.Close()
+
+	ac := &apiClient{clientConfig: &ClientConfig{
+		HTTPClient:  ts.Client(),
+		HTTPOptions: HTTPOptions{BaseURL: ts.URL, RetryOptions: &RetryOptions{InitialDelay: time.Millisecond}},
+	}}
+	if _, err := sendRequest(context.Background(), ac, "foo", http.MethodGet, nil, mergeHTTPOptions(ac.clientConfig, nil)); err != nil {
+		t.Fatalf("sendRequest() failed: %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("server called %d times, want 2", got)
+	}
+}
+
+func TestSendStreamRequestRetry(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "data:{\"key1\":\"value1\"}\n\n")
+	}))
+	defer ts.Close()
+
+	ac := &apiClient{clientConfig: &ClientConfig{HTTPClient: ts.Client()}}
+	httpOptions := &HTTPOptions{BaseURL: ts.URL, RetryOptions: &RetryOptions{InitialDelay: time.Millisecond}}
+	var rs responseStream[map[string]any]
+	if err := sendStreamRequest(context.Background(), ac, "foo", http.MethodPost, map[string]any{"key": "value"}, httpOptions, &rs); err != nil {
+		t.Fatalf("sendStreamRequest() failed: %v", err)
+	}
+	var got []map[string]any
+	for resp, err := range iterateResponseStream(&rs, func(m map[string]any) (*map[string]any, error) { return &m, nil }) {
+		if err != nil {
+			t.Fatalf("iterateResponseStream() failed: %v", err)
+		}
+		got = append(got, *resp)
+	}
+	if diff := cmp.Diff([]map[string]any{{"key1": "value1"}}, got); diff != "" {
+		t.Errorf("stream mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSendRequestRetryContextCanceled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	ac := &apiClient{clientConfig: &ClientConfig{HTTPClient: ts.Client()}}
+	httpOptions := &HTTPOptions{BaseURL: ts.URL, RetryOptions: &RetryOptions{Attempts: 3, InitialDelay: time.Hour, MaxDelay: time.Hour}}
+	start := time.Now()
+	_, err := sendRequest(ctx, ac, "foo", http.MethodGet, nil, httpOptions)
+	if err == nil {
+		t.Fatal("sendRequest() succeeded, want error")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("sendRequest() took %v, want it to stop at the context deadline", elapsed)
+	}
+}
+
+func TestRetryOptionsBackoff(t *testing.T) {
+	o := &RetryOptions{InitialDelay: time.Second, MaxDelay: 5 * time.Second, Jitter: Ptr(0.0)}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := o.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+
+	o.Jitter = Ptr(0.5)
+	for i := 0; i < 100; i++ {
+		if got := o.backoff(1); got < 500*time.Millisecond || got > 1500*time.Millisecond {
+			t.Fatalf("backoff(1) with jitter = %v, want within [0.5s, 1.5s]", got)
+		}
+	}
+}
+
+func TestUploadFileRetry(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		// The failed chunk is resumed from the committed offset, rather than
+		// retried by the transport.
+		if r.Header.Get("X-Goog-Upload-Command") == "query" {
+			w.Header().Set("X-Goog-Upload-Status", "active")
+			w.Header().Set("X-Goog-Upload-Size-Received", "0")
+			return
+		}
+		w.Header().Set("X-Goog-Upload-Status", "final")
+		fmt.Fprintf(w, `{"file": {"name": "files/test", "sizeBytes": "%d"}}`, len(body))
+	}))
+	defer ts.Close()
+
+	ac := &apiClient{clientConfig: &ClientConfig{
+		HTTPClient:  ts.Client(),
+		HTTPOptions: HTTPOptions{RetryOptions: &RetryOptions{InitialDelay: time.Millisecond}},
+	}}
+	file, err := ac.uploadFile(context.Background(), bytes.NewReader([]byte("hello")), ts.URL+"/upload", &HTTPOptions{Headers: http.Header{}})
+	if err != nil {
+		t.Fatalf("uploadFile() failed: %v", err)
+	}
+	if file.SizeBytes == nil || *file.SizeBytes != 5 {
+		t.Errorf("uploadFile() SizeBytes = %v, want 5", file.SizeBytes)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("server called %d times, want 3", got)
+	}
+}
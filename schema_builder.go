@@ -0,0 +1,314 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SchemaEnum is implemented by types whose values are restricted to a set of
+// strings. SchemaFor calls SchemaEnum on the zero value of the type, or on a
+// pointer to it if SchemaEnum has a pointer receiver.
+type SchemaEnum interface {
+	SchemaEnum() []string
+}
+
+var (
+	schemaEnumType = reflect.TypeFor[SchemaEnum]()
+	timeType       = reflect.TypeFor[time.Time]()
+)
+
+// SchemaFor returns the schema of the JSON encoding of values of type T. See
+// [SchemaForType].
+func SchemaFor[T any]() (*Schema, error) {
+	return SchemaForType(reflect.TypeFor[T]())
+}
+
+// SchemaForType returns the schema of the JSON encoding of values of type t,
+// following the rules of encoding/json:
+//
+//   - Struct fields are named after their json tag, and fields tagged "-" and
+//     unexported fields are skipped. Fields of embedded structs are promoted.
+//   - Fields are required unless their json tag has the omitempty or omitzero
+//     option.
+//   - Pointers are nullable.
+//   - Slices and arrays are arrays, except []byte, which is a base64 string.
+//   - Maps with string keys are objects without declared properties.
+//   - time.Time is a string in the date-time format.
+//
+// The description struct tag sets the description of a field. The values of a
+// string field are restricted with the enum struct tag, a comma-separated list
+// of values, or by implementing [SchemaEnum] on its type.
+//
+// Recursive types, interfaces, channels, functions and complex numbers can't be
+// expressed and return an error.
+func SchemaForType(t reflect.Type) (*Schema, error) {
+	b := &schemaBuilder{visiting: make(map[reflect.Type]bool)}
+	schema, err := b.schema(t)
+	if err != nil {
+		return nil, fmt.Errorf("SchemaForType: %w", err)
+	}
+	return schema, nil
+}
+
+// FunctionDeclarationFor returns the declaration of a function named name whose
+// arguments are decoded into a value of type Args, which must be a struct. The
+// parameters of the declaration are the schema of Args. fn is only used to infer
+// the types; see [RegisterFunction] to also bind it to a [ToolRegistry].
+func FunctionDeclarationFor[Args, Result any](name, description string, fn func(context.Context, Args) (Result, error)) (*FunctionDeclaration, error) {
+	argsType := reflect.TypeFor[Args]()
+	for argsType.Kind() == reflect.Pointer {
+		argsType = argsType.Elem()
+	}
+	if argsType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("FunctionDeclarationFor: arguments of function %q must be a struct, got %s", name, argsType)
+	}
+	parameters, err := SchemaForType(argsType)
+	if err != nil {
+		return nil, fmt.Errorf("FunctionDeclarationFor: invalid arguments of function %q: %w", name, err)
+	}
+	return &FunctionDeclaration{Name: name, Description: description, Parameters: parameters}, nil
+}
+
+// RegisterFunction declares fn with [FunctionDeclarationFor] and registers it to r.
+// The arguments of a call are decoded into Args through their JSON encoding, and
+// the result is encoded back. Results that don't encode to a JSON object are sent
+// to the model under the "result" key.
+func RegisterFunction[Args, Result any](r *ToolRegistry, name, description string, fn func(context.Context, Args) (Result, error)) error {
+	declaration, err := FunctionDeclarationFor(name, description, fn)
+	if err != nil {
+		return err
+	}
+	return r.Register(declaration, func(ctx context.Context, args map[string]any) (map[string]any, error) {
+		var typedArgs Args
+		if err := mapToStruct(args, &typedArgs); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		result, err := fn(ctx, typedArgs)
+		if err != nil {
+			return nil, err
+		}
+		b, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("invalid result: %w", err)
+		}
+		output := make(map[string]any)
+		if err := json.Unmarshal(b, &output); err != nil {
+			var value any
+			if err := json.Unmarshal(b, &value); err != nil {
+				return nil, fmt.Errorf("invalid result: %w", err)
+			}
+			output = map[string]any{"result": value}
+		}
+		return output, nil
+	})
+}
+
+type schemaBuilder struct {
+	// visiting holds the struct types being built, to detect recursive types.
+	visiting map[reflect.Type]bool
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) (*Schema, error) {
+	if t.Kind() == reflect.Pointer {
+		schema, err := b.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		schema.Nullable = Ptr(true)
+		return schema, nil
+	}
+	if t.Kind() != reflect.Interface {
+		var enum SchemaEnum
+		if t.Implements(schemaEnumType) {
+			enum = reflect.Zero(t).Interface().(SchemaEnum)
+		} else if reflect.PointerTo(t).Implements(schemaEnumType) {
+			enum = reflect.New(t).Interface().(SchemaEnum)
+		}
+		if enum != nil {
+			return &Schema{Type: TypeString, Enum: enum.SchemaEnum()}, nil
+		}
+	}
+	if t == timeType {
+		return &Schema{Type: TypeString, Format: "date-time"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: TypeBoolean}, nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: TypeInteger, Format: "int32"}, nil
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: TypeInteger, Format: "int64"}, nil
+	case reflect.Float32:
+		return &Schema{Type: TypeNumber, Format: "float"}, nil
+	case reflect.Float64:
+		return &Schema{Type: TypeNumber, Format: "double"}, nil
+	case reflect.String:
+		return &Schema{Type: TypeString}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: TypeString}, nil
+		}
+		items, err := b.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		schema := &Schema{Type: TypeArray, Items: items}
+		if t.Kind() == reflect.Array {
+			schema.MinItems = Ptr(int64(t.Len()))
+			schema.MaxItems = Ptr(int64(t.Len()))
+		}
+		return schema, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("map type %s must have string keys", t)
+		}
+		return &Schema{Type: TypeObject}, nil
+	case reflect.Struct:
+		return b.structSchema(t)
+	default:
+		return nil, fmt.Errorf("type %s is not supported", t)
+	}
+}
+
+func (b *schemaBuilder) structSchema(t reflect.Type) (*Schema, error) {
+	if b.visiting[t] {
+		return nil, fmt.Errorf("type %s is recursive, which schemas can't express", t)
+	}
+	b.visiting[t] = true
+	defer delete(b.visiting, t)
+
+	schema := &Schema{Type: TypeObject, Properties: make(map[string]*Schema)}
+	if err := b.addFields(schema, t, true, map[string]bool{}); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// addFields adds the fields of struct type t to schema, promoting the fields of
+// embedded structs. Fields named in shadowed belong to an enclosing struct and
+// win over the fields of t.
+func (b *schemaBuilder) addFields(schema *Schema, t reflect.Type, required bool, shadowed map[string]bool) error {
+	// The fields of t win over the fields of the structs embedded in t.
+	inner := maps.Clone(shadowed)
+	for i := 0; i < t.NumField(); i++ {
+		if name, ok := fieldName(t.Field(i)); ok {
+			inner[name] = true
+		}
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := fieldName(field)
+		if !ok {
+			if embeddedType, embeddedRequired, ok := embeddedStruct(field); ok {
+				if b.visiting[embeddedType] {
+					return fmt.Errorf("type %s is recursive, which schemas can't express", embeddedType)
+				}
+				b.visiting[embeddedType] = true
+				err := b.addFields(schema, embeddedType, required && embeddedRequired, inner)
+				delete(b.visiting, embeddedType)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if shadowed[name] {
+			continue
+		}
+		if _, ok := schema.Properties[name]; ok {
+			continue
+		}
+
+		_, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		fieldSchema, err := b.schema(field.Type)
+		if err != nil {
+			return fmt.Errorf("field %s.%s: %w", t, field.Name, err)
+		}
+		if hasTagOption(options, "string") {
+			fieldSchema.Type, fieldSchema.Format, fieldSchema.Items = TypeString, "", nil
+		}
+		if values, ok := field.Tag.Lookup("enum"); ok {
+			if fieldSchema.Type != TypeString {
+				return fmt.Errorf("field %s.%s: enum requires a string type, got %s", t, field.Name, field.Type)
+			}
+			fieldSchema.Enum = strings.Split(values, ",")
+		}
+		if description, ok := field.Tag.Lookup("description"); ok {
+			fieldSchema.Description = description
+		}
+		schema.Properties[name] = fieldSchema
+		schema.PropertyOrdering = append(schema.PropertyOrdering, name)
+		if required && !hasTagOption(options, "omitempty") && !hasTagOption(options, "omitzero") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return nil
+}
+
+// fieldName returns the JSON name of a struct field. It returns false for
+// skipped fields and for embedded structs, whose fields are promoted.
+func fieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		if _, _, ok := embeddedStruct(field); ok {
+			return "", false
+		}
+		name = field.Name
+	}
+	if !field.IsExported() {
+		return "", false
+	}
+	return name, true
+}
+
+// embeddedStruct returns the struct type of an embedded field whose fields are
+// promoted, and whether they are present in every value of the embedding struct.
+func embeddedStruct(field reflect.StructField) (reflect.Type, bool, bool) {
+	if !field.Anonymous || strings.Split(field.Tag.Get("json"), ",")[0] != "" || field.Tag.Get("json") == "-" {
+		return nil, false, false
+	}
+	t := field.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return nil, false, false
+	}
+	return t, field.Type.Kind() != reflect.Pointer, true
+}
+
+func hasTagOption(options, option string) bool {
+	for options != "" {
+		var o string
+		o, options, _ = strings.Cut(options, ",")
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,202 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type schemaTestUnit string
+
+func (schemaTestUnit) SchemaEnum() []string { return []string{"celsius", "fahrenheit"} }
+
+type schemaTestLevel string
+
+func (*schemaTestLevel) SchemaEnum() []string { return []string{"low", "high"} }
+
+type schemaTestBase struct {
+	ID      string `json:"id" description:"Unique identifier."`
+	Comment string `json:"comment"`
+}
+
+type schemaTestRecord struct {
+	schemaTestBase
+	Comment  string            `json:"comment,omitempty"`
+	Name     string            `json:"name"`
+	Age      int               `json:"age,omitempty"`
+	Score    *float64          `json:"score"`
+	Tags     []string          `json:"tags,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Created  time.Time         `json:"created"`
+	Unit     schemaTestUnit    `json:"unit"`
+	Level    schemaTestLevel   `json:"level"`
+	Color    string            `json:"color" enum:"red,green"`
+	Count    int64             `json:"count,string"`
+	Data     []byte            `json:"data,omitempty"`
+	Ignored  string            `json:"-"`
+	Untagged bool
+	private  int
+}
+
+type schemaTestNode struct {
+	Value    string            `json:"value"`
+	Children []*schemaTestNode `json:"children"`
+}
+
+func TestSchemaFor(t *testing.T) {
+	got, err := SchemaFor[schemaTestRecord]()
+	if err != nil {
+		t.Fatalf("SchemaFor() failed: %v", err)
+	}
+	want := &Schema{
+		Type: TypeObject,
+		Properties: map[string]*Schema{
+			"id":       {Type: TypeString, Description: "Unique identifier."},
+			"comment":  {Type: TypeString},
+			"name":     {Type: TypeString},
+			"age":      {Type: TypeInteger, Format: "int64"},
+			"score":    {Type: TypeNumber, Format: "double", Nullable: Ptr(true)},
+			"tags":     {Type: TypeArray, Items: &Schema{Type: TypeString}},
+			"labels":   {Type: TypeObject},
+			"created":  {Type: TypeString, Format: "date-time"},
+			"unit":     {Type: TypeString, Enum: []string{"celsius", "fahrenheit"}},
+			"level":    {Type: TypeString, Enum: []string{"low", "high"}},
+			"color":    {Type: TypeString, Enum: []string{"red", "green"}},
+			"count":    {Type: TypeString},
+			"data":     {Type: TypeString},
+			"Untagged": {Type: TypeBoolean},
+		},
+		PropertyOrdering: []string{"id", "comment", "name", "age", "score", "tags", "labels", "created", "unit", "level", "color", "count", "data", "Untagged"},
+		Required:         []string{"id", "name", "score", "created", "unit", "level", "color", "count", "Untagged"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("SchemaFor() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSchemaForTypeErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		t       reflect.Type
+		wantErr string
+	}{
+		{"recursive", reflect.TypeFor[schemaTestNode](), "recursive"},
+		{"interface", reflect.TypeFor[struct{ Any any }](), "not supported"},
+		{"enum interface", reflect.TypeFor[struct{ Enum SchemaEnum }](), "not supported"},
+		{"map keys", reflect.TypeFor[map[int]string](), "string keys"},
+		{"enum on int", reflect.TypeFor[struct {
+			N int `enum:"1,2"`
+		}](), "enum requires a string type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := SchemaForType(tt.t)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("SchemaForType() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSchemaForConverters(t *testing.T) {
+	schema, err := SchemaFor[schemaTestRecord]()
+	if err != nil {
+		t.Fatalf("SchemaFor() failed: %v", err)
+	}
+	schemaMap := make(map[string]any)
+	if err := deepMarshal(schema, &schemaMap); err != nil {
+		t.Fatalf("deepMarshal() failed: %v", err)
+	}
+	for _, backend := range []Backend{BackendGeminiAPI, BackendVertexAI} {
+		ac := &apiClient{clientConfig: &ClientConfig{Backend: backend}}
+		converter := schemaToMldev
+		if backend == BackendVertexAI {
+			converter = schemaToVertex
+		}
+		if _, err := converter(ac, schemaMap, nil); err != nil {
+			t.Errorf("%s converter failed: %v", backend, err)
+		}
+	}
+}
+
+type weatherArgs struct {
+	City string `json:"city" description:"Name of the city."`
+	Days int    `json:"days,omitempty"`
+}
+
+type weatherResult struct {
+	Forecast []string `json:"forecast"`
+}
+
+func TestRegisterFunction(t *testing.T) {
+	ctx := context.Background()
+	var gotArgs weatherArgs
+	registry := NewToolRegistry()
+	err := RegisterFunction(registry, "get_weather", "Returns the forecast.", func(ctx context.Context, args weatherArgs) (weatherResult, error) {
+		gotArgs = args
+		return weatherResult{Forecast: []string{"sunny", "rainy"}}, nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterFunction() failed: %v", err)
+	}
+	if err := RegisterFunction(registry, "echo", "", func(ctx context.Context, args weatherArgs) (string, error) {
+		return args.City, nil
+	}); err != nil {
+		t.Fatalf("RegisterFunction() failed: %v", err)
+	}
+	if err := RegisterFunction(registry, "bad", "", func(ctx context.Context, args string) (string, error) {
+		return args, nil
+	}); err == nil {
+		t.Error("RegisterFunction() with non-struct arguments succeeded, want error")
+	}
+
+	declaration := registry.Tool().FunctionDeclarations[0]
+	wantDeclaration := &FunctionDeclaration{
+		Name:        "get_weather",
+		Description: "Returns the forecast.",
+		Parameters: &Schema{
+			Type: TypeObject,
+			Properties: map[string]*Schema{
+				"city": {Type: TypeString, Description: "Name of the city."},
+				"days": {Type: TypeInteger, Format: "int64"},
+			},
+			PropertyOrdering: []string{"city", "days"},
+			Required:         []string{"city"},
+		},
+	}
+	if diff := cmp.Diff(wantDeclaration, declaration); diff != "" {
+		t.Errorf("declaration mismatch (-want +got):\n%s", diff)
+	}
+
+	resp := registry.Call(ctx, &FunctionCall{Name: "get_weather", Args: map[string]any{"city": "Paris", "days": float64(2)}})
+	if diff := cmp.Diff(weatherArgs{City: "Paris", Days: 2}, gotArgs); diff != "" {
+		t.Errorf("handler args mismatch (-want +got):\n%s", diff)
+	}
+	wantResponse := map[string]any{"output": map[string]any{"forecast": []any{"sunny", "rainy"}}}
+	if diff := cmp.Diff(wantResponse, resp.Response); diff != "" {
+		t.Errorf("function response mismatch (-want +got):\n%s", diff)
+	}
+
+	resp = registry.Call(ctx, &FunctionCall{Name: "echo", Args: map[string]any{"city": "Rome"}})
+	if diff := cmp.Diff(map[string]any{"output": map[string]any{"result": "Rome"}}, resp.Response); diff != "" {
+		t.Errorf("function response mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"fmt"
+	"iter"
+)
+
+// PromptBlockedError is returned in strict mode (see GenerateContentConfig.Strict)
+// when the server blocks the prompt and generates no candidates.
+type PromptBlockedError struct {
+	// BlockReason is the reason the prompt was blocked.
+	BlockReason BlockedReason
+	// BlockReasonMessage is a readable description of BlockReason.
+	BlockReasonMessage string
+	// SafetyRatings are the safety ratings of the prompt.
+	SafetyRatings []*SafetyRating
+	// Response is the response that reported the block.
+	Response *GenerateContentResponse
+}
+
+// Error returns a string representation of the PromptBlockedError.
+func (e *PromptBlockedError) Error() string {
+	if e.BlockReasonMessage != "" {
+		return fmt.Sprintf("prompt blocked: %s: %s", e.BlockReason, e.BlockReasonMessage)
+	}
+	return fmt.Sprintf("prompt blocked: %s", e.BlockReason)
+}
+
+// FinishReasonError is returned in strict mode (see GenerateContentConfig.Strict)
+// when a candidate stops for a reason other than [FinishReasonStop].
+type FinishReasonError struct {
+	// FinishReason is the reason the candidate stopped.
+	FinishReason FinishReason
+	// FinishMessage describes FinishReason in more detail.
+	FinishMessage string
+	// CandidateIndex is the index of the candidate that stopped.
+	CandidateIndex int32
+	// SafetyRatings are the safety ratings of the candidate.
+	SafetyRatings []*SafetyRating
+	// Content is the content that the candidate generated before it stopped. For
+	// streams, it holds the parts of all the chunks received for the candidate.
+	Content *Content
+	// Response is the response, or the stream chunk, that reported the finish reason.
+	Response *GenerateContentResponse
+}
+
+// Error returns a string representation of the FinishReasonError.
+func (e *FinishReasonError) Error() string {
+	if e.FinishMessage != "" {
+		return fmt.Sprintf("candidate %d finished with reason %s: %s", e.CandidateIndex, e.FinishReason, e.FinishMessage)
+	}
+	return fmt.Sprintf("candidate %d finished with reason %s", e.CandidateIndex, e.FinishReason)
+}
+
+// checkStrictResponse returns the strict mode error of resp, if any. For streams,
+// partial holds the parts received so far for each candidate.
+func checkStrictResponse(resp *GenerateContentResponse, partial map[int32][]*Part) error {
+	if resp == nil {
+		return nil
+	}
+	if feedback := resp.PromptFeedback; feedback != nil && feedback.BlockReason != "" {
+		return &PromptBlockedError{
+			BlockReason:        feedback.BlockReason,
+			BlockReasonMessage: feedback.BlockReasonMessage,
+			SafetyRatings:      feedback.SafetyRatings,
+			Response:           resp,
+		}
+	}
+	for _, candidate := range resp.Candidates {
+		if candidate == nil {
+			continue
+		}
+		switch candidate.FinishReason {
+		case "", FinishReasonUnspecified, FinishReasonStop:
+			continue
+		}
+		content := candidate.Content
+		if parts, ok := partial[candidate.Index]; ok {
+			content = &Content{Role: RoleModel, Parts: parts}
+		}
+		return &FinishReasonError{
+			FinishReason:   candidate.FinishReason,
+			FinishMessage:  candidate.FinishMessage,
+			CandidateIndex: candidate.Index,
+			SafetyRatings:  candidate.SafetyRatings,
+			Content:        content,
+			Response:       resp,
+		}
+	}
+	return nil
+}
+
+// strictStream applies strict mode to every chunk of stream. The stream ends with
+// the first strict mode error.
+func strictStream(stream iter.Seq2[*GenerateContentResponse, error]) iter.Seq2[*GenerateContentResponse, error] {
+	return func(yield func(*GenerateContentResponse, error) bool) {
+		partial := make(map[int32][]*Part)
+		for chunk, err := range stream {
+			if err != nil {
+				if !yield(nil, err) {
+					return
+				}
+				continue
+			}
+			if chunk != nil {
+				for _, candidate := range chunk.Candidates {
+					if candidate != nil && candidate.Content != nil {
+						partial[candidate.Index] = append(partial[candidate.Index], candidate.Content.Parts...)
+					}
+				}
+			}
+			if err := checkStrictResponse(chunk, partial); err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(chunk, nil) {
+				return
+			}
+		}
+	}
+}
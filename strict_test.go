@@ -0,0 +1,166 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStrictGenerateContent(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		desc     string
+		response string
+		strict   bool
+		check    func(t *testing.T, err error)
+	}{
+		{
+			desc:     "blocked prompt",
+			response: `{"promptFeedback": {"blockReason": "SAFETY", "safetyRatings": [{"category": "HARM_CATEGORY_HARASSMENT", "probability": "HIGH", "blocked": true}]}}`,
+			strict:   true,
+			check: func(t *testing.T, err error) {
+				var blocked *PromptBlockedError
+				if !errors.As(err, &blocked) {
+					t.Fatalf("GenerateContent() error = %v, want *PromptBlockedError", err)
+				}
+				if blocked.BlockReason != BlockedReasonSafety || len(blocked.SafetyRatings) != 1 || !blocked.SafetyRatings[0].Blocked {
+					t.Errorf("PromptBlockedError = %+v, want SAFETY with a blocked rating", blocked)
+				}
+			},
+		},
+		{
+			desc:     "safety finish reason",
+			response: `{"candidates": [{"content": {"role": "model", "parts": [{"text": "partial"}]}, "finishReason": "SAFETY", "safetyRatings": [{"category": "HARM_CATEGORY_DANGEROUS_CONTENT", "probability": "HIGH"}]}]}`,
+			strict:   true,
+			check: func(t *testing.T, err error) {
+				var finished *FinishReasonError
+				if !errors.As(err, &finished) {
+					t.Fatalf("GenerateContent() error = %v, want *FinishReasonError", err)
+				}
+				if finished.FinishReason != FinishReasonSafety || len(finished.SafetyRatings) != 1 {
+					t.Errorf("FinishReasonError = %+v, want SAFETY with a rating", finished)
+				}
+				if diff := cmp.Diff(Text("partial")[0].Parts, finished.Content.Parts); diff != "" {
+					t.Errorf("partial content mismatch (-want +got):\n%s", diff)
+				}
+			},
+		},
+		{
+			desc:     "max tokens",
+			response: `{"candidates": [{"content": {"role": "model", "parts": [{"text": "trunc"}]}, "finishReason": "MAX_TOKENS"}]}`,
+			strict:   true,
+			check: func(t *testing.T, err error) {
+				var finished *FinishReasonError
+				if !errors.As(err, &finished) || finished.FinishReason != FinishReasonMaxTokens {
+					t.Errorf("GenerateContent() error = %v, want *FinishReasonError with MAX_TOKENS", err)
+				}
+			},
+		},
+		{
+			desc:     "stop",
+			response: `{"candidates": [{"content": {"role": "model", "parts": [{"text": "done"}]}, "finishReason": "STOP"}]}`,
+			strict:   true,
+		},
+		{
+			desc:     "not strict",
+			response: `{"candidates": [{"finishReason": "SAFETY"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.response)
+			}))
+			defer ts.Close()
+			client := newTestClient(t, ts, nil)
+
+			resp, err := client.Models.GenerateContent(ctx, "gemini-2.0-flash", Text("hello"), &GenerateContentConfig{Strict: tt.strict})
+			if tt.check == nil {
+				if err != nil || resp == nil {
+					t.Errorf("GenerateContent() = %v, %v, want a response", resp, err)
+				}
+				return
+			}
+			if resp != nil {
+				t.Errorf("GenerateContent() response = %v, want nil", resp)
+			}
+			tt.check(t, err)
+		})
+	}
+}
+
+func TestStrictGenerateContentStream(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data:{\"candidates\": [{\"content\": {\"role\": \"model\", \"parts\": [{\"text\": \"one \"}]}}]}\n\n")
+		fmt.Fprint(w, "data:{\"candidates\": [{\"content\": {\"role\": \"model\", \"parts\": [{\"text\": \"two\"}]}, \"finishReason\": \"RECITATION\"}]}\n\n")
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts, nil)
+
+	var texts []string
+	var gotErr error
+	for resp, err := range client.Models.GenerateContentStream(context.Background(), "gemini-2.0-flash", Text("hello"), &GenerateContentConfig{Strict: true}) {
+		if err != nil {
+			gotErr = err
+			continue
+		}
+		texts = append(texts, resp.Text())
+	}
+	if diff := cmp.Diff([]string{"one "}, texts); diff != "" {
+		t.Errorf("stream mismatch (-want +got):\n%s", diff)
+	}
+	var finished *FinishReasonError
+	if !errors.As(gotErr, &finished) || finished.FinishReason != FinishReasonRecitation {
+		t.Fatalf("stream error = %v, want *FinishReasonError with RECITATION", gotErr)
+	}
+	want := []*Part{{Text: "one "}, {Text: "two"}}
+	if diff := cmp.Diff(want, finished.Content.Parts); diff != "" {
+		t.Errorf("partial content mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStrictChatSendMessageStream(t *testing.T) {
+	ctx := context.Background()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data:{\"promptFeedback\": {\"blockReason\": \"PROHIBITED_CONTENT\"}}\n\n")
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts, nil)
+
+	chat, err := client.Chats.Create(ctx, "gemini-2.0-flash", &GenerateContentConfig{Strict: true}, nil)
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	var gotErr error
+	for _, err := range chat.SendMessageStream(ctx, Part{Text: "hello"}) {
+		if err != nil {
+			gotErr = err
+		}
+	}
+	var blocked *PromptBlockedError
+	if !errors.As(gotErr, &blocked) || blocked.BlockReason != BlockedReasonProhibitedContent {
+		t.Errorf("SendMessageStream() error = %v, want *PromptBlockedError with PROHIBITED_CONTENT", gotErr)
+	}
+	if len(chat.History(false)) != 0 {
+		t.Errorf("History() = %v, want no recorded turns for a blocked prompt", chat.History(false))
+	}
+}
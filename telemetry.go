@@ -0,0 +1,316 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer and meter used by the SDK.
+const instrumentationName = "google.golang.org/genai"
+
+// Attribute keys of the OpenTelemetry semantic conventions for generative AI
+// systems. See https://opentelemetry.io/docs/specs/semconv/gen-ai/.
+const (
+	attrOperationName      = attribute.Key("gen_ai.operation.name")
+	attrSystem             = attribute.Key("gen_ai.system")
+	attrRequestModel       = attribute.Key("gen_ai.request.model")
+	attrRequestTemperature = attribute.Key("gen_ai.request.temperature")
+	attrRequestTopP        = attribute.Key("gen_ai.request.top_p")
+	attrRequestTopK        = attribute.Key("gen_ai.request.top_k")
+	attrRequestMaxTokens   = attribute.Key("gen_ai.request.max_tokens")
+	attrResponseModel      = attribute.Key("gen_ai.response.model")
+	attrResponseID         = attribute.Key("gen_ai.response.id")
+	attrFinishReasons      = attribute.Key("gen_ai.response.finish_reasons")
+	attrInputTokens        = attribute.Key("gen_ai.usage.input_tokens")
+	attrOutputTokens       = attribute.Key("gen_ai.usage.output_tokens")
+	attrCachedTokens       = attribute.Key("gen_ai.usage.cache_read.input_tokens")
+	attrTokenType          = attribute.Key("gen_ai.token.type")
+	attrServerAddress      = attribute.Key("server.address")
+	attrErrorType          = attribute.Key("error.type")
+)
+
+// telemetry holds the OpenTelemetry instruments of a client. A nil *telemetry
+// disables instrumentation.
+type telemetry struct {
+	tracer           trace.Tracer
+	propagator       propagation.TextMapPropagator
+	duration         metric.Float64Histogram
+	tokenUsage       metric.Int64Histogram
+	timeToFirstChunk metric.Float64Histogram
+	timePerChunk     metric.Float64Histogram
+}
+
+// newTelemetry creates the instruments for the providers set in cc. It returns
+// nil if neither a tracer provider nor a meter provider is set.
+func newTelemetry(cc *ClientConfig) (*telemetry, error) {
+	if cc.TracerProvider == nil && cc.MeterProvider == nil {
+		return nil, nil
+	}
+	tracerProvider := cc.TracerProvider
+	if tracerProvider == nil {
+		tracerProvider = otel.GetTracerProvider()
+	}
+	meterProvider := cc.MeterProvider
+	if meterProvider == nil {
+		meterProvider = otel.GetMeterProvider()
+	}
+	meter := meterProvider.Meter(instrumentationName, metric.WithInstrumentationVersion(version))
+
+	t := &telemetry{
+		tracer:     tracerProvider.Tracer(instrumentationName, trace.WithInstrumentationVersion(version)),
+		propagator: otel.GetTextMapPropagator(),
+	}
+	var err error
+	t.duration, err = meter.Float64Histogram("gen_ai.client.operation.duration",
+		metric.WithDescription("Duration of SDK operations."), metric.WithUnit("s"))
+	if err != nil {
+		return nil, fmt.Errorf("newTelemetry: error creating duration histogram: %w", err)
+	}
+	t.tokenUsage, err = meter.Int64Histogram("gen_ai.client.token.usage",
+		metric.WithDescription("Number of input and output tokens used."), metric.WithUnit("{token}"))
+	if err != nil {
+		return nil, fmt.Errorf("newTelemetry: error creating token usage histogram: %w", err)
+	}
+	t.timeToFirstChunk, err = meter.Float64Histogram("gen_ai.client.operation.time_to_first_chunk",
+		metric.WithDescription("Time from the start of a streaming operation to its first chunk."), metric.WithUnit("s"))
+	if err != nil {
+		return nil, fmt.Errorf("newTelemetry: error creating time to first chunk histogram: %w", err)
+	}
+	t.timePerChunk, err = meter.Float64Histogram("gen_ai.client.operation.time_per_output_chunk",
+		metric.WithDescription("Time between consecutive chunks of a streaming operation."), metric.WithUnit("s"))
+	if err != nil {
+		return nil, fmt.Errorf("newTelemetry: error creating time per chunk histogram: %w", err)
+	}
+	return t, nil
+}
+
+type callSpanKey struct{}
+
+// callSpan records the span and the metrics of one SDK operation.
+// All methods are no-ops on a nil *callSpan.
+type callSpan struct {
+	t            *telemetry
+	span         trace.Span
+	start        time.Time
+	lastChunk    time.Time
+	chunks       int
+	attrs        []attribute.KeyValue
+	responseID   string
+	model        string
+	finishReason []string
+	usage        map[string]any
+}
+
+// startSpan starts the span of the operation set on ctx by withOperation. It
+// returns a nil span if telemetry is disabled, or if ctx is already inside the
+// span of an operation, such as the file creation of Files.Upload.
+func (ac *apiClient) startSpan(ctx context.Context, model string, body map[string]any, httpOptions *HTTPOptions) (context.Context, *callSpan) {
+	if ac.telemetry == nil || ctx.Value(callSpanKey{}) != nil {
+		return ctx, nil
+	}
+	operation := operationFromContext(ctx)
+	attrs := []attribute.KeyValue{
+		attrOperationName.String(genAIOperationName(operation)),
+		attrSystem.String(genAISystem(ac.clientConfig.Backend)),
+	}
+	if model != "" {
+		attrs = append(attrs, attrRequestModel.String(strings.TrimPrefix(model, "models/")))
+	}
+	if httpOptions != nil {
+		if u, err := url.Parse(httpOptions.BaseURL); err == nil && u.Hostname() != "" {
+			attrs = append(attrs, attrServerAddress.String(u.Hostname()))
+		}
+	}
+
+	spanAttrs := slices.Clone(attrs)
+	if config, ok := body["generationConfig"].(map[string]any); ok {
+		if v, ok := config["temperature"].(float64); ok {
+			spanAttrs = append(spanAttrs, attrRequestTemperature.Float64(v))
+		}
+		if v, ok := config["topP"].(float64); ok {
+			spanAttrs = append(spanAttrs, attrRequestTopP.Float64(v))
+		}
+		if v, ok := config["topK"].(float64); ok {
+			spanAttrs = append(spanAttrs, attrRequestTopK.Float64(v))
+		}
+		if v, ok := config["maxOutputTokens"].(float64); ok {
+			spanAttrs = append(spanAttrs, attrRequestMaxTokens.Int64(int64(v)))
+		}
+	}
+
+	cs := &callSpan{t: ac.telemetry, start: time.Now(), attrs: attrs}
+	ctx, cs.span = ac.telemetry.tracer.Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(spanAttrs...))
+	return context.WithValue(ctx, callSpanKey{}, cs), cs
+}
+
+// injectTraceContext propagates the trace context of ctx into header, if
+// telemetry is enabled.
+func (ac *apiClient) injectTraceContext(ctx context.Context, header http.Header) {
+	if ac.telemetry == nil {
+		return
+	}
+	ac.telemetry.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// observe records the attributes of a raw response body, before its conversion
+// to SDK types. Stream chunks are observed one by one.
+func (cs *callSpan) observe(resp map[string]any) {
+	if cs == nil || resp == nil {
+		return
+	}
+	if v, ok := resp["responseId"].(string); ok {
+		cs.responseID = v
+	}
+	if v, ok := resp["modelVersion"].(string); ok {
+		cs.model = v
+	}
+	if v, ok := resp["usageMetadata"].(map[string]any); ok {
+		cs.usage = v
+	}
+	candidates, _ := resp["candidates"].([]any)
+	for _, c := range candidates {
+		candidate, _ := c.(map[string]any)
+		if reason, ok := candidate["finishReason"].(string); ok && !slices.Contains(cs.finishReason, reason) {
+			cs.finishReason = append(cs.finishReason, reason)
+		}
+	}
+}
+
+// chunk records the arrival of a stream chunk.
+func (cs *callSpan) chunk(resp map[string]any) {
+	if cs == nil {
+		return
+	}
+	now := time.Now()
+	ctx := trace.ContextWithSpan(context.Background(), cs.span)
+	if cs.chunks == 0 {
+		cs.t.timeToFirstChunk.Record(ctx, now.Sub(cs.start).Seconds(), metric.WithAttributes(cs.attrs...))
+	} else {
+		cs.t.timePerChunk.Record(ctx, now.Sub(cs.lastChunk).Seconds(), metric.WithAttributes(cs.attrs...))
+	}
+	cs.chunks++
+	cs.lastChunk = now
+	cs.observe(resp)
+}
+
+// end ends the span and records the duration and token usage of the operation.
+func (cs *callSpan) end(err error) {
+	if cs == nil {
+		return
+	}
+	ctx := trace.ContextWithSpan(context.Background(), cs.span)
+	// Attributes shared by the span and the metrics.
+	var common []attribute.KeyValue
+	if cs.model != "" {
+		common = append(common, attrResponseModel.String(cs.model))
+	}
+	if err != nil {
+		common = append(common, attrErrorType.String(telemetryErrorType(err)))
+		cs.span.RecordError(err)
+		cs.span.SetStatus(codes.Error, err.Error())
+	}
+	attrs := append(slices.Clone(cs.attrs), common...)
+
+	spanAttrs := common
+	if cs.responseID != "" {
+		spanAttrs = append(spanAttrs, attrResponseID.String(cs.responseID))
+	}
+	if len(cs.finishReason) > 0 {
+		spanAttrs = append(spanAttrs, attrFinishReasons.StringSlice(cs.finishReason))
+	}
+	if v, ok := cs.usage["promptTokenCount"].(float64); ok {
+		spanAttrs = append(spanAttrs, attrInputTokens.Int64(int64(v)))
+		cs.t.tokenUsage.Record(ctx, int64(v), metric.WithAttributes(append(attrs, attrTokenType.String("input"))...))
+	}
+	if v, ok := cs.usage["candidatesTokenCount"].(float64); ok {
+		spanAttrs = append(spanAttrs, attrOutputTokens.Int64(int64(v)))
+		cs.t.tokenUsage.Record(ctx, int64(v), metric.WithAttributes(append(attrs, attrTokenType.String("output"))...))
+	}
+	if v, ok := cs.usage["cachedContentTokenCount"].(float64); ok {
+		spanAttrs = append(spanAttrs, attrCachedTokens.Int64(int64(v)))
+	}
+	cs.span.SetAttributes(spanAttrs...)
+	cs.t.duration.Record(ctx, time.Since(cs.start).Seconds(), metric.WithAttributes(attrs...))
+	cs.span.End()
+}
+
+// genAIOperationName maps an SDK operation to a well-known operation name of
+// the semantic conventions, or returns the SDK operation unchanged.
+func genAIOperationName(operation string) string {
+	switch operation {
+	case "Models.GenerateContent", "Models.GenerateContentStream", "Live.Connect":
+		return "generate_content"
+	case "Models.EmbedContent":
+		return "embeddings"
+	default:
+		return operation
+	}
+}
+
+func genAISystem(backend Backend) string {
+	if backend == BackendVertexAI {
+		return "vertex_ai"
+	}
+	return "gemini"
+}
+
+// telemetryErrorType returns the value of the error.type attribute for err.
+func telemetryErrorType(err error) string {
+	var apiErr APIError
+	switch {
+	case errors.As(err, &apiErr):
+		if apiErr.Status != "" {
+			return apiErr.Status
+		}
+		return strconv.Itoa(apiErr.Code)
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded"
+	default:
+		return "_OTHER"
+	}
+}
+
+// modelFromPath returns the model addressed by a request path, such as
+// "gemini-2.0-flash" for "publishers/google/models/gemini-2.0-flash:generateContent".
+func modelFromPath(path string) string {
+	i := strings.LastIndex(path, "models/")
+	if i < 0 {
+		return ""
+	}
+	model := path[i+len("models/"):]
+	if j := strings.IndexAny(model, ":/?"); j >= 0 {
+		model = model[:j]
+	}
+	return model
+}
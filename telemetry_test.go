@@ -0,0 +1,249 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTelemetryTestClient(t *testing.T, ts *httptest.Server) (*Client, *tracetest.SpanRecorder, *sdkmetric.ManualReader) {
+	t.Helper()
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	client := newTestClient(t, ts, &ClientConfig{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+		MeterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	})
+	return client, spans, reader
+}
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func collectMetrics(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Aggregation {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect() failed: %v", err)
+	}
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+func TestTelemetryGenerateContent(t *testing.T) {
+	propagator := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(propagator) })
+
+	var gotTraceparent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTraceparent = r.Header.Get("traceparent")
+		fmt.Fprint(w, `{
+			"candidates": [{"content": {"role": "model", "parts": [{"text": "hi"}]}, "finishReason": "STOP"}],
+			"usageMetadata": {"promptTokenCount": 3, "candidatesTokenCount": 5, "cachedContentTokenCount": 2, "totalTokenCount": 8},
+			"modelVersion": "gemini-2.0-flash-001",
+			"responseId": "response-1"
+		}`)
+	}))
+	defer ts.Close()
+	client, spans, reader := newTelemetryTestClient(t, ts)
+
+	_, err := client.Models.GenerateContent(context.Background(), "gemini-2.0-flash", Text("hello"), &GenerateContentConfig{Temperature: Ptr[float32](0.5)})
+	if err != nil {
+		t.Fatalf("GenerateContent() failed: %v", err)
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("got %d spans, want 1", len(ended))
+	}
+	span := ended[0]
+	if span.Name() != "Models.GenerateContent" {
+		t.Errorf("span name = %q, want Models.GenerateContent", span.Name())
+	}
+	if gotTraceparent == "" || gotTraceparent[36:52] != span.SpanContext().SpanID().String() {
+		t.Errorf("traceparent = %q, want the span ID %s", gotTraceparent, span.SpanContext().SpanID())
+	}
+	attrs := spanAttributes(span)
+	want := map[attribute.Key]any{
+		attrOperationName:      "generate_content",
+		attrSystem:             "gemini",
+		attrRequestModel:       "gemini-2.0-flash",
+		attrRequestTemperature: 0.5,
+		attrResponseModel:      "gemini-2.0-flash-001",
+		attrResponseID:         "response-1",
+		attrFinishReasons:      []string{"STOP"},
+		attrInputTokens:        int64(3),
+		attrOutputTokens:       int64(5),
+		attrCachedTokens:       int64(2),
+	}
+	for k, v := range want {
+		if diff := cmp.Diff(v, attrs[k].AsInterface()); diff != "" {
+			t.Errorf("span attribute %s mismatch (-want +got):\n%s", k, diff)
+		}
+	}
+
+	metrics := collectMetrics(t, reader)
+	duration, ok := metrics["gen_ai.client.operation.duration"].(metricdata.Histogram[float64])
+	if !ok || len(duration.DataPoints) != 1 || duration.DataPoints[0].Count != 1 {
+		t.Errorf("duration metric = %#v, want one data point", metrics["gen_ai.client.operation.duration"])
+	}
+	usage, ok := metrics["gen_ai.client.token.usage"].(metricdata.Histogram[int64])
+	if !ok {
+		t.Fatalf("token usage metric = %#v, want histogram", metrics["gen_ai.client.token.usage"])
+	}
+	gotUsage := make(map[string]int64)
+	for _, dp := range usage.DataPoints {
+		tokenType, _ := dp.Attributes.Value(attrTokenType)
+		gotUsage[tokenType.AsString()] = dp.Sum
+	}
+	if diff := cmp.Diff(map[string]int64{"input": 3, "output": 5}, gotUsage); diff != "" {
+		t.Errorf("token usage mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTelemetryStream(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data:{\"candidates\": [{\"content\": {\"role\": \"model\", \"parts\": [{\"text\": \"a\"}]}}]}\n\n")
+		fmt.Fprint(w, "data:{\"candidates\": [{\"content\": {\"role\": \"model\", \"parts\": [{\"text\": \"b\"}]}, \"finishReason\": \"STOP\"}], \"usageMetadata\": {\"promptTokenCount\": 2, \"candidatesTokenCount\": 4}}\n\n")
+	}))
+	defer ts.Close()
+	client, spans, reader := newTelemetryTestClient(t, ts)
+
+	for _, err := range client.Models.GenerateContentStream(context.Background(), "gemini-2.0-flash", Text("hello"), nil) {
+		if err != nil {
+			t.Fatalf("GenerateContentStream() failed: %v", err)
+		}
+		if len(spans.Ended()) != 0 {
+			t.Errorf("span ended before the stream was consumed")
+		}
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("got %d spans, want 1", len(ended))
+	}
+	attrs := spanAttributes(ended[0])
+	if got := attrs[attrOutputTokens].AsInt64(); got != 4 {
+		t.Errorf("output tokens = %d, want 4", got)
+	}
+	if diff := cmp.Diff([]string{"STOP"}, attrs[attrFinishReasons].AsStringSlice()); diff != "" {
+		t.Errorf("finish reasons mismatch (-want +got):\n%s", diff)
+	}
+
+	metrics := collectMetrics(t, reader)
+	for name, count := range map[string]uint64{
+		"gen_ai.client.operation.time_to_first_chunk":   1,
+		"gen_ai.client.operation.time_per_output_chunk": 1,
+	} {
+		h, ok := metrics[name].(metricdata.Histogram[float64])
+		if !ok || len(h.DataPoints) != 1 || h.DataPoints[0].Count != count {
+			t.Errorf("%s = %#v, want %d recordings", name, metrics[name], count)
+		}
+	}
+}
+
+func TestTelemetryError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error": {"code": 404, "message": "not found", "status": "NOT_FOUND"}}`)
+	}))
+	defer ts.Close()
+	client, spans, _ := newTelemetryTestClient(t, ts)
+
+	if _, err := client.Models.Get(context.Background(), "gemini-2.0-flash", nil); err == nil {
+		t.Fatal("Get() succeeded, want error")
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("got %d spans, want 1", len(ended))
+	}
+	if ended[0].Status().Code != codes.Error {
+		t.Errorf("span status = %v, want Error", ended[0].Status())
+	}
+	if got := spanAttributes(ended[0])[attrErrorType].AsString(); got != "NOT_FOUND" {
+		t.Errorf("error type = %q, want NOT_FOUND", got)
+	}
+}
+
+func TestTelemetryUpload(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Goog-Upload-Command") == "start" {
+			w.Header().Set("X-Goog-Upload-URL", "http://"+r.Host+"/upload")
+			fmt.Fprint(w, `{}`)
+			return
+		}
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("X-Goog-Upload-Status", "final")
+		fmt.Fprint(w, `{"file": {"name": "files/test"}}`)
+	}))
+	defer ts.Close()
+	client, spans, _ := newTelemetryTestClient(t, ts)
+
+	if _, err := client.Files.Upload(context.Background(), bytes.NewReader([]byte("hello")), &UploadFileConfig{MIMEType: "text/plain"}); err != nil {
+		t.Fatalf("Upload() failed: %v", err)
+	}
+
+	var names []string
+	for _, span := range spans.Ended() {
+		names = append(names, span.Name())
+	}
+	if diff := cmp.Diff([]string{"Files.Upload"}, names); diff != "" {
+		t.Errorf("spans mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModelFromPath(t *testing.T) {
+	tests := map[string]string{
+		"models/gemini-2.0-flash:generateContent":                                    "gemini-2.0-flash",
+		"publishers/google/models/gemini-2.0-flash:streamGenerateContent?alt=sse":    "gemini-2.0-flash",
+		"projects/p/locations/l/publishers/google/models/text-embedding-004:predict": "text-embedding-004",
+		"models/gemini-2.0-flash":                                                    "gemini-2.0-flash",
+		"cachedContents/123":                                                         "",
+		"projects/p/locations/l/tunedModels/123":                                     "",
+	}
+	for path, want := range tests {
+		if got := modelFromPath(path); got != want {
+			t.Errorf("modelFromPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -797,6 +797,11 @@ type HTTPOptions struct {
 	APIVersion string `json:"apiVersion,omitempty"`
 	// Additional HTTP headers to be sent with the request.
 	Headers http.Header `json:"headers,omitempty"`
+
+	// Handwritten fields, see types_handwritten.go.
+
+	// Optional. Retry policy for failed requests. If nil, requests are not retried.
+	RetryOptions *RetryOptions `json:"retryOptions,omitempty"`
 }
 
 // Schema that defines the format of input and output data.
@@ -1295,6 +1300,18 @@ type GenerateContentConfig struct {
 	AudioTimestamp bool `json:"audioTimestamp,omitempty"`
 	// The thinking features configuration.
 	ThinkingConfig *ThinkingConfig `json:"thinkingConfig,omitempty"`
+
+	// Handwritten fields, see types_handwritten.go.
+
+	// Optional. If true, a blocked prompt is returned as a *PromptBlockedError and
+	// a candidate that stops for a reason other than STOP, such as SAFETY or
+	// MAX_TOKENS, as a *FinishReasonError instead of a successful response. It
+	// applies to every chunk of a stream. The field is not sent to the server.
+	Strict bool `json:"-"`
+	// Optional. If set, the SDK executes the function calls of the model with the
+	// handlers of its registry. See [AutomaticFunctionCallingConfig]. The field is
+	// not sent to the server.
+	AutomaticFunctionCalling *AutomaticFunctionCallingConfig `json:"-"`
 }
 
 // Source attributions for content.
@@ -1589,6 +1606,13 @@ type GenerateContentResponse struct {
 	PromptFeedback *GenerateContentResponsePromptFeedback `json:"promptFeedback,omitempty"`
 	// Usage metadata about the response(s).
 	UsageMetadata *GenerateContentResponseUsageMetadata `json:"usageMetadata,omitempty"`
+
+	// Handwritten fields, see types_handwritten.go.
+
+	// The turns exchanged in automatic function calling mode before this response:
+	// the function calls of the model, each followed by the function responses
+	// sent back to it. See [AutomaticFunctionCallingConfig].
+	AutomaticFunctionCallingHistory []*Content `json:"-"`
 }
 
 // Text concatenates all the text parts in the GenerateContentResponse.
@@ -2904,12 +2928,50 @@ type UploadFileConfig struct {
 	MIMEType string `json:"mimeType,omitempty"`
 	// Optional display name of the file.
 	DisplayName string `json:"displayName,omitempty"`
+
+	// Handwritten fields, see types_handwritten.go.
+
+	// Optional. Size in bytes of the chunks in which the file is sent, which must be
+	// a multiple of 256 KiB. Defaults to 8 MiB. The field is not sent to the server.
+	ChunkSize int `json:"-"`
+	// Optional. Number of times the upload of a chunk is resumed from the offset
+	// that the server committed after a transport or server error, before the
+	// upload fails. Defaults to 3; a negative value disables resumption. The field
+	// is not sent to the server.
+	ResumeAttempts int `json:"-"`
+	// Optional. Called after each chunk with the progress of the upload. The field
+	// is not sent to the server.
+	Progress func(UploadProgress) `json:"-"`
+	// Optional. If set, the upload waits until the processing of the file ends, as
+	// Files.WaitUntilActive does with this config, so that the returned file is
+	// ACTIVE and can be used in requests. Set it to &WaitUntilActiveConfig{} to
+	// use the default polling. The field is not sent to the server.
+	WaitUntilActive *WaitUntilActiveConfig `json:"-"`
+	// Optional. If set, the upload computes the SHA-256 hash of the file and
+	// reuses an uploaded file with the same hash, found in the index or in
+	// Files.All, instead of uploading it again. Files that are FAILED or that
+	// expire within 5 minutes are not reused. The reader passed to Upload must
+	// implement io.Seeker. The field is not sent to the server.
+	DedupIndex FileIndex `json:"-"`
 }
 
 // Used to override the default configuration.
 type DownloadFileConfig struct {
 	// Used to override HTTP request options.
 	HTTPOptions *HTTPOptions `json:"httpOptions,omitempty"`
+
+	// Handwritten fields, see types_handwritten.go.
+
+	// Optional. Number of times the download resumes with an HTTP Range request
+	// after a connection or server error, before the download fails. Defaults to
+	// 3; a negative value disables resumption. The field is not sent to the server.
+	ResumeAttempts int `json:"-"`
+	// Optional. Called as the file is written with the progress of the download.
+	// The field is not sent to the server.
+	Progress func(DownloadProgress) `json:"-"`
+	// Optional. If set, Files.Download doesn't copy the downloaded bytes to the
+	// VideoBytes field of a video. The field is not sent to the server.
+	SkipVideoBytes bool `json:"-"`
 }
 
 // Configuration for upscaling an image.
@@ -3575,6 +3637,13 @@ type LiveConnectConfig struct {
 	// Configures context window compression mechanism.
 	// If included, server will compress context window to fit into given length.
 	ContextWindowCompression *ContextWindowCompressionConfig `json:"contextWindowCompression,omitempty"`
+
+	// Handwritten fields, see types_handwritten.go.
+
+	// Optional. If set, the session resumes itself on a new connection when the
+	// server sends GoAway or the connection fails. Session resumption is enabled
+	// if SessionResumption is not set. The field is not sent to the server.
+	Reconnect *LiveReconnectConfig `json:"-"`
 }
 
 // Parameters for sending client content to the live API.
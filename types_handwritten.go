@@ -0,0 +1,40 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+// Some generated types of types.go have handwritten fields, which are not part
+// of the API definitions that types.go is generated from. They follow a
+// "Handwritten fields" comment at the end of their struct, and must be kept when
+// types.go is regenerated. The declaration below refers to each of them, so that
+// the package doesn't compile if one is dropped.
+var _ = []any{
+	// See retry.go.
+	HTTPOptions{RetryOptions: nil},
+	// See strict.go.
+	GenerateContentConfig{Strict: false},
+	// See function_calling.go.
+	GenerateContentConfig{AutomaticFunctionCalling: nil},
+	GenerateContentResponse{AutomaticFunctionCallingHistory: nil},
+	// See upload.go.
+	UploadFileConfig{ChunkSize: 0, ResumeAttempts: 0, Progress: nil},
+	// See files_wait.go.
+	UploadFileConfig{WaitUntilActive: nil},
+	// See files_dedup.go.
+	UploadFileConfig{DedupIndex: nil},
+	// See download.go.
+	DownloadFileConfig{ResumeAttempts: 0, Progress: nil, SkipVideoBytes: false},
+	// See live_reconnect.go.
+	LiveConnectConfig{Reconnect: nil},
+}
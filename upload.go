@@ -0,0 +1,477 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultUploadResumeAttempts = 3
+	uploadCancelTimeout         = 10 * time.Second
+	// uploadChunkGranularity is the size that the chunks of a resumable upload,
+	// except the last one, must be a multiple of.
+	uploadChunkGranularity = 256 * 1024
+)
+
+// UploadSession identifies a resumable upload session. It is serializable, so
+// that a process can persist it and resume the upload after a restart with
+// [Files.ResumeUpload].
+type UploadSession struct {
+	// URL is the upload URL of the session.
+	URL string `json:"url"`
+	// SizeBytes is the size of the file, or 0 if it is unknown.
+	SizeBytes int64 `json:"sizeBytes,omitempty"`
+}
+
+// UploadProgress reports the progress of an upload.
+type UploadProgress struct {
+	// UploadedBytes is the number of bytes that the server committed.
+	UploadedBytes int64
+	// TotalBytes is the size of the file, or 0 if it is unknown.
+	TotalBytes int64
+}
+
+// uploader sends the contents of a reader to a resumable upload session, chunk
+// by chunk. When a chunk fails, it queries the offset that the server
+// committed and sends the rest of the chunk from there.
+type uploader struct {
+	ac             *apiClient
+	session        *UploadSession
+	httpOptions    *HTTPOptions
+	chunkSize      int
+	resumeAttempts int
+	progress       func(UploadProgress)
+	// offset is the number of bytes that the server committed.
+	offset int64
+}
+
+func newUploader(ac *apiClient, session *UploadSession, httpOptions *HTTPOptions, config *UploadFileConfig) (*uploader, error) {
+	chunkSize, err := uploadChunkSize(config)
+	if err != nil {
+		return nil, err
+	}
+	u := &uploader{
+		ac:             ac,
+		session:        session,
+		httpOptions:    httpOptions,
+		chunkSize:      chunkSize,
+		resumeAttempts: defaultUploadResumeAttempts,
+	}
+	if config != nil {
+		if config.ResumeAttempts != 0 {
+			u.resumeAttempts = max(config.ResumeAttempts, 0)
+		}
+		u.progress = config.Progress
+	}
+	return u, nil
+}
+
+// uploadChunkSize returns the size of the chunks of an upload, which must be a
+// multiple of 256 KiB.
+func uploadChunkSize(config *UploadFileConfig) (int, error) {
+	if config == nil || config.ChunkSize == 0 {
+		return maxChunkSize, nil
+	}
+	if config.ChunkSize < 0 || config.ChunkSize%uploadChunkGranularity != 0 {
+		return 0, fmt.Errorf("ChunkSize %d must be a multiple of 256 KiB", config.ChunkSize)
+	}
+	return config.ChunkSize, nil
+}
+
+// upload sends r, whose first byte is at u.offset, and finalizes the upload.
+func (u *uploader) upload(ctx context.Context, r io.Reader) (*File, error) {
+	buffer := make([]byte, u.chunkSize)
+	for {
+		bytesRead, err := io.ReadFull(r, buffer)
+		// Check both EOF and UnexpectedEOF errors.
+		// ErrUnexpectedEOF: Reading a file file_size%chunkSize<len(buffer).
+		// EOF: Reading a file file_size%chunkSize==0. The underlying reader return 0 bytes buffer and EOF at next call.
+		uploadCommand := "upload"
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			uploadCommand += ", finalize"
+		} else if err != nil {
+			return nil, fmt.Errorf("Failed to read bytes from file at offset %d: %w. Bytes actually read: %d", u.offset, err, bytesRead)
+		}
+
+		resp, respBody, err := u.sendChunk(ctx, buffer[:bytesRead], uploadCommand)
+		if err != nil {
+			return nil, err
+		}
+		u.reportProgress()
+
+		uploadStatus := resp.Header.Get("X-Goog-Upload-Status")
+		if uploadStatus != "final" && strings.Contains(uploadCommand, "finalize") {
+			return nil, fmt.Errorf("send finalize command but doesn't receive final status. Offset %d, Bytes read: %d, Upload status: %s", u.offset, bytesRead, uploadStatus)
+		}
+		if uploadStatus == "final" {
+			return uploadedFile(respBody)
+		}
+		if uploadStatus != "active" {
+			// Upload is interrupted ('cancelled', etc.)
+			return nil, fmt.Errorf("Failed to upload file: Upload status is %q", uploadStatus)
+		}
+	}
+}
+
+// sendChunk sends chunk, which starts at u.offset, resuming the upload from the
+// committed offset if a request fails.
+func (u *uploader) sendChunk(ctx context.Context, chunk []byte, uploadCommand string) (*http.Response, map[string]any, error) {
+	start := u.offset
+	retryOptions := resolveRetryOptions(u.ac, u.httpOptions)
+	if retryOptions == nil {
+		retryOptions = &RetryOptions{}
+	}
+	for attempt := 0; ; attempt++ {
+		resp, respBody, err := u.command(ctx, uploadCommand, chunk[u.offset-start:])
+		if err == nil {
+			u.offset = start + int64(len(chunk))
+			return resp, respBody, nil
+		}
+		if attempt >= u.resumeAttempts || ctx.Err() != nil || !resumableError(err) {
+			return nil, nil, err
+		}
+		if err := sleepContext(ctx, retryOptions.backoff(attempt+1)); err != nil {
+			return nil, nil, err
+		}
+
+		// Query the committed offset and send the rest of the chunk.
+		status, received, resp, respBody, qerr := u.query(ctx)
+		if qerr != nil {
+			// Send the chunk again from the last known offset.
+			continue
+		}
+		if status == "final" {
+			u.offset = received
+			return resp, respBody, nil
+		}
+		if status != "active" {
+			return nil, nil, fmt.Errorf("upload session is %q, cannot resume: %w", status, err)
+		}
+		if received < start || received > start+int64(len(chunk)) {
+			return nil, nil, fmt.Errorf("server committed %d bytes, outside of the chunk at offset %d: %w", received, start, err)
+		}
+		u.offset = received
+	}
+}
+
+// command sends an upload command with data at u.offset.
+func (u *uploader) command(ctx context.Context, uploadCommand string, data []byte) (*http.Response, map[string]any, error) {
+	offset := u.offset
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.session.URL, bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to create upload request for chunk at offset %d: %w", offset, err)
+	}
+	doMergeHeaders(u.httpOptions.Headers, &req.Header)
+	doMergeHeaders(sdkHeader(ctx, u.ac), &req.Header)
+
+	req.Header.Set("X-Goog-Upload-Command", uploadCommand)
+	if uploadCommand != "query" && uploadCommand != "cancel" {
+		req.Header.Set("X-Goog-Upload-Offset", strconv.FormatInt(offset, 10))
+	}
+	req.Header.Set("Content-Length", strconv.FormatInt(int64(len(data)), 10))
+	u.ac.injectTraceContext(ctx, req.Header)
+
+	call := &InterceptedCall{Operation: operationFromContext(ctx), Kind: CallKindUpload, Request: req}
+	intercepted, err := intercept(ctx, u.ac, call, func(ctx context.Context, call *InterceptedCall) (*InterceptedResponse, error) {
+		// The request is not retried: a failed chunk is resumed by sendChunk from
+		// the committed offset instead.
+		resp, err := doRequestWithRetry(u.ac, call.Request, nil)
+		if err != nil {
+			return nil, fmt.Errorf("upload request failed for chunk at offset %d: %w", offset, err)
+		}
+		defer resp.Body.Close()
+
+		respBody, err := deserializeUnaryResponse(resp)
+		if err != nil {
+			return nil, fmt.Errorf("response body is invalid for chunk at offset %d: %w", offset, err)
+		}
+		return &InterceptedResponse{HTTPResponse: resp, Body: respBody}, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if intercepted.HTTPResponse == nil {
+		return nil, nil, fmt.Errorf("upload request for chunk at offset %d returned no HTTP response", offset)
+	}
+	return intercepted.HTTPResponse, intercepted.Body, nil
+}
+
+// query returns the status of the upload session and the number of bytes that
+// the server committed.
+func (u *uploader) query(ctx context.Context) (status string, received int64, resp *http.Response, respBody map[string]any, err error) {
+	resp, respBody, err = u.command(ctx, "query", nil)
+	if err != nil {
+		return "", 0, nil, nil, err
+	}
+	status = resp.Header.Get("X-Goog-Upload-Status")
+	if v := resp.Header.Get("X-Goog-Upload-Size-Received"); v != "" {
+		received, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return "", 0, nil, nil, fmt.Errorf("invalid X-Goog-Upload-Size-Received header %q: %w", v, err)
+		}
+	} else if status == "active" {
+		return "", 0, nil, nil, fmt.Errorf("upload query response has no X-Goog-Upload-Size-Received header")
+	}
+	return status, received, resp, respBody, nil
+}
+
+// cancel cancels the upload session. It runs after ctx is cancelled, so it uses
+// a context of its own.
+func (u *uploader) cancel(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), uploadCancelTimeout)
+	defer cancel()
+	_, _, err := u.command(ctx, "cancel", nil)
+	return err
+}
+
+func (u *uploader) reportProgress() {
+	if u.progress != nil {
+		u.progress(UploadProgress{UploadedBytes: u.offset, TotalBytes: u.session.SizeBytes})
+	}
+}
+
+// resumableError reports whether a failed upload or download request may be
+// resumed: transport errors, server errors and throttling.
+func resumableError(err error) bool {
+	var apiErr APIError
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+	return apiErr.Code >= 500 || apiErr.Code == http.StatusRequestTimeout || apiErr.Code == http.StatusTooManyRequests
+}
+
+func uploadedFile(respBody map[string]any) (*File, error) {
+	fileMap, ok := respBody["file"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("Failed to upload file: the final response has no file")
+	}
+	var response = new(File)
+	if err := mapToStruct(fileMap, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// upload implements Files.Upload.
+func (m Files) upload(ctx context.Context, r io.Reader, config *UploadFileConfig) (file *File, err error) {
+	ctx = withOperation(ctx, "Files.Upload")
+	ctx, span := m.apiClient.startSpan(ctx, "", nil, &m.apiClient.clientConfig.HTTPOptions)
+	defer func() { span.end(err) }()
+
+	var sum string
+	if config != nil && config.DedupIndex != nil {
+		file, sum, err = m.dedupUpload(ctx, r, config)
+		if err != nil {
+			return nil, err
+		}
+		if file != nil {
+			return m.waitUntilUploadActive(ctx, file, config)
+		}
+	}
+
+	file, err = m.uploadContents(ctx, r, config)
+	if err != nil {
+		return nil, err
+	}
+	if sum != "" {
+		if err := config.DedupIndex.Store(ctx, &FileIndexEntry{SHA256: sum, Name: file.Name, ExpirationTime: file.ExpirationTime}); err != nil {
+			return nil, fmt.Errorf("Upload: %w", err)
+		}
+	}
+	return m.waitUntilUploadActive(ctx, file, config)
+}
+
+// uploadContents sends the contents of r to a resumable upload session, or to Cloud
+// Storage on the Vertex AI backend.
+func (m Files) uploadContents(ctx context.Context, r io.Reader, config *UploadFileConfig) (*File, error) {
+	if m.apiClient.clientConfig.Backend == BackendVertexAI {
+		return m.storageUpload(ctx, r, config)
+	}
+
+	// Check the config before the file is created.
+	if _, err := uploadChunkSize(config); err != nil {
+		return nil, fmt.Errorf("Upload: %w", err)
+	}
+	session, httpOptions, err := m.createUploadSession(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	u, err := newUploader(m.apiClient, session, httpOptions, config)
+	if err != nil {
+		return nil, fmt.Errorf("Upload: %w", err)
+	}
+	file, err := u.upload(ctx, r)
+	if err != nil {
+		if ctx.Err() != nil {
+			// Cancel the session, so that the server discards the uploaded chunks.
+			_ = u.cancel(ctx)
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// CreateUploadSession starts a resumable upload of a file and returns its
+// session, which can be persisted to resume the upload after a process restart.
+// Send the contents of the file with [Files.ResumeUpload].
+func (m Files) CreateUploadSession(ctx context.Context, config *UploadFileConfig) (session *UploadSession, err error) {
+	ctx = withOperation(ctx, "Files.CreateUploadSession")
+	ctx, span := m.apiClient.startSpan(ctx, "", nil, &m.apiClient.clientConfig.HTTPOptions)
+	defer func() { span.end(err) }()
+
+	session, _, err = m.createUploadSession(ctx, config)
+	return session, err
+}
+
+// ResumeUpload sends the contents of r to the upload session, starting from the
+// offset that the server committed, and returns information about the resulting
+// file. r must read the whole file: it is positioned at the committed offset with
+// Seek. If the session is already finalized, the file is returned without
+// reading r.
+//
+// Unlike [Files.Upload], ResumeUpload doesn't cancel the session when ctx is
+// cancelled, so that the upload can be resumed later; use [Files.CancelUpload]
+// to discard it.
+func (m Files) ResumeUpload(ctx context.Context, session *UploadSession, r io.ReadSeeker, config *UploadFileConfig) (file *File, err error) {
+	ctx = withOperation(ctx, "Files.ResumeUpload")
+	ctx, span := m.apiClient.startSpan(ctx, "", nil, &m.apiClient.clientConfig.HTTPOptions)
+	defer func() { span.end(err) }()
+	if m.apiClient.clientConfig.Backend == BackendVertexAI {
+		return nil, fmt.Errorf("This method is only supported in the Gemini Developer client.")
+	}
+	if session == nil || session.URL == "" {
+		return nil, fmt.Errorf("ResumeUpload: session must have an upload URL")
+	}
+
+	u, err := newUploader(m.apiClient, session, uploadHTTPOptions(config), config)
+	if err != nil {
+		return nil, fmt.Errorf("ResumeUpload: %w", err)
+	}
+	status, received, _, respBody, err := u.query(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ResumeUpload: failed to query the upload session: %w", err)
+	}
+	switch status {
+	case "final":
+		file, err := uploadedFile(respBody)
+		if err != nil {
+			return nil, err
+		}
+		return m.waitUntilUploadActive(ctx, file, config)
+	case "active":
+	default:
+		return nil, fmt.Errorf("ResumeUpload: upload session is %q", status)
+	}
+	if _, err := r.Seek(received, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("ResumeUpload: failed to seek to offset %d: %w", received, err)
+	}
+	u.offset = received
+	file, err = u.upload(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return m.waitUntilUploadActive(ctx, file, config)
+}
+
+// waitUntilUploadActive waits until an uploaded file is active if the config
+// asks for it.
+func (m Files) waitUntilUploadActive(ctx context.Context, file *File, config *UploadFileConfig) (*File, error) {
+	if config == nil || config.WaitUntilActive == nil || file.State == FileStateActive {
+		return file, nil
+	}
+	return m.WaitUntilActive(ctx, file.Name, config.WaitUntilActive)
+}
+
+// CancelUpload cancels an upload session, discarding the uploaded chunks.
+func (m Files) CancelUpload(ctx context.Context, session *UploadSession) (err error) {
+	ctx = withOperation(ctx, "Files.CancelUpload")
+	ctx, span := m.apiClient.startSpan(ctx, "", nil, &m.apiClient.clientConfig.HTTPOptions)
+	defer func() { span.end(err) }()
+	if session == nil || session.URL == "" {
+		return fmt.Errorf("CancelUpload: session must have an upload URL")
+	}
+	u, err := newUploader(m.apiClient, session, uploadHTTPOptions(nil), nil)
+	if err != nil {
+		return err
+	}
+	_, _, err = u.command(ctx, "cancel", nil)
+	return err
+}
+
+// createUploadSession creates the file and returns its upload session, with the
+// HTTP options of the requests that upload its contents.
+func (m Files) createUploadSession(ctx context.Context, config *UploadFileConfig) (*UploadSession, *HTTPOptions, error) {
+	if m.apiClient.clientConfig.Backend == BackendVertexAI {
+		return nil, nil, fmt.Errorf("This method is only supported in the Gemini Developer client.")
+	}
+
+	var fileToUpload File
+	if config != nil {
+		fileToUpload.MIMEType = config.MIMEType
+		fileToUpload.Name = config.Name
+		fileToUpload.DisplayName = config.DisplayName
+	}
+
+	if fileToUpload.Name != "" && !strings.HasPrefix(fileToUpload.Name, "files/") {
+		fileToUpload.Name = "files/" + fileToUpload.Name
+	}
+
+	httpOptions := uploadHTTPOptions(config)
+	httpOptions.Headers.Add("Content-Type", "application/json")
+	httpOptions.Headers.Add("X-Goog-Upload-Command", "start")
+	httpOptions.Headers.Add("X-Goog-Upload-Header-Content-Type", fileToUpload.MIMEType)
+
+	var createFileConfig CreateFileConfig
+	createFileConfig.HTTPOptions = httpOptions
+
+	resp, err := m.create(ctx, &fileToUpload, &createFileConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to create file. Ran into an error: %w", err)
+	}
+	if resp.HTTPHeaders == nil || resp.HTTPHeaders.Get("x-goog-upload-url") == "" {
+		return nil, nil, fmt.Errorf("Failed to create file. Upload URL was not returned from the create file request.")
+	}
+
+	session := &UploadSession{URL: resp.HTTPHeaders.Get("x-goog-upload-url")}
+	if size := httpOptions.Headers.Get("X-Goog-Upload-Header-Content-Length"); size != "" {
+		session.SizeBytes, _ = strconv.ParseInt(size, 10, 64)
+	}
+	return session, httpOptions, nil
+}
+
+// uploadHTTPOptions returns the HTTP options of the requests of an upload,
+// without modifying the options of config.
+func uploadHTTPOptions(config *UploadFileConfig) *HTTPOptions {
+	var httpOptions HTTPOptions
+	if config != nil && config.HTTPOptions != nil {
+		httpOptions = *config.HTTPOptions
+	}
+	httpOptions.Headers = httpOptions.Headers.Clone()
+	if httpOptions.Headers == nil {
+		httpOptions.Headers = http.Header{}
+	}
+	httpOptions.APIVersion = ""
+	httpOptions.Headers.Add("X-Goog-Upload-Protocol", "resumable")
+	return &httpOptions
+}
@@ -0,0 +1,229 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// resumableUploadServer implements the resumable upload protocol for a single
+// upload session, and keeps the uploaded bytes.
+type resumableUploadServer struct {
+	t *testing.T
+
+	mu     sync.Mutex
+	data   []byte
+	status string
+	// failures is the number of chunk requests that fail after committing half of
+	// their bytes.
+	failures int
+	// onChunk is called before a chunk request is handled.
+	onChunk  func()
+	commands []string
+}
+
+func newResumableUploadServer(t *testing.T) (*resumableUploadServer, *httptest.Server) {
+	s := &resumableUploadServer{t: t, status: "active"}
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	return s, ts
+}
+
+func (s *resumableUploadServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	command := r.Header.Get("X-Goog-Upload-Command")
+	s.commands = append(s.commands, command)
+	if command == "start" {
+		w.Header().Set("X-Goog-Upload-URL", "http://"+r.Host+"/session")
+		return
+	}
+
+	switch command {
+	case "query":
+		w.Header().Set("X-Goog-Upload-Status", s.status)
+		w.Header().Set("X-Goog-Upload-Size-Received", strconv.Itoa(len(s.data)))
+		if s.status == "final" {
+			s.writeFile(w)
+		}
+		return
+	case "cancel":
+		s.status = "cancelled"
+		return
+	}
+
+	if s.onChunk != nil {
+		s.onChunk()
+	}
+	if s.status != "active" {
+		http.Error(w, "upload is "+s.status, http.StatusBadRequest)
+		return
+	}
+	if offset := r.Header.Get("X-Goog-Upload-Offset"); offset != strconv.Itoa(len(s.data)) {
+		http.Error(w, fmt.Sprintf("offset %s, want %d", offset, len(s.data)), http.StatusBadRequest)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.t.Errorf("failed to read chunk: %v", err)
+	}
+	if s.failures > 0 {
+		s.failures--
+		s.data = append(s.data, body[:len(body)/2]...)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	s.data = append(s.data, body...)
+	if !strings.Contains(command, "finalize") {
+		w.Header().Set("X-Goog-Upload-Status", "active")
+		return
+	}
+	s.status = "final"
+	w.Header().Set("X-Goog-Upload-Status", "final")
+	s.writeFile(w)
+}
+
+func (s *resumableUploadServer) writeFile(w http.ResponseWriter) {
+	fmt.Fprintf(w, `{"file": {"name": "files/uploaded", "sizeBytes": "%d"}}`, len(s.data))
+}
+
+func TestFilesUploadResumesFailedChunks(t *testing.T) {
+	server, ts := newResumableUploadServer(t)
+	server.failures = 2
+	client := newTestClient(t, ts, nil)
+
+	const chunk = uploadChunkGranularity
+	data := strings.Repeat("hello, world", chunk/4)
+	var progress []UploadProgress
+	config := &UploadFileConfig{
+		MIMEType:    "text/plain",
+		ChunkSize:   chunk,
+		Progress:    func(p UploadProgress) { progress = append(progress, p) },
+		HTTPOptions: &HTTPOptions{Headers: http.Header{"X-Goog-Upload-Header-Content-Length": {strconv.Itoa(len(data))}}},
+	}
+	file, err := client.Files.Upload(context.Background(), strings.NewReader(data), config)
+	if err != nil {
+		t.Fatalf("Upload() failed: %v", err)
+	}
+	if file.SizeBytes == nil || *file.SizeBytes != 3*chunk {
+		t.Errorf("Upload() SizeBytes = %v, want %d", file.SizeBytes, 3*chunk)
+	}
+	if string(server.data) != data {
+		t.Errorf("server received %d bytes, want the %d bytes of the file", len(server.data), len(data))
+	}
+	if got := slices.Index(server.commands, "query"); got < 0 {
+		t.Errorf("server commands = %v, want a query", server.commands)
+	}
+	want := []UploadProgress{{chunk, 3 * chunk}, {2 * chunk, 3 * chunk}, {3 * chunk, 3 * chunk}, {3 * chunk, 3 * chunk}}
+	if diff := cmp.Diff(want, progress); diff != "" {
+		t.Errorf("progress mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFilesUploadChunks(t *testing.T) {
+	server, ts := newResumableUploadServer(t)
+	server.failures = 1
+	client := newTestClient(t, ts, &ClientConfig{
+		HTTPOptions: HTTPOptions{RetryOptions: &RetryOptions{Attempts: 3, InitialDelay: time.Millisecond}},
+	})
+
+	// The chunks must be a multiple of 256 KiB.
+	if _, err := client.Files.Upload(context.Background(), strings.NewReader("hello"), &UploadFileConfig{MIMEType: "text/plain", ChunkSize: 1000}); err == nil {
+		t.Errorf("Upload() with ChunkSize 1000 succeeded, want error")
+	}
+	if len(server.commands) != 0 {
+		t.Errorf("server commands = %v, want none for an invalid config", server.commands)
+	}
+
+	// The failed chunk is not retried by the transport, only resumed.
+	if _, err := client.Files.Upload(context.Background(), strings.NewReader("hello"), &UploadFileConfig{MIMEType: "text/plain", ResumeAttempts: -1}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Upload() error = %v, want ErrUnavailable", err)
+	}
+	if want := []string{"start", "upload, finalize"}; !slices.Equal(server.commands, want) {
+		t.Errorf("server commands = %q, want %q", server.commands, want)
+	}
+}
+
+func TestFilesResumeUpload(t *testing.T) {
+	ctx := context.Background()
+	server, ts := newResumableUploadServer(t)
+	client := newTestClient(t, ts, nil)
+
+	session, err := client.Files.CreateUploadSession(ctx, &UploadFileConfig{MIMEType: "text/plain"})
+	if err != nil {
+		t.Fatalf("CreateUploadSession() failed: %v", err)
+	}
+	// The session survives a process restart.
+	data, err := json.Marshal(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored UploadSession
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+	// A previous process uploaded the first bytes.
+	server.data = []byte("hello, ")
+
+	file, err := client.Files.ResumeUpload(ctx, &restored, strings.NewReader("hello, world"), nil)
+	if err != nil {
+		t.Fatalf("ResumeUpload() failed: %v", err)
+	}
+	if got := string(server.data); got != "hello, world" {
+		t.Errorf("server received %q, want %q", got, "hello, world")
+	}
+	if file.Name != "files/uploaded" {
+		t.Errorf("ResumeUpload() Name = %q, want %q", file.Name, "files/uploaded")
+	}
+
+	// Resuming a finalized upload returns the file.
+	file, err = client.Files.ResumeUpload(ctx, &restored, strings.NewReader("ignored"), nil)
+	if err != nil {
+		t.Fatalf("ResumeUpload() of a finalized upload failed: %v", err)
+	}
+	if file.SizeBytes == nil || *file.SizeBytes != 12 {
+		t.Errorf("ResumeUpload() SizeBytes = %v, want 12", file.SizeBytes)
+	}
+}
+
+func TestFilesUploadCancel(t *testing.T) {
+	server, ts := newResumableUploadServer(t)
+	client := newTestClient(t, ts, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server.onChunk = cancel
+	_, err := client.Files.Upload(ctx, strings.NewReader(strings.Repeat("hello, world", uploadChunkGranularity/4)), &UploadFileConfig{MIMEType: "text/plain", ChunkSize: uploadChunkGranularity})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Upload() error = %v, want context.Canceled", err)
+	}
+	if server.status != "cancelled" {
+		t.Errorf("upload session status = %q, want %q", server.status, "cancelled")
+	}
+}
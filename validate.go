@@ -0,0 +1,361 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Reasons of the field violations reported by client-side validation.
+const (
+	// ViolationRequired means that a required field is missing or empty.
+	ViolationRequired = "REQUIRED"
+	// ViolationInvalidValue means that the value of a field is out of range or
+	// malformed.
+	ViolationInvalidValue = "INVALID_VALUE"
+	// ViolationConflict means that a field conflicts with another field.
+	ViolationConflict = "CONFLICT"
+	// ViolationRoleOrder means that the turns of a conversation don't alternate
+	// between the user and the model.
+	ViolationRoleOrder = "ROLE_ORDER"
+	// ViolationUnmatchedFunctionResponse means that a function response doesn't
+	// answer a function call of the previous model turn.
+	ViolationUnmatchedFunctionResponse = "UNMATCHED_FUNCTION_RESPONSE"
+	// ViolationUnsupportedByBackend means that a field is not supported by the
+	// backend of the client.
+	ViolationUnsupportedByBackend = "UNSUPPORTED_BY_BACKEND"
+)
+
+// ValidationError is returned by the client-side validation of a request. It
+// lists every invalid field that was found, and matches [ErrInvalidArgument]
+// with errors.Is, like the errors of the server for invalid requests.
+type ValidationError struct {
+	// FieldViolations describes the invalid fields of the request.
+	FieldViolations []FieldViolation
+}
+
+func (e *ValidationError) Error() string {
+	var violations []string
+	for _, v := range e.FieldViolations {
+		violations = append(violations, fmt.Sprintf("%s: %s", v.Field, v.Description))
+	}
+	return fmt.Sprintf("invalid request: %s", strings.Join(violations, "; "))
+}
+
+// Is reports whether target is ErrInvalidArgument.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidArgument
+}
+
+// ValidateGenerateContent checks a GenerateContent request for backend without
+// sending it, and returns a *ValidationError if it is invalid. It checks the
+// contents, the configuration, and its tools and schemas, against the rules that
+// the backend would otherwise enforce after a round trip. Backend-specific rules
+// are skipped for BackendUnspecified.
+//
+// Clients validate every request automatically when ClientConfig.ValidateRequests
+// is set.
+func ValidateGenerateContent(backend Backend, contents []*Content, config *GenerateContentConfig) error {
+	return validateGenerateContent(backend, contents, config, false)
+}
+
+// ValidateGenerateContentStream is like [ValidateGenerateContent], for a
+// GenerateContentStream request.
+func ValidateGenerateContentStream(backend Backend, contents []*Content, config *GenerateContentConfig) error {
+	return validateGenerateContent(backend, contents, config, true)
+}
+
+func validateGenerateContent(backend Backend, contents []*Content, config *GenerateContentConfig, stream bool) error {
+	v := &requestValidator{backend: backend}
+	v.contents(contents, "contents")
+	v.generateContentConfig(config, stream, "config")
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return &ValidationError{FieldViolations: v.violations}
+}
+
+// functionNamePattern matches the names that function declarations allow.
+var functionNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.-]{0,63}$`)
+
+type requestValidator struct {
+	backend    Backend
+	violations []FieldViolation
+}
+
+func (v *requestValidator) add(field, reason, format string, args ...any) {
+	v.violations = append(v.violations, FieldViolation{Field: field, Description: fmt.Sprintf(format, args...), Reason: reason})
+}
+
+// vertexOnly reports set fields as unsupported when the backend is the Gemini API.
+func (v *requestValidator) vertexOnly(field string, set bool) {
+	if set && v.backend == BackendGeminiAPI {
+		v.add(field, ViolationUnsupportedByBackend, "is only supported by Vertex AI")
+	}
+}
+
+func (v *requestValidator) contents(contents []*Content, path string) {
+	if len(contents) == 0 {
+		v.add(path, ViolationRequired, "must not be empty")
+		return
+	}
+	var previous *Content
+	previousRole := ""
+	for i, content := range contents {
+		contentPath := fmt.Sprintf("%s[%d]", path, i)
+		if content == nil {
+			v.add(contentPath, ViolationRequired, "must not be nil")
+			previous, previousRole = nil, ""
+			continue
+		}
+		role := content.Role
+		if role == "" {
+			role = RoleUser
+		}
+		switch {
+		case role != RoleUser && role != RoleModel:
+			v.add(contentPath+".role", ViolationInvalidValue, "must be %q or %q, got %q", RoleUser, RoleModel, content.Role)
+		case role == previousRole:
+			v.add(contentPath+".role", ViolationRoleOrder, "turns must alternate between %q and %q, got two %q turns in a row", RoleUser, RoleModel, role)
+		}
+		v.parts(content.Parts, contentPath+".parts")
+		v.functionResponses(content, previous, contentPath+".parts")
+		previous, previousRole = content, role
+	}
+}
+
+func (v *requestValidator) parts(parts []*Part, path string) {
+	if len(parts) == 0 {
+		v.add(path, ViolationRequired, "must not be empty")
+		return
+	}
+	for i, part := range parts {
+		partPath := fmt.Sprintf("%s[%d]", path, i)
+		if part == nil {
+			v.add(partPath, ViolationRequired, "must not be nil")
+			continue
+		}
+		var data []string
+		for name, set := range map[string]bool{
+			"text":                part.Text != "",
+			"inlineData":          part.InlineData != nil,
+			"fileData":            part.FileData != nil,
+			"functionCall":        part.FunctionCall != nil,
+			"functionResponse":    part.FunctionResponse != nil,
+			"executableCode":      part.ExecutableCode != nil,
+			"codeExecutionResult": part.CodeExecutionResult != nil,
+		} {
+			if set {
+				data = append(data, name)
+			}
+		}
+		switch {
+		case len(data) == 0 && !part.Thought:
+			v.add(partPath, ViolationRequired, "must have text, inline data, file data or a function call or response")
+		case len(data) > 1:
+			slices.Sort(data)
+			v.add(partPath, ViolationConflict, "must have a single kind of data, got %s", strings.Join(data, ", "))
+		}
+		if part.InlineData != nil && part.InlineData.MIMEType == "" {
+			v.add(partPath+".inlineData.mimeType", ViolationRequired, "must not be empty")
+		}
+		if part.FileData != nil && part.FileData.FileURI == "" {
+			v.add(partPath+".fileData.fileUri", ViolationRequired, "must not be empty")
+		}
+		if part.FunctionCall != nil && part.FunctionCall.Name == "" {
+			v.add(partPath+".functionCall.name", ViolationRequired, "must not be empty")
+		}
+		if part.FunctionResponse != nil && part.FunctionResponse.Name == "" {
+			v.add(partPath+".functionResponse.name", ViolationRequired, "must not be empty")
+		}
+		v.vertexOnly(partPath+".videoMetadata", part.VideoMetadata != nil)
+	}
+}
+
+// functionResponses checks that the function responses of content answer the
+// function calls of previous, the turn before it.
+func (v *requestValidator) functionResponses(content, previous *Content, path string) {
+	var calls []*FunctionCall
+	if previous != nil && previous.Role == RoleModel {
+		for _, part := range previous.Parts {
+			if part != nil && part.FunctionCall != nil {
+				calls = append(calls, part.FunctionCall)
+			}
+		}
+	}
+	for i, part := range content.Parts {
+		if part == nil || part.FunctionResponse == nil || part.FunctionResponse.Name == "" {
+			continue
+		}
+		response := part.FunctionResponse
+		matched := slices.ContainsFunc(calls, func(call *FunctionCall) bool {
+			return call.Name == response.Name && (call.ID == "" || response.ID == "" || call.ID == response.ID)
+		})
+		if !matched {
+			v.add(fmt.Sprintf("%s[%d].functionResponse", path, i), ViolationUnmatchedFunctionResponse,
+				"doesn't answer a call of function %q in the previous model turn", response.Name)
+		}
+	}
+}
+
+func (v *requestValidator) generateContentConfig(config *GenerateContentConfig, stream bool, path string) {
+	if config == nil {
+		return
+	}
+	if config.SystemInstruction != nil {
+		v.parts(config.SystemInstruction.Parts, path+".systemInstruction.parts")
+	}
+	if config.Temperature != nil && (*config.Temperature < 0 || *config.Temperature > 2) {
+		v.add(path+".temperature", ViolationInvalidValue, "must be between 0 and 2, got %v", *config.Temperature)
+	}
+	if config.TopP != nil && (*config.TopP < 0 || *config.TopP > 1) {
+		v.add(path+".topP", ViolationInvalidValue, "must be between 0 and 1, got %v", *config.TopP)
+	}
+	if config.TopK != nil && *config.TopK < 0 {
+		v.add(path+".topK", ViolationInvalidValue, "must not be negative, got %v", *config.TopK)
+	}
+	if config.CandidateCount < 0 {
+		v.add(path+".candidateCount", ViolationInvalidValue, "must not be negative, got %d", config.CandidateCount)
+	} else if stream && config.CandidateCount > 1 {
+		v.add(path+".candidateCount", ViolationInvalidValue, "must be at most 1 for streaming requests, got %d", config.CandidateCount)
+	}
+	if config.MaxOutputTokens < 0 {
+		v.add(path+".maxOutputTokens", ViolationInvalidValue, "must not be negative, got %d", config.MaxOutputTokens)
+	}
+	if config.Logprobs != nil && !config.ResponseLogprobs {
+		v.add(path+".logprobs", ViolationConflict, "requires responseLogprobs")
+	}
+
+	switch config.ResponseMIMEType {
+	case "application/json":
+	case "text/x.enum":
+		if config.ResponseSchema == nil || len(config.ResponseSchema.Enum) == 0 {
+			v.add(path+".responseSchema", ViolationRequired, "must be an enum schema for responseMimeType %q", config.ResponseMIMEType)
+		}
+	default:
+		if config.ResponseSchema != nil {
+			v.add(path+".responseSchema", ViolationConflict, "requires responseMimeType \"application/json\" or \"text/x.enum\", got %q", config.ResponseMIMEType)
+		}
+	}
+	if config.ResponseSchema != nil {
+		v.schema(config.ResponseSchema, path+".responseSchema")
+	}
+
+	for i, setting := range config.SafetySettings {
+		if setting != nil {
+			v.vertexOnly(fmt.Sprintf("%s.safetySettings[%d].method", path, i), setting.Method != "")
+		}
+	}
+	v.tools(config.Tools, path+".tools")
+	v.vertexOnly(path+".routingConfig", config.RoutingConfig != nil)
+	v.vertexOnly(path+".modelSelectionConfig", config.ModelSelectionConfig != nil)
+	v.vertexOnly(path+".labels", len(config.Labels) > 0)
+	v.vertexOnly(path+".audioTimestamp", config.AudioTimestamp)
+}
+
+func (v *requestValidator) tools(tools []*Tool, path string) {
+	declared := make(map[string]bool)
+	for i, tool := range tools {
+		toolPath := fmt.Sprintf("%s[%d]", path, i)
+		if tool == nil {
+			v.add(toolPath, ViolationRequired, "must not be nil")
+			continue
+		}
+		v.vertexOnly(toolPath+".retrieval", tool.Retrieval != nil)
+		for j, declaration := range tool.FunctionDeclarations {
+			declarationPath := fmt.Sprintf("%s.functionDeclarations[%d]", toolPath, j)
+			if declaration == nil {
+				v.add(declarationPath, ViolationRequired, "must not be nil")
+				continue
+			}
+			switch {
+			case !functionNamePattern.MatchString(declaration.Name):
+				v.add(declarationPath+".name", ViolationInvalidValue,
+					"must start with a letter or an underscore, contain only letters, digits, underscores, dots and dashes, and have at most 64 characters, got %q", declaration.Name)
+			case declared[declaration.Name]:
+				v.add(declarationPath+".name", ViolationConflict, "function %q is declared more than once", declaration.Name)
+			}
+			declared[declaration.Name] = true
+			if declaration.Parameters != nil {
+				v.schema(declaration.Parameters, declarationPath+".parameters")
+			}
+			if declaration.Response != nil {
+				v.vertexOnly(declarationPath+".response", true)
+				v.schema(declaration.Response, declarationPath+".response")
+			}
+		}
+	}
+}
+
+func (v *requestValidator) schema(schema *Schema, path string) {
+	switch schema.Type {
+	case "", TypeUnspecified, TypeString, TypeNumber, TypeInteger, TypeBoolean, TypeArray, TypeObject:
+	default:
+		v.add(path+".type", ViolationInvalidValue, "unknown type %q", schema.Type)
+	}
+	if len(schema.Enum) > 0 && schema.Type != "" && schema.Type != TypeString {
+		v.add(path+".enum", ViolationConflict, "requires type %q, got %q", TypeString, schema.Type)
+	}
+	if schema.Type == TypeArray && schema.Items == nil {
+		v.add(path+".items", ViolationRequired, "must be set for type %q", TypeArray)
+	}
+	if len(schema.Properties) > 0 && schema.Type != "" && schema.Type != TypeObject {
+		v.add(path+".properties", ViolationConflict, "requires type %q, got %q", TypeObject, schema.Type)
+	}
+	for _, field := range []struct {
+		name  string
+		names []string
+	}{{"required", schema.Required}, {"propertyOrdering", schema.PropertyOrdering}} {
+		for i, name := range field.names {
+			if _, ok := schema.Properties[name]; !ok {
+				v.add(fmt.Sprintf("%s.%s[%d]", path, field.name, i), ViolationInvalidValue, "property %q is not declared", name)
+			}
+		}
+	}
+	if schema.MinItems != nil && schema.MaxItems != nil && *schema.MinItems > *schema.MaxItems {
+		v.add(path+".minItems", ViolationConflict, "must not be greater than maxItems")
+	}
+	if schema.MinLength != nil && schema.MaxLength != nil && *schema.MinLength > *schema.MaxLength {
+		v.add(path+".minLength", ViolationConflict, "must not be greater than maxLength")
+	}
+	if schema.Minimum != nil && schema.Maximum != nil && *schema.Minimum > *schema.Maximum {
+		v.add(path+".minimum", ViolationConflict, "must not be greater than maximum")
+	}
+	v.vertexOnly(path+".example", schema.Example != nil)
+	v.vertexOnly(path+".pattern", schema.Pattern != "")
+	v.vertexOnly(path+".default", schema.Default != nil)
+	v.vertexOnly(path+".maxLength", schema.MaxLength != nil)
+	v.vertexOnly(path+".minLength", schema.MinLength != nil)
+	v.vertexOnly(path+".minProperties", schema.MinProperties != nil)
+	v.vertexOnly(path+".maxProperties", schema.MaxProperties != nil)
+
+	if schema.Items != nil {
+		v.schema(schema.Items, path+".items")
+	}
+	for _, name := range slices.Sorted(maps.Keys(schema.Properties)) {
+		if property := schema.Properties[name]; property != nil {
+			v.schema(property, path+".properties."+name)
+		}
+	}
+	for i, subschema := range schema.AnyOf {
+		if subschema != nil {
+			v.schema(subschema, fmt.Sprintf("%s.anyOf[%d]", path, i))
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidateGenerateContent(t *testing.T) {
+	weatherCall := &Content{Role: RoleModel, Parts: []*Part{{FunctionCall: &FunctionCall{Name: "get_weather"}}}}
+	tests := []struct {
+		desc     string
+		backend  Backend
+		contents []*Content
+		config   *GenerateContentConfig
+		stream   bool
+		want     []FieldViolation
+	}{
+		{
+			desc:     "valid",
+			backend:  BackendGeminiAPI,
+			contents: []*Content{{Parts: []*Part{{Text: "weather?"}}}, weatherCall, {Role: RoleUser, Parts: []*Part{{FunctionResponse: &FunctionResponse{Name: "get_weather"}}}}},
+			config:   &GenerateContentConfig{ResponseMIMEType: "application/json", ResponseSchema: &Schema{Type: TypeString}, CandidateCount: 2},
+		},
+		{
+			desc:    "empty contents",
+			backend: BackendGeminiAPI,
+			want:    []FieldViolation{{Field: "contents", Description: "must not be empty", Reason: ViolationRequired}},
+		},
+		{
+			desc:     "contents",
+			backend:  BackendGeminiAPI,
+			contents: []*Content{{Role: RoleUser}, {Role: RoleUser, Parts: []*Part{{Text: "a", InlineData: &Blob{Data: []byte("b")}}}}, {Role: "system", Parts: []*Part{{}}}},
+			want: []FieldViolation{
+				{Field: "contents[0].parts", Description: "must not be empty", Reason: ViolationRequired},
+				{Field: "contents[1].role", Description: `turns must alternate between "user" and "model", got two "user" turns in a row`, Reason: ViolationRoleOrder},
+				{Field: "contents[1].parts[0]", Description: "must have a single kind of data, got inlineData, text", Reason: ViolationConflict},
+				{Field: "contents[1].parts[0].inlineData.mimeType", Description: "must not be empty", Reason: ViolationRequired},
+				{Field: "contents[2].role", Description: `must be "user" or "model", got "system"`, Reason: ViolationInvalidValue},
+				{Field: "contents[2].parts[0]", Description: "must have text, inline data, file data or a function call or response", Reason: ViolationRequired},
+			},
+		},
+		{
+			desc:     "unmatched function response",
+			backend:  BackendGeminiAPI,
+			contents: []*Content{{Parts: []*Part{{Text: "weather?"}}}, weatherCall, {Role: RoleUser, Parts: []*Part{{FunctionResponse: &FunctionResponse{Name: "get_time"}}}}},
+			want: []FieldViolation{
+				{Field: "contents[2].parts[0].functionResponse", Description: `doesn't answer a call of function "get_time" in the previous model turn`, Reason: ViolationUnmatchedFunctionResponse},
+			},
+		},
+		{
+			desc:     "config",
+			backend:  BackendGeminiAPI,
+			contents: Text("hello"),
+			config: &GenerateContentConfig{
+				Temperature:    Ptr[float32](3),
+				CandidateCount: 2,
+				ResponseSchema: &Schema{Type: TypeArray, Pattern: "^a"},
+				Labels:         map[string]string{"team": "a"},
+				Tools: []*Tool{{FunctionDeclarations: []*FunctionDeclaration{
+					{Name: "1st", Parameters: &Schema{Type: TypeObject, Required: []string{"city"}}},
+					{Name: "f", Response: &Schema{Type: TypeString}},
+					{Name: "f"},
+				}}},
+			},
+			stream: true,
+			want: []FieldViolation{
+				{Field: "config.temperature", Description: "must be between 0 and 2, got 3", Reason: ViolationInvalidValue},
+				{Field: "config.candidateCount", Description: "must be at most 1 for streaming requests, got 2", Reason: ViolationInvalidValue},
+				{Field: "config.responseSchema", Description: `requires responseMimeType "application/json" or "text/x.enum", got ""`, Reason: ViolationConflict},
+				{Field: "config.responseSchema.items", Description: `must be set for type "ARRAY"`, Reason: ViolationRequired},
+				{Field: "config.responseSchema.pattern", Description: "is only supported by Vertex AI", Reason: ViolationUnsupportedByBackend},
+				{Field: "config.tools[0].functionDeclarations[0].name", Description: `must start with a letter or an underscore, contain only letters, digits, underscores, dots and dashes, and have at most 64 characters, got "1st"`, Reason: ViolationInvalidValue},
+				{Field: "config.tools[0].functionDeclarations[0].parameters.required[0]", Description: `property "city" is not declared`, Reason: ViolationInvalidValue},
+				{Field: "config.tools[0].functionDeclarations[1].response", Description: "is only supported by Vertex AI", Reason: ViolationUnsupportedByBackend},
+				{Field: "config.tools[0].functionDeclarations[2].name", Description: `function "f" is declared more than once`, Reason: ViolationConflict},
+				{Field: "config.labels", Description: "is only supported by Vertex AI", Reason: ViolationUnsupportedByBackend},
+			},
+		},
+		{
+			desc:     "vertex only fields on vertex",
+			backend:  BackendVertexAI,
+			contents: Text("hello"),
+			config:   &GenerateContentConfig{Labels: map[string]string{"team": "a"}, ResponseMIMEType: "application/json", ResponseSchema: &Schema{Type: TypeString, Pattern: "^a"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			validate := ValidateGenerateContent
+			if tt.stream {
+				validate = ValidateGenerateContentStream
+			}
+			err := validate(tt.backend, tt.contents, tt.config)
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("Validate() failed: %v", err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Validate() error = %v, want *ValidationError", err)
+			}
+			if !errors.Is(err, ErrInvalidArgument) {
+				t.Errorf("errors.Is(%v, ErrInvalidArgument) = false, want true", err)
+			}
+			if diff := cmp.Diff(tt.want, validationErr.FieldViolations); diff != "" {
+				t.Errorf("field violations mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateRequests(t *testing.T) {
+	ctx := context.Background()
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer ts.Close()
+	client := newTestClient(t, ts, nil)
+	client.Models.apiClient.clientConfig.ValidateRequests = true
+
+	config := &GenerateContentConfig{ResponseSchema: &Schema{Type: TypeString}}
+	if _, err := client.Models.GenerateContent(ctx, "gemini-2.0-flash", Text("hello"), config); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("GenerateContent() error = %v, want a validation error", err)
+	}
+	for _, err := range client.Models.GenerateContentStream(ctx, "gemini-2.0-flash", nil, nil) {
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("GenerateContentStream() error = %v, want *ValidationError", err)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("server received %d requests, want none", requests)
+	}
+}